@@ -4,11 +4,14 @@ import (
 	"embed"
 	"fmt"
 	"net/http"
+	"time"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	config "github.com/minilikmila/standard-auth-go/configs"
 	"github.com/minilikmila/standard-auth-go/internal/api/routes"
 	jwt_ "github.com/minilikmila/standard-auth-go/internal/auth/jwt"
+	"github.com/minilikmila/standard-auth-go/internal/auth/policy"
+	"github.com/minilikmila/standard-auth-go/internal/auth/ratelimit"
 	"github.com/minilikmila/standard-auth-go/internal/infrastructure/database"
 	"github.com/minilikmila/standard-auth-go/internal/service"
 	"github.com/sirupsen/logrus"
@@ -36,19 +39,36 @@ func Run(files embed.FS, args map[string]string) {
 	repo := database.NewRepository(db.DB())
 
 	// Initialize JWT service
-	jwtService := jwt_.NewJWTService(cfg)
+	jwtService, err := jwt_.NewJWTService(cfg)
+	if err != nil {
+		logrus.Fatalf("Failed to load signing keys: %v", err)
+	}
+	go jwtService.WatchKeys(cfg.JWT.KeyReloadInterval * time.Second)
 
 	// Initialize email service (you'll need to implement this)
 	emailService := service.NewEmailService(cfg)
 
 	// Initialize SMS service (you'll need to implement this)
-	smsService := service.NewSMSService(cfg)
+	smsService := service.NewSMSService(cfg, mode == "debug")
 
 	// Initialize auth service
 	authService := service.NewAuthService(repo, cfg, emailService, smsService, jwtService)
 
+	// Load the attribute based policies, if any
+	var policies *policy.Engine
+	if cfg.PolicyFile != "" {
+		if policies, err = policy.Load(cfg.PolicyFile); err != nil {
+			logrus.Fatalf("Failed to load policies: %v", err)
+		}
+		go policies.Watch(cfg.PolicyReloadInterval * time.Second)
+	}
+
+	// Rate limit buckets, kept in memory
+	limiter := ratelimit.NewMemoryStore()
+	go limiter.Sweep(time.Minute)
+
 	// Initialize routes with all services
-	routes := routes.InitRoute(repo, cfg, mode, authService)
+	routes := routes.InitRoute(repo, cfg, mode, authService, policies, limiter)
 
 	host := fmt.Sprintf("%s:%v", "0.0.0.0", cfg.Port)
 	logrus.WithField("host", "http://"+host).Info("Started Go authentication server")
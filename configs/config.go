@@ -2,68 +2,177 @@ package configs
 
 import (
 	"encoding/json"
+	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/minilikmila/standard-auth-go/internal/auth/crypto"
 )
 
 type JWTConfig struct {
-	Aud            string        `json:"audience"`
-	Alg            string        `json:"algorithm"`
-	Exp            time.Duration `json:"expiry"`
-	Iss            string        `json:"issuer"`
-	PrivateKeyPath string        `json:"private_key_path"`
-	PublicKeyPath  string        `json:"public_key_path"`
-	Secret         string        `json:"secret"`
-	Type           string        `json:"-"`
-	privateKey     interface{}
-	publicKey      interface{}
+	Aud                  string        `json:"audience"`
+	Alg                  string        `json:"algorithm"`
+	Exp                  time.Duration `json:"expiry"`
+	RefreshExp           time.Duration `json:"refresh_expiry"`
+	Iss                  string        `json:"issuer"`
+	PrivateKeyPath       string        `json:"private_key_path"`
+	PublicKeyPath        string        `json:"public_key_path"`
+	KeyID                string        `json:"key_id"`                 // kid header, defaults to the RFC 7638 thumbprint of the public key
+	VerificationKeyPaths []string      `json:"verification_key_paths"` // extra public keys still trusted, e.g. the previous signing key
+	KeysDir              string        `json:"keys_dir"`               // rotated key set, the key pair above seeds it on first start
+	KeyReloadInterval    time.Duration `json:"key_reload_interval"`    // seconds between reloads of keys_dir
+	Secret               string        `json:"secret"`
+	Type                 string        `json:"-"`
+	privateKey           interface{}
+	publicKey            interface{}
+	verificationKeys     [][]byte
 }
 
+// LockoutPolicy locks password login after Attempts failures in a row for For minutes. Every further failure
+// doubles the lock, up to MaxFor minutes. Zero attempts turns lockout off.
 type LockoutPolicy struct {
 	Attempts int           `json:"attempts"`
 	For      time.Duration `json:"for"`
+	MaxFor   time.Duration `json:"max_for"`
+}
+
+// PasswordPolicy is checked whenever a password is set. MaxLength counts bytes, bcrypt can't hash more than 72.
+// BreachedDir holds an offline k-anonymity corpus: one file per upper case 5 character SHA-1 prefix, each line the
+// remaining 35 characters optionally followed by ":count" (the layout of the Have I Been Pwned range API).
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	// DisallowPersonalInfo rejects passwords containing the email's local part or a word of the name
+	DisallowPersonalInfo bool   `json:"disallow_personal_info"`
+	History              int    `json:"history"` // last passwords that can't be reused, 0 turns it off
+	BreachedDir          string `json:"breached_dir"`
+}
+
+// RateLimit allows Requests per Window seconds from one client IP and IdentifierRequests per Window for one
+// email or phone number. Zero turns either off.
+type RateLimit struct {
+	Requests           int           `json:"requests"`
+	IdentifierRequests int           `json:"identifier_requests"`
+	Window             time.Duration `json:"window"`
+}
+
+type MFAConfig struct {
+	Issuer        string   `json:"issuer"`
+	Required      bool     `json:"required"`
+	RequiredRoles []string `json:"required_roles"`
+	EncryptionKey string   `json:"encryption_key"` // secret TOTP secrets are encrypted with at rest, required
+}
+
+// OIDCProviderConfig turns the service into an OpenID Connect provider for other apps
+type OIDCProviderConfig struct {
+	Enabled       bool          `json:"enabled"`
+	Issuer        string        `json:"issuer"`          // defaults to instance_url
+	LoginURL      string        `json:"login_url"`       // app page that signs the user in and returns to return_to
+	ConsentURL    string        `json:"consent_url"`     // app page that asks the user to approve a client
+	CodeExpiry    time.Duration `json:"code_expiry"`     // seconds
+	IDTokenExpiry time.Duration `json:"id_token_expiry"` // minutes
+}
+
+// SMSOTPConfig controls one-time passcode login by SMS
+type SMSOTPConfig struct {
+	Enabled         bool          `json:"enabled"`         // sign in with a texted code, needs phone support
+	Expiry          time.Duration `json:"expiry"`          // minutes
+	ResendCooldown  time.Duration `json:"resend_cooldown"` // seconds
+	MaxAttempts     int           `json:"max_attempts"`
+	MaxSendsPerHour int           `json:"max_sends_per_hour"`
+	HashKey         string        `json:"hash_key"` // secret login codes are hashed with, required when enabled
+}
+
+type WebAuthnConfig struct {
+	Enabled       bool     `json:"enabled"`
+	RPID          string   `json:"rp_id"`
+	RPDisplayName string   `json:"rp_display_name"`
+	RPOrigins     []string `json:"rp_origins"`
 }
 
 type SocialAuthConfig struct {
 	ClientID string `json:"client_id"`
 	SecretID string `json:"secret_id"`
 	Enabled  bool   `json:"enabled"`
+	// Optional endpoint overrides, the provider defaults are used when empty (e.g. point them at a local fake IdP)
+	Issuer      string   `json:"issuer"`
+	AuthURL     string   `json:"auth_url"`
+	TokenURL    string   `json:"token_url"`
+	JWKSURL     string   `json:"jwks_url"`
+	UserInfoURL string   `json:"userinfo_url"`
+	Scopes      []string `json:"scopes"`
+}
+
+// CustomOIDCConfig registers an extra OpenID Connect provider (e.g. a corporate IdP) by config alone.
+// Endpoints left empty are discovered from the issuer.
+type CustomOIDCConfig struct {
+	Name string `json:"name"`
+	SocialAuthConfig
 }
 
 type Config struct {
-	Host                     string           `json:"host"`
-	Port                     int              `json:"port"`
-	DatabaseUri              string           `json:"database_uri"`
-	InstanceUrl              string           `json:"instance_url"`
-	DisableSignup            bool             `json:"disable_signup"`
-	DisableEmail             bool             `json:"disable_email"`
-	DisablePhone             bool             `json:"disable_phone"`
-	GeneieApiKey             string           `json:"genie_api_key"`
-	CloudinaryCloudName      string           `json:"cloudinary_cloud_name"`
-	CloudinaryKey            string           `json:"cloudinary_key"`
-	CloudinarySecret         string           `json:"cloudinary_secret"`
-	AccessTokenCookieName    string           `json:"access_token_cookie_name"`
-	AccessTokenCookieDomain  string           `json:"access_token_cookie_domain"`
-	RefreshTokenCookieName   string           `json:"refresh_token_cookie_name"`
-	RefreshTokenCookieDomain string           `json:"refresh_token_cookie_domain"`
-	SessionCookieName        string           `json:"session_cookie_name"`
-	SessionCookieDomain      string           `json:"session_cookie_domain"`
-	JWT                      JWTConfig        `json:"jwt"`
-	Google                   SocialAuthConfig `json:"google"`
-	Github                   SocialAuthConfig `json:"github"`
-	Linkedin                 SocialAuthConfig `json:"linkedin"`
-	Facebook                 SocialAuthConfig `json:"facebook"`
-	Apple                    SocialAuthConfig `json:"apple"`
-	Twitter                  SocialAuthConfig `json:"twitter"`
-	Slack                    SocialAuthConfig `json:"slack"`
-	Discord                  SocialAuthConfig `json:"discord"`
-	SocialAuthRedirectUrl    string           `json:"social_auth_redirect_url"`
-	MaxConnectionPoolSize    int              `json:"max_connection_pool_size"`
-	LockoutPolicy            LockoutPolicy    `json:"lockout_policy"`
-	AdminRoles               []string         `json:"admin_roles"`
-	ReadOnlyRoles            []string         `json:"read_only_roles"`
+	Host                     string             `json:"host"`
+	Port                     int                `json:"port"`
+	DatabaseUri              string             `json:"database_uri"`
+	InstanceUrl              string             `json:"instance_url"`
+	DisableSignup            bool               `json:"disable_signup"`
+	DisableEmail             bool               `json:"disable_email"`
+	DisablePhone             bool               `json:"disable_phone"`
+	GeneieApiKey             string             `json:"genie_api_key"`
+	CloudinaryCloudName      string             `json:"cloudinary_cloud_name"`
+	CloudinaryKey            string             `json:"cloudinary_key"`
+	CloudinarySecret         string             `json:"cloudinary_secret"`
+	AccessTokenCookieName    string             `json:"access_token_cookie_name"`
+	AccessTokenCookieDomain  string             `json:"access_token_cookie_domain"`
+	RefreshTokenCookieName   string             `json:"refresh_token_cookie_name"`
+	RefreshTokenCookieDomain string             `json:"refresh_token_cookie_domain"`
+	SessionCookieName        string             `json:"session_cookie_name"`
+	SessionCookieDomain      string             `json:"session_cookie_domain"`
+	JWT                      JWTConfig          `json:"jwt"`
+	Google                   SocialAuthConfig   `json:"google"`
+	Github                   SocialAuthConfig   `json:"github"`
+	Linkedin                 SocialAuthConfig   `json:"linkedin"`
+	Facebook                 SocialAuthConfig   `json:"facebook"`
+	Apple                    SocialAuthConfig   `json:"apple"`
+	Twitter                  SocialAuthConfig   `json:"twitter"`
+	Slack                    SocialAuthConfig   `json:"slack"`
+	Discord                  SocialAuthConfig   `json:"discord"`
+	CustomOIDC               []CustomOIDCConfig `json:"custom_oidc"`
+	// AutoLinkVerifiedEmail lets a provider sign into an existing account whose email it reports as verified, as long
+	// as the account verified that email too.
+	// When off, the user has to sign in first and link the provider explicitly.
+	AutoLinkVerifiedEmail bool               `json:"auto_link_verified_email"`
+	OIDC                  OIDCProviderConfig `json:"oidc_provider"`
+	SocialAuthRedirectUrl string             `json:"social_auth_redirect_url"`
+	MaxConnectionPoolSize int                `json:"max_connection_pool_size"`
+	LockoutPolicy         LockoutPolicy      `json:"lockout_policy"`
+	PasswordPolicy        PasswordPolicy     `json:"password_policy"`
+	// Throttling of the /auth routes keyed by their path without the prefix, e.g. "login" or "otp/verify", and of
+	// "oauth/token". Configured routes replace their default, a zero window turns one off.
+	RateLimits      map[string]RateLimit `json:"rate_limits"`
+	MFA             MFAConfig            `json:"mfa"`
+	MagicLinkExp    time.Duration        `json:"magic_link_expiry"` // minutes
+	OrgInviteExpiry time.Duration        `json:"org_invite_expiry"` // hours
+	SMSOTP          SMSOTPConfig         `json:"sms_otp"`
+	WebAuthn        WebAuthnConfig       `json:"webauthn"`
+	// Roles seeded at migration, admin roles get every permission and read-only roles every ":read" one.
+	// Seeded roles are managed through the roles API afterwards.
+	AdminRoles      []string            `json:"admin_roles"`
+	RolePermissions map[string][]string `json:"role_permissions"` // default permissions of extra roles
+	ReadOnlyRoles   []string            `json:"read_only_roles"`
+	// Attribute based rules for Authorize, reread every policy_reload_interval seconds
+	PolicyFile           string        `json:"policy_file"`
+	PolicyReloadInterval time.Duration `json:"policy_reload_interval"`
+	// Header naming the tenant whose overrides apply, requests without it are matched to a tenant by host
+	TenantHeader string `json:"tenant_header"`
+	// IPs or CIDRs of the reverse proxies whose X-Forwarded-For is believed, with none the peer address is the
+	// client IP that rate limits, policies and device logs see
+	TrustedProxies []string `json:"trusted_proxies"`
 	// Email service
 	SMTPHost    string `json:"smtp_host"`
 	SMTPPort    string `json:"smtp_port"`
@@ -84,13 +193,56 @@ func DefaultConfig() *Config {
 		DisableEmail:          false,
 		DisablePhone:          true,
 		SessionCookieName:     "genie_session",
+		AdminRoles:            []string{"admin"},
+		PolicyReloadInterval:  60,
+		TenantHeader:          "X-Tenant",
 		JWT: JWTConfig{
-			Exp: 2000,
-			Alg: "RS512",
+			Exp:               2000,
+			RefreshExp:        43200, // 30 days
+			Alg:               "RS512",
+			KeyReloadInterval: 60,
 		},
 		LockoutPolicy: LockoutPolicy{
 			Attempts: 10,
 			For:      60,
+			MaxFor:   1440, // a day
+		},
+		PasswordPolicy: PasswordPolicy{
+			MinLength:            8,
+			MaxLength:            72,
+			DisallowPersonalInfo: true,
+			History:              3,
+		},
+		RateLimits: map[string]RateLimit{
+			"sign-up":               {Requests: 10, IdentifierRequests: 3, Window: 3600},
+			"login":                 {Requests: 30, IdentifierRequests: 10, Window: 300},
+			"verify-email":          {Requests: 20, Window: 900},
+			"verify-phone":          {Requests: 10, IdentifierRequests: 5, Window: 900},
+			"forgot-password":       {Requests: 10, IdentifierRequests: 3, Window: 3600},
+			"reset-password":        {Requests: 20, Window: 900},
+			"unlock":                {Requests: 20, Window: 900},
+			"magic-link":            {Requests: 10, IdentifierRequests: 3, Window: 3600},
+			"magic-link/verify":     {Requests: 20, Window: 900},
+			"otp":                   {Requests: 10, IdentifierRequests: 5, Window: 3600},
+			"otp/verify":            {Requests: 20, IdentifierRequests: 5, Window: 900},
+			"mfa/verify":            {Requests: 20, Window: 900},
+			"mfa/enroll":            {Requests: 20, Window: 900},
+			"mfa/enroll/confirm":    {Requests: 20, Window: 900},
+			"webauthn/login/begin":  {Requests: 30, IdentifierRequests: 10, Window: 300},
+			"webauthn/login/finish": {Requests: 30, Window: 300},
+			"oauth/token":           {Requests: 60, Window: 60},
+		},
+		MagicLinkExp:    10,
+		OrgInviteExpiry: 72,
+		OIDC: OIDCProviderConfig{
+			CodeExpiry:    60,
+			IDTokenExpiry: 60,
+		},
+		SMSOTP: SMSOTPConfig{
+			Expiry:          5,
+			ResendCooldown:  60,
+			MaxAttempts:     5,
+			MaxSendsPerHour: 5,
 		},
 		Google:                SocialAuthConfig{Enabled: false},
 		Github:                SocialAuthConfig{Enabled: false},
@@ -125,7 +277,14 @@ func New(path string) (*Config, error) {
 		return nil, err
 	}
 
-	if config.JWT.privateKey, config.JWT.publicKey, err = crypto.ReadKeys(config.JWT.PrivateKeyPath, config.JWT.PublicKeyPath); err != nil {
+	// With a key directory the configured pair is optional, it only seeds the directory
+	if config.JWT.KeysDir == "" || config.JWT.PrivateKeyPath != "" {
+		if config.JWT.privateKey, config.JWT.publicKey, err = crypto.ReadKeys(config.JWT.PrivateKeyPath, config.JWT.PublicKeyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.JWT.verificationKeys, err = crypto.ReadPublicKeys(config.JWT.VerificationKeyPaths); err != nil {
 		return nil, err
 	}
 
@@ -149,7 +308,33 @@ func validateSocial(config *Config) error {
 	if config.Apple.Enabled && (config.Apple.ClientID == "" || config.Apple.SecretID == "") {
 		return ErrAppleConfig
 	}
-	// ...will continue
+
+	if config.Github.Enabled && (config.Github.ClientID == "" || config.Github.SecretID == "") {
+		return ErrGithubConfig
+	}
+
+	if config.Twitter.Enabled && (config.Twitter.ClientID == "" || config.Twitter.SecretID == "") {
+		return ErrTwitterConfig
+	}
+
+	if config.Slack.Enabled && (config.Slack.ClientID == "" || config.Slack.SecretID == "") {
+		return ErrSlackConfig
+	}
+
+	if config.Discord.Enabled && (config.Discord.ClientID == "" || config.Discord.SecretID == "") {
+		return ErrDiscordConfig
+	}
+
+	names := map[string]bool{}
+	for _, custom := range config.CustomOIDC {
+		if !custom.Enabled {
+			continue
+		}
+		if custom.Name == "" || custom.Issuer == "" || custom.ClientID == "" || names[custom.Name] {
+			return ErrCustomOIDCConfig
+		}
+		names[custom.Name] = true
+	}
 	return nil
 }
 
@@ -163,6 +348,41 @@ func validateCommon(config *Config) error {
 		return ErrPhoneEmailDisabled
 	}
 
+	if config.SMSOTP.Enabled && config.SMSOTP.HashKey == "" {
+		return ErrSMSOTPConfig
+	}
+
+	if config.MFA.EncryptionKey == "" {
+		return ErrMFAConfig
+	}
+
+	for _, proxy := range config.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return ErrTrustedProxiesConfig
+			}
+		}
+	}
+
+	if config.WebAuthn.Enabled && (config.WebAuthn.RPID == "" || len(config.WebAuthn.RPOrigins) == 0) {
+		return ErrWebAuthnConfig
+	}
+
+	// Only asymmetric keys can be published ahead of use and rotated without a shared secret changing hands
+	if config.JWT.KeysDir != "" && strings.HasPrefix(config.JWT.Alg, "HS") {
+		return ErrKeyRotationConfig
+	}
+
+	if config.OIDC.Enabled {
+		// ID tokens must be verifiable by clients, a shared HMAC secret can't be published
+		if config.OIDC.LoginURL == "" || config.OIDC.ConsentURL == "" || strings.HasPrefix(config.JWT.Alg, "HS") {
+			return ErrOIDCProviderConfig
+		}
+		if config.OIDC.Issuer == "" {
+			config.OIDC.Issuer = config.InstanceUrl
+		}
+	}
+
 	return nil
 }
 
@@ -173,3 +393,8 @@ func (j *JWTConfig) GetSignKey() interface{} {
 func (j *JWTConfig) GetDecodeKey() interface{} {
 	return j.publicKey
 }
+
+// GetVerificationKeys returns the PEM encoded extra public keys
+func (j *JWTConfig) GetVerificationKeys() [][]byte {
+	return j.verificationKeys
+}
@@ -0,0 +1,97 @@
+package configs
+
+import "testing"
+
+func TestValidateCommonTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    error
+	}{
+		{"none", nil, nil},
+		{"ips", []string{"127.0.0.1", "::1"}, nil},
+		{"cidrs", []string{"10.0.0.0/8", "fd00::/8"}, nil},
+		{"hostname", []string{"proxy.internal"}, ErrTrustedProxiesConfig},
+		{"bad cidr", []string{"10.0.0.0/33"}, ErrTrustedProxiesConfig},
+		{"empty entry", []string{""}, ErrTrustedProxiesConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.DatabaseUri = "postgres://localhost/auth"
+			config.MFA.EncryptionKey = "a-long-random-secret"
+			config.TrustedProxies = tt.proxies
+			if err := validateCommon(config); err != tt.want {
+				t.Errorf("validateCommon = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCommonSMSHashKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		hashKey string
+		want    error
+	}{
+		{"sms login off", false, "", nil},
+		{"sms login on with key", true, "a-long-random-secret", nil},
+		{"sms login on without key", true, "", ErrSMSOTPConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.DatabaseUri = "postgres://localhost/auth"
+			config.MFA.EncryptionKey = "a-long-random-secret"
+			config.DisablePhone = false
+			config.SMSOTP.Enabled = tt.enabled
+			config.SMSOTP.HashKey = tt.hashKey
+			if err := validateCommon(config); err != tt.want {
+				t.Errorf("validateCommon = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCommonMFAEncryptionKey(t *testing.T) {
+	config := DefaultConfig()
+	config.DatabaseUri = "postgres://localhost/auth"
+	if err := validateCommon(config); err != ErrMFAConfig {
+		t.Errorf("validateCommon without an mfa encryption key = %v, want %v", err, ErrMFAConfig)
+	}
+}
+
+func TestValidateSocialCustomOIDC(t *testing.T) {
+	corp := func(name string) CustomOIDCConfig {
+		return CustomOIDCConfig{Name: name, SocialAuthConfig: SocialAuthConfig{Enabled: true, Issuer: "https://idp.corp.example.com", ClientID: "client-id"}}
+	}
+	disabled := CustomOIDCConfig{Name: "old"}
+	noIssuer := corp("corp")
+	noIssuer.Issuer = ""
+	noClient := corp("corp")
+	noClient.ClientID = ""
+
+	tests := []struct {
+		name   string
+		custom []CustomOIDCConfig
+		want   error
+	}{
+		{"none", nil, nil},
+		{"complete", []CustomOIDCConfig{corp("corp"), corp("partner")}, nil},
+		{"disabled and incomplete", []CustomOIDCConfig{disabled, disabled}, nil},
+		{"no name", []CustomOIDCConfig{corp("")}, ErrCustomOIDCConfig},
+		{"no issuer", []CustomOIDCConfig{noIssuer}, ErrCustomOIDCConfig},
+		{"no client id", []CustomOIDCConfig{noClient}, ErrCustomOIDCConfig},
+		{"duplicate name", []CustomOIDCConfig{corp("corp"), corp("corp")}, ErrCustomOIDCConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.CustomOIDC = tt.custom
+			if err := validateSocial(config); err != tt.want {
+				t.Errorf("validateSocial = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
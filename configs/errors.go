@@ -11,6 +11,10 @@ var ErrFacebookConfig = errors.New("expected facebook_client_id, facebook_client
 var ErrGithubConfig = errors.New("expected github_client_id, github_client_secret to be set if github provider is enabled")
 var ErrLinkedinConfig = errors.New("expected linkedin_client_id, linkedin_client_secret to be set if linkedin provider is enabled")
 var ErrAppleConfig = errors.New("expected apple_client_id, apple_client_secret to be set if apple provider is enabled")
+var ErrTwitterConfig = errors.New("expected twitter_client_id, twitter_client_secret to be set if twitter provider is enabled")
+var ErrSlackConfig = errors.New("expected slack_client_id, slack_client_secret to be set if slack provider is enabled")
+var ErrDiscordConfig = errors.New("expected discord_client_id, discord_client_secret to be set if discord provider is enabled")
+var ErrCustomOIDCConfig = errors.New("expected name, issuer and client_id to be set for every custom oidc provider, names must be unique")
 var ErrDatabaseURIRequired = errors.New("expected database uri")
 var ErrPhoneEmailDisabled = errors.New("can't disable email and phone at the same time")
 var ErrSMSNotConfigured = errors.New("expected sms to be set if phone support is enabled")
@@ -18,3 +22,9 @@ var ErrEmailNotConfigured = errors.New("expected smtp to be set if email support
 var ErrParsingPrivateKey = errors.New("unable to parse private key")
 var ErrParsingPublicKey = errors.New("unable to parse public key")
 var ErrInvalidCustomDataSchema = errors.New("invalid custom data schema")
+var ErrKeyRotationConfig = errors.New("expected an asymmetric jwt algorithm if jwt keys_dir is set")
+var ErrOIDCProviderConfig = errors.New("expected oidc_provider login_url and consent_url and an asymmetric jwt algorithm if the oidc provider is enabled")
+var ErrSMSOTPConfig = errors.New("expected sms_otp hash_key to be set if sms login is enabled")
+var ErrMFAConfig = errors.New("expected mfa encryption_key to be set")
+var ErrWebAuthnConfig = errors.New("expected webauthn rp_id and rp_origins to be set if webauthn is enabled")
+var ErrTrustedProxiesConfig = errors.New("expected every trusted_proxies entry to be an ip or cidr")
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondAdminUserError maps admin user management errors to responses
+func respondAdminUserError(ctx *gin.Context, err error, message string) {
+	if respondPasswordPolicy(ctx, err) {
+		return
+	}
+	switch err {
+	case service.ErrUserNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrUserAlreadyExists:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	case service.ErrInvalidName, service.ErrNoContact, service.ErrInvalidStatus:
+		ctx.JSON(http.StatusBadRequest, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		})
+	case service.ErrCannotModifySelf:
+		ctx.JSON(http.StatusForbidden, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusForbidden,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// adminFromContext resolves the signed in admin, answering 401 when there is none
+func adminFromContext(ctx *gin.Context) (*model.UserCtxData, bool) {
+	admin, err := utils.GetUserContext(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, model.Response{
+			Message:    "Unauthorized",
+			StatusCode: http.StatusUnauthorized,
+		})
+		return nil, false
+	}
+	return admin, true
+}
+
+// ListUsers lists a page of users, filtered by the query string
+func ListUsers(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var filter model.UserFilter
+		var page model.Pagination
+		if err := ctx.ShouldBindQuery(&filter); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid filter",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+		if err := ctx.ShouldBindQuery(&page); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid page",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		users, err := authService.ListUsers(ctx, filter, page)
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error listing users")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Users retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data:       users,
+		})
+	}
+}
+
+// GetUser returns any user, soft deleted ones included
+func GetUser(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := authService.GetUser(ctx, ctx.Param("user_id"))
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error retrieving user")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "User retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"user": user,
+			},
+		})
+	}
+}
+
+// AdminCreateUser creates an account on someone's behalf
+func AdminCreateUser(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var body model.AdminUserForm
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		user, err := authService.AdminCreateUser(ctx, admin.ID, body)
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error creating user")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "User created",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"user": user,
+			},
+		})
+	}
+}
+
+// UpdateUserRole changes a user's primary role
+func UpdateUserRole(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			Role string `json:"role" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		user, err := authService.UpdateUserRole(ctx, admin.ID, ctx.Param("user_id"), body.Role)
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error updating role")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Role updated",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"user": user,
+			},
+		})
+	}
+}
+
+// VerifyUser marks a user's email and/or phone verified
+func VerifyUser(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			Email bool `json:"email"`
+			Phone bool `json:"phone"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil || (!body.Email && !body.Phone) {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Choose email and/or phone to verify",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		user, err := authService.VerifyUser(ctx, admin.ID, ctx.Param("user_id"), body.Email, body.Phone)
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error verifying user")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "User verified",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"user": user,
+			},
+		})
+	}
+}
+
+// SendUserPasswordReset emails a user a password reset link
+func SendUserPasswordReset(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		if err := authService.SendUserPasswordReset(ctx, admin.ID, ctx.Param("user_id")); err != nil {
+			respondAdminUserError(ctx, err, "Error sending password reset")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Password reset email sent",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// DeleteUser soft deletes a user
+func DeleteUser(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		if err := authService.DeleteUser(ctx, admin.ID, ctx.Param("user_id")); err != nil {
+			respondAdminUserError(ctx, err, "Error deleting user")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "User deleted",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// RestoreUser restores a soft deleted user
+func RestoreUser(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		user, err := authService.RestoreUser(ctx, admin.ID, ctx.Param("user_id"))
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error restoring user")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "User restored",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"user": user,
+			},
+		})
+	}
+}
+
+// SetUserStatus activates, suspends, bans or schedules the deletion of a user
+func SetUserStatus(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			Status string     `json:"status" binding:"required"`
+			Reason string     `json:"reason"`
+			Until  *time.Time `json:"until"` // end of a suspension
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		user, err := authService.SetUserStatus(ctx, admin.ID, ctx.Param("user_id"), body.Status, body.Reason, body.Until)
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error changing account status")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Account status updated",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"user": user,
+			},
+		})
+	}
+}
+
+// UnlockUser lifts a user's login lock
+func UnlockUser(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		admin, ok := adminFromContext(ctx)
+		if !ok {
+			return
+		}
+
+		user, err := authService.UnlockUser(ctx, admin.ID, ctx.Param("user_id"))
+		if err != nil {
+			respondAdminUserError(ctx, err, "Error unlocking user")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "User unlocked",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"user": user,
+			},
+		})
+	}
+}
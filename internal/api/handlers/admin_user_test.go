@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// adminUserService fails every call with err and records the listing it was asked for
+type adminUserService struct {
+	service.AuthService
+	err    error
+	filter model.UserFilter
+	page   model.Pagination
+}
+
+func (s *adminUserService) user() (*model.User, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	id := uuid.New()
+	return &model.User{ID: &id}, nil
+}
+
+func (s *adminUserService) ListUsers(ctx context.Context, filter model.UserFilter, page model.Pagination) (*model.UserPage, error) {
+	s.filter, s.page = filter, page
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.UserPage{Users: []model.User{}, Page: page.Page, PerPage: page.PerPage}, nil
+}
+
+func (s *adminUserService) GetUser(ctx context.Context, userID string) (*model.User, error) {
+	return s.user()
+}
+
+func (s *adminUserService) AdminCreateUser(ctx context.Context, adminID string, form model.AdminUserForm) (*model.User, error) {
+	return s.user()
+}
+
+func (s *adminUserService) UpdateUserRole(ctx context.Context, adminID, userID, role string) (*model.User, error) {
+	return s.user()
+}
+
+func (s *adminUserService) VerifyUser(ctx context.Context, adminID, userID string, email, phone bool) (*model.User, error) {
+	return s.user()
+}
+
+func (s *adminUserService) SendUserPasswordReset(ctx context.Context, adminID, userID string) error {
+	return s.err
+}
+
+func (s *adminUserService) SetUserStatus(ctx context.Context, adminID, userID, status, reason string, until *time.Time) (*model.User, error) {
+	return s.user()
+}
+
+func (s *adminUserService) DeleteUser(ctx context.Context, adminID, userID string) error {
+	return s.err
+}
+
+func (s *adminUserService) RestoreUser(ctx context.Context, adminID, userID string) (*model.User, error) {
+	return s.user()
+}
+
+func TestListUsersQuery(t *testing.T) {
+	authService := &adminUserService{}
+	call := request{method: http.MethodGet, route: "/admin/users",
+		path: "/admin/users?email=jane&role=editor&email_verified=true&signup_method=google&created_after=2024-01-01T00:00:00Z&deleted=true&page=2&per_page=50"}
+	recorder := call.serve(t, ListUsers(authService))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	filter := authService.filter
+	if filter.Email != "jane" || filter.Role != "editor" || filter.SignUpMethod != "google" || !filter.Deleted {
+		t.Errorf("filter = %+v", filter)
+	}
+	if filter.EmailVerified == nil || !*filter.EmailVerified || filter.PhoneVerified != nil {
+		t.Errorf("verification filter = %v %v, want only email verified", filter.EmailVerified, filter.PhoneVerified)
+	}
+	if filter.CreatedAfter == nil || !filter.CreatedAfter.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || filter.CreatedBefore != nil {
+		t.Errorf("created range = %v %v", filter.CreatedAfter, filter.CreatedBefore)
+	}
+	if authService.page != (model.Pagination{Page: 2, PerPage: 50}) {
+		t.Errorf("page = %+v", authService.page)
+	}
+
+	for _, query := range []string{"email_verified=maybe", "created_before=yesterday", "page=first", "per_page=-"} {
+		call := request{method: http.MethodGet, route: "/admin/users", path: "/admin/users?" + query}
+		if recorder := call.serve(t, ListUsers(&adminUserService{})); recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, recorder.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestAdminUserRoutes(t *testing.T) {
+	admin := &model.UserCtxData{ID: uuid.NewString(), Roles: []string{"admin"}}
+	userPath := "/admin/users/" + uuid.NewString()
+	list := request{method: http.MethodGet, route: "/admin/users", path: "/admin/users"}
+	view := request{method: http.MethodGet, route: "/admin/users/:user_id", path: userPath}
+	create := request{method: http.MethodPost, route: "/admin/users", path: "/admin/users", body: `{"name":"Jane","email":"jane@example.com"}`}
+	role := request{method: http.MethodPut, route: "/admin/users/:user_id/role", path: userPath + "/role", body: `{"role":"editor"}`}
+	verify := request{method: http.MethodPost, route: "/admin/users/:user_id/verify", path: userPath + "/verify", body: `{"email":true}`}
+	reset := request{method: http.MethodPost, route: "/admin/users/:user_id/password-reset", path: userPath + "/password-reset"}
+	remove := request{method: http.MethodDelete, route: "/admin/users/:user_id", path: userPath}
+	restore := request{method: http.MethodPost, route: "/admin/users/:user_id/restore", path: userPath + "/restore"}
+	status := request{method: http.MethodPut, route: "/admin/users/:user_id/status", path: userPath + "/status", body: `{"status":"suspended","reason":"spam","until":"2030-01-01T00:00:00Z"}`}
+
+	tests := []struct {
+		name    string
+		call    request
+		handler func(service.AuthService) gin.HandlerFunc
+		err     error
+		status  int
+	}{
+		{"list failure", list, ListUsers, errors.New("store down"), http.StatusInternalServerError},
+		{"view", view, GetUser, nil, http.StatusOK},
+		{"view unknown user", view, GetUser, service.ErrUserNotFound, http.StatusNotFound},
+		{"create", create, AdminCreateUser, nil, http.StatusCreated},
+		{"create without email", request{method: http.MethodPost, route: create.route, path: create.path, body: `{"name":"Jane"}`}, AdminCreateUser, nil, http.StatusBadRequest},
+		{"create existing user", create, AdminCreateUser, service.ErrUserAlreadyExists, http.StatusConflict},
+		{"create with a bad role", create, AdminCreateUser, service.ErrInvalidName, http.StatusBadRequest},
+		{"role", role, UpdateUserRole, nil, http.StatusOK},
+		{"own role", role, UpdateUserRole, service.ErrCannotModifySelf, http.StatusForbidden},
+		{"verify", verify, VerifyUser, nil, http.StatusOK},
+		{"verify nothing", request{method: http.MethodPost, route: verify.route, path: verify.path, body: `{}`}, VerifyUser, nil, http.StatusBadRequest},
+		{"verify a missing phone", verify, VerifyUser, service.ErrNoContact, http.StatusBadRequest},
+		{"reset without a contact", reset, SendUserPasswordReset, service.ErrNoContact, http.StatusBadRequest},
+		{"delete", remove, DeleteUser, nil, http.StatusOK},
+		{"delete self", remove, DeleteUser, service.ErrCannotModifySelf, http.StatusForbidden},
+		{"restore", restore, RestoreUser, nil, http.StatusOK},
+		{"restore a live user", restore, RestoreUser, service.ErrUserNotFound, http.StatusNotFound},
+		{"status", status, SetUserStatus, nil, http.StatusOK},
+		{"status missing", request{method: http.MethodPut, route: status.route, path: status.path, body: `{"reason":"spam"}`}, SetUserStatus, nil, http.StatusBadRequest},
+		{"suspension end not a time", request{method: http.MethodPut, route: status.route, path: status.path, body: `{"status":"suspended","until":"tomorrow"}`}, SetUserStatus, nil, http.StatusBadRequest},
+		{"unknown status", status, SetUserStatus, service.ErrInvalidStatus, http.StatusBadRequest},
+		{"ban self", status, SetUserStatus, service.ErrCannotModifySelf, http.StatusForbidden},
+		{"status of an unknown user", status, SetUserStatus, service.ErrUserNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.call.user = admin
+			if recorder := tt.call.serve(t, tt.handler(&adminUserService{err: tt.err})); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+
+			// Listing and viewing are left to the route guards, every change is made in the admin's name
+			if tt.call.method == http.MethodGet {
+				return
+			}
+			tt.call.user = nil
+			if recorder := tt.call.serve(t, tt.handler(&adminUserService{})); recorder.Code != http.StatusUnauthorized {
+				t.Errorf("anonymous status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
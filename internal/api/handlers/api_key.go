@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondAPIKeyError maps API key errors to responses
+func respondAPIKeyError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrAPIKeyNotFound, service.ErrUserNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrPermissionDenied:
+		ctx.JSON(http.StatusForbidden, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusForbidden,
+		})
+	case service.ErrInvalidExpiry:
+		ctx.JSON(http.StatusBadRequest, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// CreateAPIKey issues a personal API key, the key itself is only shown in this response
+func CreateAPIKey(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Name        string     `json:"name" binding:"required"`
+			Permissions []string   `json:"permissions"`
+			ExpiresAt   *time.Time `json:"expires_at"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		apiKey, key, err := authService.CreateAPIKey(ctx, user.ID, body.Name, body.Permissions, body.ExpiresAt)
+		if err != nil {
+			respondAPIKeyError(ctx, err, "Error creating api key")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "API key created, store it now as it won't be shown again",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"api_key": key,
+				"key":     apiKey,
+			},
+		})
+	}
+}
+
+// ListAPIKeys lists the user's API keys
+func ListAPIKeys(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		keys, err := authService.ListAPIKeys(ctx, user.ID)
+		if err != nil {
+			respondAPIKeyError(ctx, err, "Error listing api keys")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "API keys retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"keys": keys,
+			},
+		})
+	}
+}
+
+// RevokeAPIKey revokes one of the user's API keys
+func RevokeAPIKey(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.RevokeAPIKey(ctx, user.ID, ctx.Param("key_id")); err != nil {
+			respondAPIKeyError(ctx, err, "Error revoking api key")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "API key revoked",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// request describes one call to a handler. The route is the gin pattern the handler is mounted on, so path
+// parameters resolve, user is what Authenticate would have put in the context. A body is sent as JSON unless
+// header says otherwise.
+type request struct {
+	method  string
+	route   string
+	path    string
+	body    string
+	user    *model.UserCtxData
+	cookies []*http.Cookie
+	header  http.Header
+}
+
+func (r request) serve(t *testing.T, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Handle(r.method, r.route, func(ctx *gin.Context) {
+		if r.user != nil {
+			data, err := json.Marshal(r.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx.Set("user_data", data)
+		}
+		ctx.Next()
+	}, handler)
+
+	req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+	if r.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	for _, c := range r.cookies {
+		req.AddCookie(c)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// decode reads the data of a model.Response
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, data interface{}) {
+	t.Helper()
+	body := struct {
+		Data interface{} `json:"data"`
+	}{Data: data}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response %s: %v", recorder.Body.String(), err)
+	}
+}
+
+// cookie returns the cookie the response set, nil when it set none
+func cookie(recorder *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range recorder.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
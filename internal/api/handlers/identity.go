@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondIdentityError maps identity linking errors to responses
+func respondIdentityError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrProviderDisabled:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    "Provider is not enabled",
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrIdentityNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    "Identity not found",
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrUserNotFound:
+		ctx.JSON(http.StatusUnauthorized, model.Response{
+			Message:    "Unauthorized",
+			StatusCode: http.StatusUnauthorized,
+		})
+	case service.ErrLastLoginMethod:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// ListIdentities lists the external identities linked to the current user
+func ListIdentities(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		identities, err := authService.ListIdentities(ctx, user.ID)
+		if err != nil {
+			respondIdentityError(ctx, err, "Error listing identities")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Identities retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"identities": identities,
+			},
+		})
+	}
+}
+
+// LinkIdentity starts a provider round trip that links the account to the current user. The browser has to be
+// sent to authorization_url, the callback then redirects back to the app with linked=<provider>.
+func LinkIdentity(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		authURL, state, err := authService.BeginOAuthLink(ctx, user.ID, ctx.Param("provider"))
+		if err != nil {
+			respondIdentityError(ctx, err, "Error starting identity link")
+			return
+		}
+
+		ctx.SetCookie(oauthStateCookie, state, 600, oauthStateCookiePath, "", false, true)
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Continue at the provider to link the account",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"authorization_url": authURL,
+			},
+		})
+	}
+}
+
+// UnlinkIdentity removes one of the current user's linked identities
+func UnlinkIdentity(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.UnlinkIdentity(ctx, user.ID, ctx.Param("identity_id")); err != nil {
+			respondIdentityError(ctx, err, "Error unlinking identity")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Identity unlinked successfully",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
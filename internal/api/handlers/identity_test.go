@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// identityService answers every identity call with err, and a link round trip with result
+type identityService struct {
+	service.AuthService
+	err    error
+	result *model.OAuthResult
+	issued bool
+}
+
+func (s *identityService) ListIdentities(ctx context.Context, userID string) ([]model.Identity, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []model.Identity{{Provider: "github", Subject: "42"}}, nil
+}
+
+func (s *identityService) BeginOAuthLink(ctx context.Context, userID, provider string) (string, string, error) {
+	return "https://idp.example.com/authorize", "state", s.err
+}
+
+func (s *identityService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	return s.err
+}
+
+func (s *identityService) CompleteOAuth(ctx context.Context, provider, state, code string) (*model.OAuthResult, error) {
+	return s.result, s.err
+}
+
+func (s *identityService) GenerateTokens(ctx context.Context, user *model.User) (string, string, error) {
+	s.issued = true
+	return "access", "refresh", nil
+}
+
+func TestIdentityRoutes(t *testing.T) {
+	user := &model.UserCtxData{ID: uuid.NewString()}
+	list := request{method: http.MethodGet, route: "/identities", path: "/identities"}
+	link := request{method: http.MethodPost, route: "/identities/:provider", path: "/identities/github"}
+	unlink := request{method: http.MethodDelete, route: "/identities/:identity_id", path: "/identities/" + uuid.NewString()}
+
+	tests := []struct {
+		name    string
+		call    request
+		handler func(service.AuthService) gin.HandlerFunc
+		err     error
+		status  int
+	}{
+		{"list", list, ListIdentities, nil, http.StatusOK},
+		{"list of a deleted user", list, ListIdentities, service.ErrUserNotFound, http.StatusUnauthorized},
+		{"link", link, LinkIdentity, nil, http.StatusOK},
+		{"link to a disabled provider", link, LinkIdentity, service.ErrProviderDisabled, http.StatusNotFound},
+		{"link failure", link, LinkIdentity, errors.New("store down"), http.StatusInternalServerError},
+		{"unlink", unlink, UnlinkIdentity, nil, http.StatusOK},
+		{"unlink someone else's identity", unlink, UnlinkIdentity, service.ErrIdentityNotFound, http.StatusNotFound},
+		{"unlink the last login method", unlink, UnlinkIdentity, service.ErrLastLoginMethod, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.call.user = user
+			recorder := tt.call.serve(t, tt.handler(&identityService{err: tt.err}))
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if state := cookie(recorder, oauthStateCookie); (state != nil) != (tt.call.path == link.path && tt.status == http.StatusOK) {
+				t.Errorf("state cookie = %+v, want it set only when a link starts", state)
+			}
+
+			tt.call.user = nil
+			if recorder := tt.call.serve(t, tt.handler(&identityService{})); recorder.Code != http.StatusUnauthorized {
+				t.Errorf("anonymous status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackLink(t *testing.T) {
+	id := uuid.New()
+	signedIn := &model.User{ID: &id}
+	state := []*http.Cookie{{Name: oauthStateCookie, Value: "state"}}
+
+	tests := []struct {
+		name     string
+		cookies  []*http.Cookie
+		service  *identityService
+		redirect url.Values
+		issued   bool
+	}{
+		{"linked", state, &identityService{result: &model.OAuthResult{User: signedIn, Linked: true}},
+			url.Values{"linked": {"github"}}, false},
+		{"identity of another user", state, &identityService{err: service.ErrIdentityInUse},
+			url.Values{"error": {"identity_in_use"}}, false},
+		{"email of another account", state, &identityService{err: service.ErrAccountExists},
+			url.Values{"error": {"account_exists"}}, false},
+		{"state of another browser", nil, &identityService{result: &model.OAuthResult{User: signedIn, Linked: true}},
+			url.Values{"error": {"invalid_state"}}, false},
+		{"signed in", state, &identityService{result: &model.OAuthResult{User: signedIn}},
+			url.Values{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := request{method: http.MethodGet, route: "/oauth/github/callback", path: "/oauth/github/callback?state=state&code=code", cookies: tt.cookies}
+			recorder := call.serve(t, OAuthCallback(tt.service, "github", "https://app.example.com"))
+			if recorder.Code != http.StatusFound {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusFound)
+			}
+			location, err := url.Parse(recorder.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := location.Query(); got.Encode() != tt.redirect.Encode() {
+				t.Errorf("redirected with %v, want %v", got, tt.redirect)
+			}
+			if tt.service.issued != tt.issued {
+				t.Errorf("tokens issued = %v, want %v", tt.service.issued, tt.issued)
+			}
+		})
+	}
+}
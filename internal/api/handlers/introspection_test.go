@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// tokenEndpointService records the client credentials and token it was called with
+type tokenEndpointService struct {
+	service.AuthService
+	response *model.IntrospectionResponse
+	err      error
+
+	clientID, clientSecret, token string
+}
+
+func (s *tokenEndpointService) IntrospectToken(ctx context.Context, clientID, clientSecret, token string) (*model.IntrospectionResponse, error) {
+	s.clientID, s.clientSecret, s.token = clientID, clientSecret, token
+	return s.response, s.err
+}
+
+func (s *tokenEndpointService) RevokeToken(ctx context.Context, clientID, clientSecret, token string) error {
+	s.clientID, s.clientSecret, s.token = clientID, clientSecret, token
+	return s.err
+}
+
+// tokenEndpointCall posts form to route, with the client credentials in basic auth when basic is set
+func tokenEndpointCall(route string, form url.Values, basic bool) request {
+	header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	if basic {
+		req, _ := http.NewRequest(http.MethodPost, route, nil)
+		req.SetBasicAuth("gateway", "secret")
+		header.Set("Authorization", req.Header.Get("Authorization"))
+	} else {
+		form.Set("client_id", "gateway")
+		form.Set("client_secret", "secret")
+	}
+	return request{method: http.MethodPost, route: route, path: route, body: form.Encode(), header: header}
+}
+
+func TestOAuthIntrospect(t *testing.T) {
+	active := &model.IntrospectionResponse{Active: true, Sub: "user", TokenType: "access_token"}
+
+	tests := []struct {
+		name      string
+		basic     bool
+		service   *tokenEndpointService
+		status    int
+		want      string
+		challenge bool
+	}{
+		{"active, basic auth", true, &tokenEndpointService{response: active}, http.StatusOK, "", false},
+		{"active, form credentials", false, &tokenEndpointService{response: active}, http.StatusOK, "", false},
+		{"inactive", true, &tokenEndpointService{response: &model.IntrospectionResponse{}}, http.StatusOK, "", false},
+		{"bad secret, basic auth", true, &tokenEndpointService{err: service.ErrInvalidClient}, http.StatusUnauthorized, "invalid_client", true},
+		{"bad secret, form credentials", false, &tokenEndpointService{err: service.ErrInvalidClient}, http.StatusUnauthorized, "invalid_client", false},
+		{"public client", true, &tokenEndpointService{err: service.ErrUnauthorizedClient}, http.StatusBadRequest, "unauthorized_client", false},
+		{"store failure", true, &tokenEndpointService{err: errors.New("store down")}, http.StatusInternalServerError, "server_error", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := tokenEndpointCall("/oauth/introspect", url.Values{"token": {"token"}}, tt.basic)
+			recorder := call.serve(t, OAuthIntrospect(tt.service))
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if tt.service.clientID != "gateway" || tt.service.clientSecret != "secret" || tt.service.token != "token" {
+				t.Errorf("service called with %q %q %q", tt.service.clientID, tt.service.clientSecret, tt.service.token)
+			}
+			if recorder.Header().Get("Cache-Control") != "no-store" {
+				t.Error("introspection response may be cached")
+			}
+			if challenge := recorder.Header().Get("WWW-Authenticate") != ""; challenge != tt.challenge {
+				t.Errorf("WWW-Authenticate sent = %v, want %v", challenge, tt.challenge)
+			}
+
+			var body struct {
+				Active bool   `json:"active"`
+				Sub    string `json:"sub"`
+				Error  string `json:"error"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error != tt.want {
+				t.Errorf("error = %q, want %q", body.Error, tt.want)
+			}
+			if tt.service.response != nil && (body.Active != tt.service.response.Active || body.Sub != tt.service.response.Sub) {
+				t.Errorf("response = %s, want %+v", recorder.Body.String(), tt.service.response)
+			}
+		})
+	}
+}
+
+func TestOAuthRevoke(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"revoked or already invalid", nil, http.StatusOK},
+		{"bad secret", service.ErrInvalidClient, http.StatusUnauthorized},
+		{"token of another client", service.ErrUnauthorizedClient, http.StatusBadRequest},
+		{"store failure", errors.New("store down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := &tokenEndpointService{err: tt.err}
+			call := tokenEndpointCall("/oauth/revoke", url.Values{"token": {"token"}, "token_type_hint": {"refresh_token"}}, false)
+			if recorder := call.serve(t, OAuthRevoke(authService)); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if authService.token != "token" {
+				t.Errorf("revoked %q, want the posted token", authService.token)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/validators"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// magicLinkNonceCookie binds a sign-in link to the browser that requested it
+	magicLinkNonceCookie = "magic_link_nonce"
+	magicLinkCookiePath  = "/api/v1/auth/magic-link"
+)
+
+// SendMagicLink emails a passwordless sign-in link and sets the browser nonce cookie
+func SendMagicLink(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			Email string `json:"email" binding:"required"`
+		}
+
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		if !validators.IsValidEmail(body.Email) {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid email format",
+				StatusCode: http.StatusBadRequest,
+				Error:      errors.New("Invalid email format"),
+			})
+			return
+		}
+
+		nonce, err := utils.GenerateRandomToken(32)
+		if err != nil {
+			log.Errorf("Error generating magic link nonce: %v", err)
+			ctx.JSON(http.StatusInternalServerError, model.Response{
+				Message:    "Error sending sign in link",
+				StatusCode: http.StatusInternalServerError,
+				Error:      err,
+			})
+			return
+		}
+
+		if err := authService.SendMagicLink(ctx, body.Email, nonce); err != nil {
+			switch err {
+			case service.ErrEmailDisabled:
+				ctx.JSON(http.StatusForbidden, model.Response{
+					Message:    "Email sign in is disabled",
+					StatusCode: http.StatusForbidden,
+				})
+			default:
+				log.Errorf("Error sending sign in link: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error sending sign in link",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		// Session cookie, the link itself expires server side
+		ctx.SetCookie(magicLinkNonceCookie, nonce, 0, magicLinkCookiePath, "", false, true)
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "If an account exists for this email, a sign in link has been sent",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// VerifyMagicLink exchanges a sign-in link token for an access/refresh pair
+func VerifyMagicLink(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			Token string `json:"token" binding:"required"`
+		}
+
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		nonce, _ := ctx.Cookie(magicLinkNonceCookie)
+
+		user, mfaToken, err := authService.VerifyMagicLink(ctx, body.Token, nonce)
+		if err != nil {
+			switch err {
+			case service.ErrInvalidToken, service.ErrUserNotFound:
+				ctx.JSON(http.StatusUnauthorized, model.Response{
+					Message:    "Invalid or expired sign in link",
+					StatusCode: http.StatusUnauthorized,
+				})
+			default:
+				log.Errorf("Error verifying sign in link: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error verifying sign in link",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		// The link is spent either way
+		ctx.SetCookie(magicLinkNonceCookie, "", -1, magicLinkCookiePath, "", false, true)
+
+		if mfaToken != "" {
+			respondMFARequired(ctx, user, mfaToken)
+			return
+		}
+
+		respondWithTokens(ctx, authService, user, "Login successful", nil)
+	}
+}
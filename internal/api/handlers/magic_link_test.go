@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// magicLinkService records the nonce each call carried
+type magicLinkService struct {
+	service.AuthService
+	err      error
+	mfaToken string
+	nonce    string
+	issued   bool
+}
+
+func (s *magicLinkService) SendMagicLink(ctx context.Context, email, nonce string) error {
+	s.nonce = nonce
+	return s.err
+}
+
+func (s *magicLinkService) VerifyMagicLink(ctx context.Context, token, nonce string) (*model.User, string, error) {
+	s.nonce = nonce
+	if s.err != nil {
+		return nil, "", s.err
+	}
+	id := uuid.New()
+	return &model.User{ID: &id}, s.mfaToken, nil
+}
+
+func (s *magicLinkService) GenerateTokens(ctx context.Context, user *model.User) (string, string, error) {
+	s.issued = true
+	return "access", "refresh", nil
+}
+
+func TestSendMagicLink(t *testing.T) {
+	send := func(body string) request {
+		return request{method: http.MethodPost, route: "/magic-link", path: "/magic-link", body: body}
+	}
+
+	authService := &magicLinkService{}
+	recorder := send(`{"email":"jane@example.com"}`).serve(t, SendMagicLink(authService))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	nonce := cookie(recorder, magicLinkNonceCookie)
+	if nonce == nil || nonce.Value == "" || nonce.Value != authService.nonce {
+		t.Fatalf("nonce cookie = %+v, want the nonce the link was bound to", nonce)
+	}
+	if !nonce.HttpOnly || nonce.Path != magicLinkCookiePath {
+		t.Errorf("nonce cookie = %+v, want it http only and scoped to the magic link routes", nonce)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{"missing email", `{}`, nil, http.StatusBadRequest},
+		{"malformed email", `{"email":"jane"}`, nil, http.StatusBadRequest},
+		{"email disabled", `{"email":"jane@example.com"}`, service.ErrEmailDisabled, http.StatusForbidden},
+		{"mail failure", `{"email":"jane@example.com"}`, context.DeadlineExceeded, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := send(tt.body).serve(t, SendMagicLink(&magicLinkService{err: tt.err}))
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if cookie(recorder, magicLinkNonceCookie) != nil {
+				t.Error("nonce cookie set although no link was sent")
+			}
+		})
+	}
+}
+
+func TestVerifyMagicLink(t *testing.T) {
+	browser := []*http.Cookie{{Name: magicLinkNonceCookie, Value: "nonce"}}
+
+	tests := []struct {
+		name     string
+		body     string
+		cookies  []*http.Cookie
+		service  *magicLinkService
+		status   int
+		issued   bool
+		forwards string
+	}{
+		{"same browser", `{"token":"t"}`, browser, &magicLinkService{}, http.StatusOK, true, "nonce"},
+		{"second factor pending", `{"token":"t"}`, browser, &magicLinkService{mfaToken: "mfa"}, http.StatusOK, false, "nonce"},
+		{"other browser", `{"token":"t"}`, nil, &magicLinkService{err: service.ErrInvalidToken}, http.StatusUnauthorized, false, ""},
+		{"account gone", `{"token":"t"}`, browser, &magicLinkService{err: service.ErrUserNotFound}, http.StatusUnauthorized, false, "nonce"},
+		{"store failure", `{"token":"t"}`, browser, &magicLinkService{err: context.Canceled}, http.StatusInternalServerError, false, "nonce"},
+		{"missing token", `{}`, browser, &magicLinkService{}, http.StatusBadRequest, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := request{method: http.MethodPost, route: "/magic-link/verify", path: "/magic-link/verify", body: tt.body, cookies: tt.cookies}
+			recorder := call.serve(t, VerifyMagicLink(tt.service))
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if tt.service.nonce != tt.forwards {
+				t.Errorf("nonce passed on = %q, want %q", tt.service.nonce, tt.forwards)
+			}
+			if tt.service.issued != tt.issued {
+				t.Errorf("tokens issued = %v, want %v", tt.service.issued, tt.issued)
+			}
+			if spent := cookie(recorder, magicLinkNonceCookie); (spent != nil && spent.MaxAge < 0) != (tt.status == http.StatusOK) {
+				t.Errorf("nonce cookie = %+v, want it cleared once the link is used", spent)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondMFAError maps second factor errors to responses
+func respondMFAError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrInvalidToken:
+		ctx.JSON(http.StatusUnauthorized, model.Response{
+			Message:    "Invalid or expired MFA token",
+			StatusCode: http.StatusUnauthorized,
+		})
+	case service.ErrInvalidCode:
+		ctx.JSON(http.StatusUnauthorized, model.Response{
+			Message:    "Invalid verification code",
+			StatusCode: http.StatusUnauthorized,
+		})
+	case service.ErrTooManyAttempts:
+		ctx.JSON(http.StatusTooManyRequests, model.Response{
+			Message:    "Too many invalid codes, please start again",
+			StatusCode: http.StatusTooManyRequests,
+		})
+	case service.ErrMFAAlreadyEnabled, service.ErrMFANotEnabled, service.ErrMFARequired:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// EnrollTOTP starts TOTP enrollment for the current user
+func EnrollTOTP(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		secret, uri, err := authService.BeginTOTPEnrollment(ctx, user.ID)
+		if err != nil {
+			respondMFAError(ctx, err, "Error starting two-factor enrollment")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Scan the QR code with your authenticator app and confirm with the first code",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"secret":      secret,
+				"otpauth_uri": uri,
+				"qr_payload":  uri,
+			},
+		})
+	}
+}
+
+// ConfirmTOTP activates TOTP for the current user with the first code from the authenticator
+func ConfirmTOTP(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		recoveryCodes, err := authService.ConfirmTOTPEnrollment(ctx, user.ID, body.Code)
+		if err != nil {
+			respondMFAError(ctx, err, "Error confirming two-factor enrollment")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Two-factor authentication enabled. Store the recovery codes somewhere safe, they are shown only once.",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"recovery_codes": recoveryCodes,
+			},
+		})
+	}
+}
+
+// DisableTOTP turns TOTP off for the current user
+func DisableTOTP(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		if err := authService.DisableTOTP(ctx, user.ID, body.Code); err != nil {
+			respondMFAError(ctx, err, "Error disabling two-factor authentication")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Two-factor authentication disabled",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// EnrollTOTPWithMFAToken starts enrollment for a user that must set up a second factor before logging in
+func EnrollTOTPWithMFAToken(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			MFAToken string `json:"mfa_token" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		user, err := authService.ResolveMFAToken(ctx, body.MFAToken)
+		if err != nil {
+			respondMFAError(ctx, err, "Error starting two-factor enrollment")
+			return
+		}
+
+		secret, uri, err := authService.BeginTOTPEnrollment(ctx, user.ID.String())
+		if err != nil {
+			respondMFAError(ctx, err, "Error starting two-factor enrollment")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Scan the QR code with your authenticator app and confirm with the first code",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"secret":      secret,
+				"otpauth_uri": uri,
+				"qr_payload":  uri,
+			},
+		})
+	}
+}
+
+// ConfirmTOTPWithMFAToken finishes a forced enrollment and completes the pending login
+func ConfirmTOTPWithMFAToken(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			MFAToken string `json:"mfa_token" binding:"required"`
+			Code     string `json:"code" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		user, recoveryCodes, err := authService.CompleteMFAEnrollment(ctx, body.MFAToken, body.Code)
+		if err != nil {
+			respondMFAError(ctx, err, "Error confirming two-factor enrollment")
+			return
+		}
+
+		respondWithTokens(ctx, authService, user, "Two-factor authentication enabled, login successful", gin.H{
+			"recovery_codes": recoveryCodes,
+		})
+	}
+}
+
+// VerifyMFA exchanges the MFA pending token and a TOTP or recovery code for access/refresh tokens
+func VerifyMFA(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			MFAToken     string `json:"mfa_token" binding:"required"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil || (body.Code == "") == (body.RecoveryCode == "") {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body, provide either code or recovery_code",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		var user *model.User
+		var err error
+		if body.RecoveryCode != "" {
+			user, err = authService.VerifyMFARecoveryCode(ctx, body.MFAToken, body.RecoveryCode)
+		} else {
+			user, err = authService.VerifyMFA(ctx, body.MFAToken, body.Code)
+		}
+		if err != nil {
+			respondMFAError(ctx, err, "Error verifying second factor")
+			return
+		}
+
+		respondWithTokens(ctx, authService, user, "Login successful", nil)
+	}
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+func RegenerateRecoveryCodes(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		recoveryCodes, err := authService.RegenerateRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			respondMFAError(ctx, err, "Error generating recovery codes")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Recovery codes regenerated, the previous codes no longer work",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"recovery_codes": recoveryCodes,
+			},
+		})
+	}
+}
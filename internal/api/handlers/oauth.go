@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// oauthStateCookie binds an authorization round trip to the browser that started it
+	oauthStateCookie     = "oauth_state"
+	oauthStateCookiePath = "/api/v1/auth/oauth"
+)
+
+// ListOAuthProviders lists the enabled social login providers so clients can render their buttons
+func ListOAuthProviders(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Providers retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"providers": authService.OAuthProviders(ctx),
+			},
+		})
+	}
+}
+
+// OAuthStart redirects the browser to the provider's consent page
+func OAuthStart(authService service.AuthService, provider string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authURL, state, err := authService.BeginOAuth(ctx, provider)
+		if err != nil {
+			switch err {
+			case service.ErrProviderDisabled:
+				ctx.JSON(http.StatusNotFound, model.Response{
+					Message:    "Provider is not enabled",
+					StatusCode: http.StatusNotFound,
+				})
+			default:
+				log.Errorf("Error starting %s login: %v", provider, err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error starting login",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		ctx.SetCookie(oauthStateCookie, state, 600, oauthStateCookiePath, "", false, true)
+		ctx.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// OAuthCallback finishes the provider round trip and sends the browser back to the app with a session, or
+// with linked=<provider> for a link round trip. Failures and pending second factors are reported to the app
+// through query parameters.
+func OAuthCallback(authService service.AuthService, provider, redirectURL string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		redirect := func(params url.Values) {
+			ctx.SetCookie(oauthStateCookie, "", -1, oauthStateCookiePath, "", false, true)
+			target := redirectURL
+			if len(params) > 0 {
+				target += "?" + params.Encode()
+			}
+			ctx.Redirect(http.StatusFound, target)
+		}
+
+		if providerErr := ctx.Query("error"); providerErr != "" {
+			redirect(url.Values{"error": {providerErr}})
+			return
+		}
+
+		state := ctx.Query("state")
+		cookieState, _ := ctx.Cookie(oauthStateCookie)
+		if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+			redirect(url.Values{"error": {"invalid_state"}})
+			return
+		}
+
+		result, err := authService.CompleteOAuth(ctx, provider, state, ctx.Query("code"))
+		if err != nil {
+			switch err {
+			case service.ErrInvalidToken:
+				redirect(url.Values{"error": {"invalid_state"}})
+			case service.ErrEmailNotVerified:
+				redirect(url.Values{"error": {"email_not_verified"}})
+			case service.ErrSignupDisabled:
+				redirect(url.Values{"error": {"signup_disabled"}})
+			case service.ErrAccountExists:
+				redirect(url.Values{"error": {"account_exists"}})
+			case service.ErrIdentityInUse:
+				redirect(url.Values{"error": {"identity_in_use"}})
+			case service.ErrOAuthExchange, service.ErrProviderDisabled:
+				redirect(url.Values{"error": {"provider_error"}})
+			default:
+				log.Errorf("Error completing %s login: %v", provider, err)
+				redirect(url.Values{"error": {"server_error"}})
+			}
+			return
+		}
+
+		if result.Linked {
+			redirect(url.Values{"linked": {provider}})
+			return
+		}
+
+		if result.MFAToken != "" {
+			params := url.Values{}
+			for key, value := range mfaRequiredData(result.User, result.MFAToken) {
+				params.Set(key, fmt.Sprint(value))
+			}
+			redirect(params)
+			return
+		}
+
+		accessToken, refreshToken, err := authService.GenerateTokens(ctx, result.User)
+		if err != nil {
+			switch err {
+			case service.ErrAccountSuspended, service.ErrAccountBanned, service.ErrAccountPendingDeletion:
+				redirect(url.Values{"error": {"account_inactive"}})
+				return
+			case service.ErrAccountLocked:
+				redirect(url.Values{"error": {"account_locked"}})
+				return
+			}
+			log.Errorf("Error generating tokens: %v", err)
+			redirect(url.Values{"error": {"server_error"}})
+			return
+		}
+		setTokenCookies(ctx, accessToken, refreshToken)
+		redirect(nil)
+	}
+}
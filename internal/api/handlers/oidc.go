@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// authorizeErrorCodes maps authorization request errors to the RFC 6749 codes sent back to the client
+var authorizeErrorCodes = map[error]string{
+	service.ErrInvalidRequest:      "invalid_request",
+	service.ErrInvalidScope:        "invalid_scope",
+	service.ErrUnsupportedResponse: "unsupported_response_type",
+	service.ErrUnauthorizedClient:  "unauthorized_client",
+	service.ErrConsentRequired:     "consent_required",
+	service.ErrSessionRevoked:      "login_required",
+	service.ErrSessionNotFound:     "login_required",
+}
+
+// respondOAuthError writes an RFC 6749 error body, the token and userinfo endpoints don't use model.Response
+func respondOAuthError(ctx *gin.Context, status int, code, description string) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// OpenIDConfiguration serves the discovery document
+func OpenIDConfiguration(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		document, err := authService.OpenIDConfiguration()
+		if err != nil {
+			ctx.JSON(http.StatusNotFound, model.Response{
+				Message:    "OpenID Connect is not enabled",
+				StatusCode: http.StatusNotFound,
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, document)
+	}
+}
+
+// JWKS serves the public keys our tokens can be verified with
+func JWKS(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.JSON(http.StatusOK, authService.JWKS())
+	}
+}
+
+// OIDCAuthorize handles the authorization endpoint. A browser without a session is sent to the login page and one
+// without consent to the consent page, both get the original request back to resume it. Errors are shown as JSON
+// until the client and redirect URI are trusted and redirected to the client afterwards.
+func OIDCAuthorize(authService service.AuthService, issuer, loginURL, consentURL string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req model.AuthorizeRequest
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid authorization request",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		client, err := authService.ValidateAuthorizeRequest(ctx, &req)
+		switch err {
+		case nil:
+		case service.ErrInvalidClient, service.ErrInvalidRedirectURI:
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    err.Error(),
+				StatusCode: http.StatusBadRequest,
+			})
+			return
+		case service.ErrOIDCDisabled:
+			ctx.JSON(http.StatusNotFound, model.Response{
+				Message:    "OpenID Connect is not enabled",
+				StatusCode: http.StatusNotFound,
+			})
+			return
+		default:
+			redirectAuthorizeError(ctx, &req, err)
+			return
+		}
+
+		tokenInfo := currentToken(ctx, authService)
+		if tokenInfo == nil || tokenInfo.SessionID == nil {
+			if strings.Contains(req.Prompt, "none") {
+				ctx.Redirect(http.StatusFound, service.AuthorizeErrorRedirect(&req, "login_required"))
+				return
+			}
+			returnTo := issuer + ctx.Request.RequestURI
+			ctx.Redirect(http.StatusFound, loginURL+"?"+url.Values{"return_to": {returnTo}}.Encode())
+			return
+		}
+
+		redirectTo, err := authService.Authorize(ctx, tokenInfo.UserID.String(), tokenInfo.SessionID.String(), &req)
+		if err == service.ErrConsentRequired && !strings.Contains(req.Prompt, "none") {
+			query := ctx.Request.URL.Query()
+			query.Set("client_name", client.Name)
+			ctx.Redirect(http.StatusFound, consentURL+"?"+query.Encode())
+			return
+		}
+		if err != nil {
+			redirectAuthorizeError(ctx, &req, err)
+			return
+		}
+		ctx.Redirect(http.StatusFound, redirectTo)
+	}
+}
+
+// redirectAuthorizeError reports the error to the client, unknown errors are logged and sent as server_error
+func redirectAuthorizeError(ctx *gin.Context, req *model.AuthorizeRequest, err error) {
+	code, ok := authorizeErrorCodes[err]
+	if !ok {
+		log.Errorf("Error authorizing client %s: %v", req.ClientID, err)
+		code = "server_error"
+	}
+	ctx.Redirect(http.StatusFound, service.AuthorizeErrorRedirect(req, code))
+}
+
+// currentToken returns the caller's access token info from the header or cookie, nil when there is none
+func currentToken(ctx *gin.Context, authService service.AuthService) *model.TokenInfo {
+	accessToken := utils.ExtractTokenFromHeader(ctx.GetHeader("Authorization"))
+	if accessToken == "" {
+		accessToken, _ = ctx.Cookie("access_token")
+	}
+	if accessToken == "" {
+		return nil
+	}
+	tokenInfo, err := authService.ValidateAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil
+	}
+	return tokenInfo
+}
+
+// OIDCConsent records the current user's answer to the consent page and returns where to send the browser
+func OIDCConsent(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			model.AuthorizeRequest
+			Approve bool `json:"approve"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		redirectTo, err := authService.GrantConsent(ctx, user.ID, user.SessionID, &body.AuthorizeRequest, body.Approve)
+		if err != nil {
+			if code, ok := authorizeErrorCodes[err]; ok {
+				redirectTo = service.AuthorizeErrorRedirect(&body.AuthorizeRequest, code)
+			} else {
+				switch err {
+				case service.ErrInvalidClient, service.ErrInvalidRedirectURI, service.ErrOIDCDisabled:
+					ctx.JSON(http.StatusBadRequest, model.Response{
+						Message:    err.Error(),
+						StatusCode: http.StatusBadRequest,
+					})
+				default:
+					log.Errorf("Error recording consent: %v", err)
+					ctx.JSON(http.StatusInternalServerError, model.Response{
+						Message:    "Error recording consent",
+						StatusCode: http.StatusInternalServerError,
+						Error:      err,
+					})
+				}
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Consent recorded",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"redirect_to": redirectTo,
+			},
+		})
+	}
+}
+
+// clientCredentials reads the client authentication of the token endpoints from HTTP basic or form parameters
+func clientCredentials(ctx *gin.Context) (string, string, bool) {
+	clientID, clientSecret, basic := ctx.Request.BasicAuth()
+	if !basic {
+		clientID = ctx.PostForm("client_id")
+		clientSecret = ctx.PostForm("client_secret")
+	}
+	return clientID, clientSecret, basic
+}
+
+// respondTokenEndpointError maps errors of the token, introspection and revocation endpoints to RFC 6749 codes
+func respondTokenEndpointError(ctx *gin.Context, err error, basic bool) {
+	switch err {
+	case service.ErrInvalidClient:
+		if basic {
+			ctx.Header("WWW-Authenticate", `Basic realm="token"`)
+		}
+		respondOAuthError(ctx, http.StatusUnauthorized, "invalid_client", err.Error())
+	case service.ErrInvalidGrant:
+		respondOAuthError(ctx, http.StatusBadRequest, "invalid_grant", err.Error())
+	case service.ErrUnsupportedGrant, service.ErrOIDCDisabled:
+		respondOAuthError(ctx, http.StatusBadRequest, "unsupported_grant_type", err.Error())
+	case service.ErrUnauthorizedClient:
+		respondOAuthError(ctx, http.StatusBadRequest, "unauthorized_client", err.Error())
+	case service.ErrInvalidScope:
+		respondOAuthError(ctx, http.StatusBadRequest, "invalid_scope", err.Error())
+	default:
+		log.Errorf("Error at token endpoint: %v", err)
+		respondOAuthError(ctx, http.StatusInternalServerError, "server_error", "internal server error")
+	}
+}
+
+// OIDCToken is the token endpoint: it redeems authorization codes and issues client credentials tokens. Clients
+// authenticate with HTTP basic or form parameters, public clients only send their client_id.
+func OIDCToken(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clientID, clientSecret, basic := clientCredentials(ctx)
+
+		var response *model.TokenResponse
+		var err error
+		switch ctx.PostForm("grant_type") {
+		case model.GrantAuthorizationCode:
+			response, err = authService.ExchangeAuthorizationCode(ctx, clientID, clientSecret,
+				ctx.PostForm("code"), ctx.PostForm("redirect_uri"), ctx.PostForm("code_verifier"))
+		case model.GrantClientCredentials:
+			response, err = authService.IssueClientCredentialsToken(ctx, clientID, clientSecret, ctx.PostForm("scope"))
+		default:
+			err = service.ErrUnsupportedGrant
+		}
+		if err != nil {
+			respondTokenEndpointError(ctx, err, basic)
+			return
+		}
+
+		ctx.Header("Cache-Control", "no-store")
+		ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// OAuthIntrospect reports whether a token is active and what it carries (RFC 7662), for confidential clients
+// such as gateways that can't verify our tokens themselves
+func OAuthIntrospect(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clientID, clientSecret, basic := clientCredentials(ctx)
+
+		response, err := authService.IntrospectToken(ctx, clientID, clientSecret, ctx.PostForm("token"))
+		if err != nil {
+			respondTokenEndpointError(ctx, err, basic)
+			return
+		}
+
+		ctx.Header("Cache-Control", "no-store")
+		ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// OAuthRevoke revokes an access or refresh token (RFC 7009), it answers 200 for tokens that were already invalid
+func OAuthRevoke(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clientID, clientSecret, basic := clientCredentials(ctx)
+
+		if err := authService.RevokeToken(ctx, clientID, clientSecret, ctx.PostForm("token")); err != nil {
+			respondTokenEndpointError(ctx, err, basic)
+			return
+		}
+
+		ctx.Status(http.StatusOK)
+	}
+}
+
+// OIDCUserInfo returns the claims released to the bearer token
+func OIDCUserInfo(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		info, err := authService.UserInfo(ctx, utils.ExtractTokenFromHeader(ctx.GetHeader("Authorization")))
+		if err != nil {
+			ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			respondOAuthError(ctx, http.StatusUnauthorized, "invalid_token", err.Error())
+			return
+		}
+		ctx.JSON(http.StatusOK, info)
+	}
+}
+
+// CreateClient registers an authorization code or client credentials client, its secret is only returned here
+func CreateClient(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Name         string   `json:"name" binding:"required"`
+			RedirectURIs []string `json:"redirect_uris"`
+			Scopes       []string `json:"scopes"`
+			GrantTypes   []string `json:"grant_types"` // defaults to authorization_code
+			Confidential bool     `json:"confidential"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		client := &model.Client{
+			Name:         body.Name,
+			RedirectURIs: strings.Join(body.RedirectURIs, " "),
+			Scopes:       strings.Join(body.Scopes, " "),
+			GrantTypes:   strings.Join(body.GrantTypes, " "),
+		}
+		secret, err := authService.CreateClient(ctx, user, client, body.Confidential)
+		if err != nil {
+			switch err {
+			case service.ErrInvalidRequest, service.ErrInvalidRedirectURI, service.ErrInvalidScope:
+				ctx.JSON(http.StatusBadRequest, model.Response{
+					Message:    err.Error(),
+					StatusCode: http.StatusBadRequest,
+				})
+			case service.ErrPermissionDenied:
+				ctx.JSON(http.StatusForbidden, model.Response{
+					Message:    err.Error(),
+					StatusCode: http.StatusForbidden,
+				})
+			default:
+				log.Errorf("Error creating client: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error creating client",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		data := gin.H{
+			"client": client,
+		}
+		if secret != "" {
+			data["client_secret"] = secret
+		}
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "Client created successfully, store the secret now, it can't be shown again",
+			StatusCode: http.StatusCreated,
+			Data:       data,
+		})
+	}
+}
+
+// ListClients lists the registered clients
+func ListClients(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clients, err := authService.ListClients(ctx)
+		if err != nil {
+			log.Errorf("Error listing clients: %v", err)
+			ctx.JSON(http.StatusInternalServerError, model.Response{
+				Message:    "Error listing clients",
+				StatusCode: http.StatusInternalServerError,
+				Error:      err,
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Clients retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"clients": clients,
+			},
+		})
+	}
+}
+
+// DeleteClient removes a client along with the consents given to it
+func DeleteClient(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := authService.DeleteClient(ctx, ctx.Param("client_id")); err != nil {
+			switch err {
+			case service.ErrClientNotFound:
+				ctx.JSON(http.StatusNotFound, model.Response{
+					Message:    "Client not found",
+					StatusCode: http.StatusNotFound,
+				})
+			default:
+				log.Errorf("Error deleting client: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error deleting client",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Client deleted successfully",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// ListConsents lists the clients the current user approved
+func ListConsents(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		consents, err := authService.ListConsents(ctx, user.ID)
+		if err != nil {
+			log.Errorf("Error listing consents: %v", err)
+			ctx.JSON(http.StatusInternalServerError, model.Response{
+				Message:    "Error listing consents",
+				StatusCode: http.StatusInternalServerError,
+				Error:      err,
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Consents retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"consents": consents,
+			},
+		})
+	}
+}
+
+// RevokeConsent withdraws the current user's approval of a client
+func RevokeConsent(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.RevokeConsent(ctx, user.ID, ctx.Param("client_id")); err != nil {
+			switch err {
+			case service.ErrConsentNotFound:
+				ctx.JSON(http.StatusNotFound, model.Response{
+					Message:    "Consent not found",
+					StatusCode: http.StatusNotFound,
+				})
+			default:
+				log.Errorf("Error revoking consent: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error revoking consent",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Consent revoked successfully",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondOrganizationError maps organization errors to responses
+func respondOrganizationError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrOrganizationNotFound, service.ErrNotMember, service.ErrUserNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrPermissionDenied, service.ErrInvitationMismatch:
+		ctx.JSON(http.StatusForbidden, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusForbidden,
+		})
+	case service.ErrAlreadyMember, service.ErrLastOwner:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	case service.ErrInvalidName, service.ErrInvalidOrgRole, service.ErrInvalidInvitation, service.ErrEmailDisabled:
+		ctx.JSON(http.StatusBadRequest, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		})
+	case service.ErrInvalidToken, service.ErrSessionRevoked:
+		ctx.JSON(http.StatusUnauthorized, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusUnauthorized,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// orgActorRole is the caller's role in the organization, holders of organizations:write act as owners
+func orgActorRole(user *model.UserCtxData, orgID string) string {
+	if user.HasPermission(model.PermissionOrganizationsWrite) {
+		return model.OrgRoleOwner
+	}
+	if user.OrgID == orgID {
+		return user.OrgRole
+	}
+	return ""
+}
+
+// CreateOrganization creates an organization owned by the caller
+func CreateOrganization(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		org, err := authService.CreateOrganization(ctx, user.ID, body.Name)
+		if err != nil {
+			respondOrganizationError(ctx, err, "Error creating organization")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "Organization created",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"organization": org,
+			},
+		})
+	}
+}
+
+// ListOrganizations lists the caller's organizations with their role in each
+func ListOrganizations(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		memberships, err := authService.ListMemberships(ctx, user.ID)
+		if err != nil {
+			respondOrganizationError(ctx, err, "Error listing organizations")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Organizations retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"memberships": memberships,
+			},
+		})
+	}
+}
+
+// SwitchOrganization exchanges the caller's access token for one scoped to an organization
+func SwitchOrganization(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			OrgID string `json:"org_id"` // empty switches back to no organization
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		accessToken, err := authService.SwitchOrganization(ctx, user.ID, user.SessionID, body.OrgID)
+		if err != nil {
+			respondOrganizationError(ctx, err, "Error switching organization")
+			return
+		}
+
+		ctx.SetCookie("access_token", accessToken, int(time.Hour*24), "/", "", false, true)
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Organization switched",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"access_token": accessToken,
+			},
+		})
+	}
+}
+
+// ListMembers lists the members of an organization
+func ListMembers(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		members, err := authService.ListMembers(ctx, ctx.Param("org_id"))
+		if err != nil {
+			respondOrganizationError(ctx, err, "Error listing members")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Members retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"members": members,
+			},
+		})
+	}
+}
+
+// InviteMember emails an invitation to join the organization
+func InviteMember(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Email string `json:"email" binding:"required,email"`
+			Role  string `json:"role"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+		if body.Role == "" {
+			body.Role = model.OrgRoleMember
+		}
+
+		orgID := ctx.Param("org_id")
+		if err := authService.InviteMember(ctx, user.ID, orgActorRole(user, orgID), orgID, body.Email, body.Role); err != nil {
+			respondOrganizationError(ctx, err, "Error inviting member")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Invitation sent",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// AcceptInvitation joins the organization an invitation was sent for
+func AcceptInvitation(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Token string `json:"token" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		membership, err := authService.AcceptInvitation(ctx, user.ID, body.Token)
+		if err != nil {
+			respondOrganizationError(ctx, err, "Error accepting invitation")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Invitation accepted",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"membership": membership,
+			},
+		})
+	}
+}
+
+// UpdateMemberRole changes a member's role in the organization
+func UpdateMemberRole(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Role string `json:"role" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		orgID := ctx.Param("org_id")
+		if err := authService.UpdateMemberRole(ctx, user.ID, orgActorRole(user, orgID), orgID, ctx.Param("user_id"), body.Role); err != nil {
+			respondOrganizationError(ctx, err, "Error updating member role")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Member role updated",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// RemoveMember removes a member from the organization
+func RemoveMember(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		orgID := ctx.Param("org_id")
+		if err := authService.RemoveMember(ctx, user.ID, orgActorRole(user, orgID), orgID, ctx.Param("user_id")); err != nil {
+			respondOrganizationError(ctx, err, "Error removing member")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Member removed",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// organizationService fails every call with err and records the role the caller acted with
+type organizationService struct {
+	service.AuthService
+	err       error
+	actorRole string
+}
+
+func (s *organizationService) InviteMember(ctx context.Context, actorID, actorRole, orgID, email, role string) error {
+	s.actorRole = actorRole
+	return s.err
+}
+
+func (s *organizationService) UpdateMemberRole(ctx context.Context, actorID, actorRole, orgID, userID, role string) error {
+	s.actorRole = actorRole
+	return s.err
+}
+
+func (s *organizationService) RemoveMember(ctx context.Context, actorID, actorRole, orgID, userID string) error {
+	s.actorRole = actorRole
+	return s.err
+}
+
+func (s *organizationService) AcceptInvitation(ctx context.Context, userID, token string) (*model.Membership, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Membership{Role: model.OrgRoleMember}, nil
+}
+
+func (s *organizationService) SwitchOrganization(ctx context.Context, userID, sessionID, orgID string) (string, error) {
+	return "access", s.err
+}
+
+func TestOrganizationRoutes(t *testing.T) {
+	orgID := uuid.NewString()
+	owner := &model.UserCtxData{ID: uuid.NewString(), SessionID: uuid.NewString(), OrgID: orgID, OrgRole: model.OrgRoleOwner}
+	invite := request{method: http.MethodPost, route: "/orgs/:org_id/invitations", path: "/orgs/" + orgID + "/invitations", body: `{"email":"jane@example.com","role":"admin"}`}
+	update := request{method: http.MethodPut, route: "/orgs/:org_id/members/:user_id", path: "/orgs/" + orgID + "/members/" + uuid.NewString(), body: `{"role":"member"}`}
+	remove := request{method: http.MethodDelete, route: "/orgs/:org_id/members/:user_id", path: "/orgs/" + orgID + "/members/" + uuid.NewString()}
+	accept := request{method: http.MethodPost, route: "/orgs/invitations/accept", path: "/orgs/invitations/accept", body: `{"token":"token"}`}
+	switchOrg := request{method: http.MethodPost, route: "/orgs/switch", path: "/orgs/switch", body: `{"org_id":"` + orgID + `"}`}
+
+	tests := []struct {
+		name    string
+		call    request
+		handler func(service.AuthService) gin.HandlerFunc
+		err     error
+		status  int
+	}{
+		{"invite", invite, InviteMember, nil, http.StatusOK},
+		{"invite without email", request{method: http.MethodPost, route: invite.route, path: invite.path, body: `{"role":"admin"}`}, InviteMember, nil, http.StatusBadRequest},
+		{"invite to an unknown role", invite, InviteMember, service.ErrInvalidOrgRole, http.StatusBadRequest},
+		{"invite a member", invite, InviteMember, service.ErrAlreadyMember, http.StatusConflict},
+		{"invite without email delivery", invite, InviteMember, service.ErrEmailDisabled, http.StatusBadRequest},
+		{"update", update, UpdateMemberRole, nil, http.StatusOK},
+		{"update without role", request{method: http.MethodPut, route: update.route, path: update.path, body: `{}`}, UpdateMemberRole, nil, http.StatusBadRequest},
+		{"demote the last owner", update, UpdateMemberRole, service.ErrLastOwner, http.StatusConflict},
+		{"admin demotes an owner", update, UpdateMemberRole, service.ErrPermissionDenied, http.StatusForbidden},
+		{"remove", remove, RemoveMember, nil, http.StatusOK},
+		{"remove a non member", remove, RemoveMember, service.ErrNotMember, http.StatusNotFound},
+		{"remove failure", remove, RemoveMember, errors.New("store down"), http.StatusInternalServerError},
+		{"accept", accept, AcceptInvitation, nil, http.StatusOK},
+		{"accept a used invitation", accept, AcceptInvitation, service.ErrInvalidInvitation, http.StatusBadRequest},
+		{"accept someone else's invitation", accept, AcceptInvitation, service.ErrInvitationMismatch, http.StatusForbidden},
+		{"switch", switchOrg, SwitchOrganization, nil, http.StatusOK},
+		{"switch to a foreign organization", switchOrg, SwitchOrganization, service.ErrNotMember, http.StatusNotFound},
+		{"switch a revoked session", switchOrg, SwitchOrganization, service.ErrSessionRevoked, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.call.user = owner
+			if recorder := tt.call.serve(t, tt.handler(&organizationService{err: tt.err})); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+
+			tt.call.user = nil
+			if recorder := tt.call.serve(t, tt.handler(&organizationService{})); recorder.Code != http.StatusUnauthorized {
+				t.Errorf("anonymous status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestOrgActorRole(t *testing.T) {
+	orgID := uuid.NewString()
+
+	tests := []struct {
+		name string
+		user *model.UserCtxData
+		want string
+	}{
+		{"admin of the organization", &model.UserCtxData{ID: "jane", OrgID: orgID, OrgRole: model.OrgRoleAdmin}, model.OrgRoleAdmin},
+		{"owner of another organization", &model.UserCtxData{ID: "jane", OrgID: uuid.NewString(), OrgRole: model.OrgRoleOwner}, ""},
+		{"organizations:write", &model.UserCtxData{ID: "root", Permissions: []string{model.PermissionOrganizationsWrite}}, model.OrgRoleOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := &organizationService{}
+			call := request{method: http.MethodDelete, route: "/orgs/:org_id/members/:user_id", path: "/orgs/" + orgID + "/members/" + uuid.NewString(), user: tt.user}
+			call.serve(t, RemoveMember(authService))
+			if authService.actorRole != tt.want {
+				t.Errorf("acted as %q, want %q", authService.actorRole, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/validators"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SendLoginOTP texts a one-time sign-in code to a phone number
+func SendLoginOTP(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			Phone string `json:"phone" binding:"required"`
+		}
+
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		if !validators.IsValidPhone(body.Phone) {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Phone number must be in E.164 format",
+				StatusCode: http.StatusBadRequest,
+			})
+			return
+		}
+
+		if err := authService.SendLoginOTP(ctx, body.Phone); err != nil {
+			switch err {
+			case service.ErrPhoneDisabled:
+				ctx.JSON(http.StatusForbidden, model.Response{
+					Message:    "Phone sign in is disabled",
+					StatusCode: http.StatusForbidden,
+				})
+			default:
+				log.Errorf("Error sending login code: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error sending login code",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "If an account exists for this number, a login code has been sent",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// VerifyLoginOTP exchanges a phone number and code for an access/refresh pair
+func VerifyLoginOTP(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			Phone string `json:"phone" binding:"required"`
+			Code  string `json:"code" binding:"required"`
+		}
+
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		user, mfaToken, err := authService.VerifyLoginOTP(ctx, body.Phone, body.Code)
+		if err != nil {
+			switch err {
+			case service.ErrPhoneDisabled:
+				ctx.JSON(http.StatusForbidden, model.Response{
+					Message:    "Phone sign in is disabled",
+					StatusCode: http.StatusForbidden,
+				})
+			case service.ErrInvalidCode:
+				ctx.JSON(http.StatusUnauthorized, model.Response{
+					Message:    "Invalid or expired code",
+					StatusCode: http.StatusUnauthorized,
+				})
+			case service.ErrTooManyAttempts:
+				ctx.JSON(http.StatusTooManyRequests, model.Response{
+					Message:    "Too many invalid codes, please request a new one",
+					StatusCode: http.StatusTooManyRequests,
+				})
+			default:
+				log.Errorf("Error verifying login code: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error verifying login code",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		if mfaToken != "" {
+			respondMFARequired(ctx, user, mfaToken)
+			return
+		}
+
+		respondWithTokens(ctx, authService, user, "Login successful", nil)
+	}
+}
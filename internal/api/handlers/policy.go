@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/auth/policy"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// ListPolicies returns the policies currently loaded
+func ListPolicies(engine *policy.Engine) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Policies retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"policies": engine.Policies(),
+			},
+		})
+	}
+}
+
+// ExplainPolicy evaluates a request without performing it and explains the decision. The subject is the caller
+// and the time now, subject attributes and the time can be overridden to try out "what if" cases.
+func ExplainPolicy(engine *policy.Engine) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Action   string                 `json:"action" binding:"required"`
+			Resource map[string]interface{} `json:"resource"`
+			Subject  map[string]interface{} `json:"subject"`
+			At       *time.Time             `json:"at"`
+			IP       string                 `json:"ip"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		req := policy.Request{
+			Subject:  policy.Subject(*user),
+			Action:   body.Action,
+			Resource: body.Resource,
+		}
+		for name, value := range body.Subject {
+			req.Subject[name] = value
+		}
+		at, ip := time.Now(), ctx.ClientIP()
+		if body.At != nil {
+			at = *body.At
+		}
+		if body.IP != "" {
+			ip = body.IP
+		}
+		req.Env = engine.Env(at, ip)
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Policy decision explained",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"decision": engine.Evaluate(req),
+			},
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondRBACError maps role and permission errors to responses
+func respondRBACError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrRoleNotFound, service.ErrPermissionNotFound, service.ErrUserNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrRoleExists, service.ErrPermissionExists, service.ErrProtectedRole:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	case service.ErrInvalidName:
+		ctx.JSON(http.StatusBadRequest, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// ListRoles lists the roles with their permissions
+func ListRoles(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		roles, err := authService.ListRoles(ctx)
+		if err != nil {
+			respondRBACError(ctx, err, "Error listing roles")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Roles retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"roles": roles,
+			},
+		})
+	}
+}
+
+// CreateRole creates a role granting existing permissions
+func CreateRole(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Name        string   `json:"name" binding:"required"`
+			Description string   `json:"description"`
+			Permissions []string `json:"permissions"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		role, err := authService.CreateRole(ctx, user.ID, body.Name, body.Description, body.Permissions)
+		if err != nil {
+			respondRBACError(ctx, err, "Error creating role")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "Role created",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"role": role,
+			},
+		})
+	}
+}
+
+// DeleteRole deletes a role, users holding it lose it
+func DeleteRole(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.DeleteRole(ctx, user.ID, ctx.Param("role")); err != nil {
+			respondRBACError(ctx, err, "Error deleting role")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Role deleted",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// GrantRolePermission adds a permission to a role
+func GrantRolePermission(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.GrantRolePermission(ctx, user.ID, ctx.Param("role"), ctx.Param("permission")); err != nil {
+			respondRBACError(ctx, err, "Error granting permission")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Permission granted",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// RevokeRolePermission removes a permission from a role
+func RevokeRolePermission(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.RevokeRolePermission(ctx, user.ID, ctx.Param("role"), ctx.Param("permission")); err != nil {
+			respondRBACError(ctx, err, "Error revoking permission")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Permission revoked",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// ListPermissions lists every known permission
+func ListPermissions(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		permissions, err := authService.ListPermissions(ctx)
+		if err != nil {
+			respondRBACError(ctx, err, "Error listing permissions")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Permissions retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"permissions": permissions,
+			},
+		})
+	}
+}
+
+// CreatePermission registers a permission
+func CreatePermission(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body struct {
+			Name        string `json:"name" binding:"required"`
+			Description string `json:"description"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		permission, err := authService.CreatePermission(ctx, user.ID, body.Name, body.Description)
+		if err != nil {
+			respondRBACError(ctx, err, "Error creating permission")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "Permission created",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"permission": permission,
+			},
+		})
+	}
+}
+
+// DeletePermission deletes a permission from every role
+func DeletePermission(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.DeletePermission(ctx, user.ID, ctx.Param("permission")); err != nil {
+			respondRBACError(ctx, err, "Error deleting permission")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Permission deleted",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// ListUserRoles lists every role of a user
+func ListUserRoles(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		roles, err := authService.ListUserRoles(ctx, ctx.Param("user_id"))
+		if err != nil {
+			respondRBACError(ctx, err, "Error listing user roles")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "User roles retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"roles": roles,
+			},
+		})
+	}
+}
+
+// AssignRole gives a user an extra role
+func AssignRole(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.AssignRole(ctx, user.ID, ctx.Param("user_id"), ctx.Param("role")); err != nil {
+			respondRBACError(ctx, err, "Error assigning role")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Role assigned",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// UnassignRole takes an assigned role away from a user
+func UnassignRole(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.UnassignRole(ctx, user.ID, ctx.Param("user_id"), ctx.Param("role")); err != nil {
+			respondRBACError(ctx, err, "Error unassigning role")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Role unassigned",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// rbacService fails every role and permission change with err
+type rbacService struct {
+	service.AuthService
+	err error
+}
+
+func (s *rbacService) CreateRole(ctx context.Context, adminID, name, description string, permissions []string) (*model.Role, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Role{Name: name, Description: description}, nil
+}
+
+func (s *rbacService) DeleteRole(ctx context.Context, adminID, name string) error {
+	return s.err
+}
+
+func (s *rbacService) RevokeRolePermission(ctx context.Context, adminID, roleName, permissionName string) error {
+	return s.err
+}
+
+func (s *rbacService) CreatePermission(ctx context.Context, adminID, name, description string) (*model.Permission, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Permission{Name: name, Description: description}, nil
+}
+
+func (s *rbacService) AssignRole(ctx context.Context, adminID, userID, roleName string) error {
+	return s.err
+}
+
+func TestRBACRoutes(t *testing.T) {
+	admin := &model.UserCtxData{ID: uuid.NewString(), Roles: []string{"admin"}}
+	createRole := request{method: http.MethodPost, route: "/roles", path: "/roles", body: `{"name":"editor","permissions":["posts:write"]}`}
+	deleteRole := request{method: http.MethodDelete, route: "/roles/:role", path: "/roles/admin"}
+	revoke := request{method: http.MethodDelete, route: "/roles/:role/permissions/:permission", path: "/roles/admin/permissions/roles:write"}
+	createPermission := request{method: http.MethodPost, route: "/permissions", path: "/permissions", body: `{"name":"posts:write"}`}
+	assign := request{method: http.MethodPut, route: "/users/:user_id/roles/:role", path: "/users/" + uuid.NewString() + "/roles/editor"}
+
+	tests := []struct {
+		name    string
+		call    request
+		handler func(service.AuthService) gin.HandlerFunc
+		err     error
+		status  int
+	}{
+		{"create role", createRole, CreateRole, nil, http.StatusCreated},
+		{"create role with a bad name", createRole, CreateRole, service.ErrInvalidName, http.StatusBadRequest},
+		{"create existing role", createRole, CreateRole, service.ErrRoleExists, http.StatusConflict},
+		{"create role with an unknown permission", createRole, CreateRole, service.ErrPermissionNotFound, http.StatusNotFound},
+		{"create role without a name", request{method: http.MethodPost, route: "/roles", path: "/roles", body: `{}`}, CreateRole, nil, http.StatusBadRequest},
+		{"delete protected role", deleteRole, DeleteRole, service.ErrProtectedRole, http.StatusConflict},
+		{"delete unknown role", deleteRole, DeleteRole, service.ErrRoleNotFound, http.StatusNotFound},
+		{"revoke from protected role", revoke, RevokeRolePermission, service.ErrProtectedRole, http.StatusConflict},
+		{"create existing permission", createPermission, CreatePermission, service.ErrPermissionExists, http.StatusConflict},
+		{"create permission with a bad name", createPermission, CreatePermission, service.ErrInvalidName, http.StatusBadRequest},
+		{"assign role", assign, AssignRole, nil, http.StatusOK},
+		{"assign role to unknown user", assign, AssignRole, service.ErrUserNotFound, http.StatusNotFound},
+		{"assign role failure", assign, AssignRole, errors.New("store down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.call.user = admin
+			if recorder := tt.call.serve(t, tt.handler(&rbacService{err: tt.err})); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+
+			tt.call.user = nil
+			if recorder := tt.call.serve(t, tt.handler(&rbacService{})); recorder.Code != http.StatusUnauthorized {
+				t.Errorf("anonymous status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ListSessions returns the signed-in devices of the current user
+func ListSessions(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		sessions, err := authService.ListSessions(ctx, user.ID)
+		if err != nil {
+			log.Errorf("Error listing sessions: %v", err)
+			ctx.JSON(http.StatusInternalServerError, model.Response{
+				Message:    "Error listing sessions",
+				StatusCode: http.StatusInternalServerError,
+				Error:      err,
+			})
+			return
+		}
+
+		items := make([]gin.H, 0, len(sessions))
+		for _, session := range sessions {
+			items = append(items, gin.H{
+				"id":           session.ID,
+				"device_name":  session.DeviceName,
+				"ip_address":   session.IPAddress,
+				"user_agent":   session.UserAgent,
+				"created_at":   session.CreatedAt,
+				"last_seen_at": session.LastSeenAt,
+				"current":      session.ID.String() == user.SessionID,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Sessions retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"sessions": items,
+			},
+		})
+	}
+}
+
+// RevokeSession signs out a single device of the current user
+func RevokeSession(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.RevokeSession(ctx, user.ID, ctx.Param("session_id")); err != nil {
+			switch err {
+			case service.ErrSessionNotFound:
+				ctx.JSON(http.StatusNotFound, model.Response{
+					Message:    "Session not found",
+					StatusCode: http.StatusNotFound,
+				})
+			default:
+				log.Errorf("Error revoking session: %v", err)
+				ctx.JSON(http.StatusInternalServerError, model.Response{
+					Message:    "Error revoking session",
+					StatusCode: http.StatusInternalServerError,
+					Error:      err,
+				})
+			}
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Session revoked successfully",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
+// RevokeOtherSessions signs out every device of the current user except the calling one
+func RevokeOtherSessions(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.RevokeOtherSessions(ctx, user.ID, user.SessionID); err != nil {
+			log.Errorf("Error revoking sessions: %v", err)
+			ctx.JSON(http.StatusInternalServerError, model.Response{
+				Message:    "Error revoking sessions",
+				StatusCode: http.StatusInternalServerError,
+				Error:      err,
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Other sessions revoked successfully",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
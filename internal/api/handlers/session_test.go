@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+type sessionService struct {
+	service.AuthService
+	sessions []model.Session
+	err      error
+	kept     string // session left alone by RevokeOtherSessions
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userID string) ([]model.Session, error) {
+	return s.sessions, s.err
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.err
+}
+
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	s.kept = currentSessionID
+	return s.err
+}
+
+func TestListSessions(t *testing.T) {
+	current, other := uuid.New(), uuid.New()
+	user := &model.UserCtxData{ID: uuid.NewString(), SessionID: current.String()}
+	authService := &sessionService{sessions: []model.Session{{ID: current}, {ID: other}}}
+
+	recorder := request{method: http.MethodGet, route: "/sessions", path: "/sessions", user: user}.serve(t, ListSessions(authService))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	var data struct {
+		Sessions []struct {
+			ID      uuid.UUID `json:"id"`
+			Current bool      `json:"current"`
+		} `json:"sessions"`
+	}
+	decode(t, recorder, &data)
+	if len(data.Sessions) != 2 || !data.Sessions[0].Current || data.Sessions[1].Current {
+		t.Errorf("sessions = %+v, want only the calling one marked current", data.Sessions)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		user   *model.UserCtxData
+		err    error
+		status int
+	}{
+		{"anonymous", nil, nil, http.StatusUnauthorized},
+		{"api key", &model.UserCtxData{ID: user.ID, APIKeyID: "key"}, nil, http.StatusUnauthorized},
+		{"store failure", user, errors.New("connection refused"), http.StatusInternalServerError},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := request{method: http.MethodGet, route: "/sessions", path: "/sessions", user: tt.user}.serve(t, ListSessions(&sessionService{err: tt.err}))
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	user := &model.UserCtxData{ID: uuid.NewString(), SessionID: uuid.NewString()}
+
+	tests := []struct {
+		name   string
+		user   *model.UserCtxData
+		err    error
+		status int
+	}{
+		{"revoked", user, nil, http.StatusOK},
+		{"someone else's or unknown", user, service.ErrSessionNotFound, http.StatusNotFound},
+		{"store failure", user, errors.New("connection refused"), http.StatusInternalServerError},
+		{"anonymous", nil, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := request{method: http.MethodDelete, route: "/sessions/:session_id", path: "/sessions/" + uuid.NewString(), user: tt.user}
+			if recorder := call.serve(t, RevokeSession(&sessionService{err: tt.err})); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	user := &model.UserCtxData{ID: uuid.NewString(), SessionID: uuid.NewString()}
+	call := request{method: http.MethodDelete, route: "/sessions", path: "/sessions", user: user}
+
+	authService := &sessionService{}
+	if recorder := call.serve(t, RevokeOtherSessions(authService)); recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if authService.kept != user.SessionID {
+		t.Errorf("kept session %q, want the calling one %q", authService.kept, user.SessionID)
+	}
+
+	if recorder := call.serve(t, RevokeOtherSessions(&sessionService{err: errors.New("connection refused")})); recorder.Code != http.StatusInternalServerError {
+		t.Errorf("status on store failure = %d, want %d", recorder.Code, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondSigningKeyError maps key rotation errors to responses
+func respondSigningKeyError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrKeyRotationDisabled:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    "Key rotation is not enabled",
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrKeyNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    "Signing key not found",
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrNoNextKey, service.ErrNextKeyExists, service.ErrActiveKey:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// ListSigningKeys lists the signing keys and their rotation state
+func ListSigningKeys(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		keys, err := authService.ListSigningKeys(ctx)
+		if err != nil {
+			respondSigningKeyError(ctx, err, "Error listing signing keys")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Signing keys retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"keys": keys,
+			},
+		})
+	}
+}
+
+// CreateSigningKey generates the next signing key
+func CreateSigningKey(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		key, err := authService.CreateSigningKey(ctx, user.ID)
+		if err != nil {
+			respondSigningKeyError(ctx, err, "Error generating signing key")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "Signing key generated, promote it once verifiers have refreshed their JWKS",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"key": key,
+			},
+		})
+	}
+}
+
+// PromoteSigningKey makes the next key the one that signs
+func PromoteSigningKey(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		key, err := authService.PromoteSigningKey(ctx, user.ID)
+		if err != nil {
+			respondSigningKeyError(ctx, err, "Error promoting signing key")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Signing key promoted successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"key": key,
+			},
+		})
+	}
+}
+
+// RetireSigningKey removes a next or retiring key right away
+func RetireSigningKey(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.RetireSigningKey(ctx, user.ID, ctx.Param("kid")); err != nil {
+			respondSigningKeyError(ctx, err, "Error retiring signing key")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Signing key retired successfully",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
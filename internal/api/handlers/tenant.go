@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// tenantBody is what create and update accept, update ignores the slug
+type tenantBody struct {
+	Slug     string               `json:"slug"`
+	Name     string               `json:"name" binding:"required"`
+	Host     *string              `json:"host"`
+	Settings model.TenantSettings `json:"settings"`
+}
+
+// respondTenantError maps tenant errors to responses
+func respondTenantError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrTenantNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrTenantExists:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	case service.ErrInvalidName, service.ErrInvalidTenantSettings:
+		ctx.JSON(http.StatusBadRequest, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusBadRequest,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// ListTenants lists the tenants with their overrides
+func ListTenants(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tenants, err := authService.ListTenants(ctx)
+		if err != nil {
+			respondTenantError(ctx, err, "Error listing tenants")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Tenants retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"tenants": tenants,
+			},
+		})
+	}
+}
+
+// GetTenant returns one tenant
+func GetTenant(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tenant, err := authService.GetTenant(ctx, ctx.Param("tenant_id"))
+		if err != nil {
+			respondTenantError(ctx, err, "Error retrieving tenant")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Tenant retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"tenant": tenant,
+			},
+		})
+	}
+}
+
+// CreateTenant registers a tenant and its configuration overrides
+func CreateTenant(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body tenantBody
+		if err := ctx.ShouldBindJSON(&body); err != nil || body.Slug == "" {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		tenant := &model.Tenant{
+			Slug:     body.Slug,
+			Name:     body.Name,
+			Host:     body.Host,
+			Settings: body.Settings,
+		}
+		if err := authService.CreateTenant(ctx, user.ID, tenant); err != nil {
+			respondTenantError(ctx, err, "Error creating tenant")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "Tenant created",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"tenant": tenant,
+			},
+		})
+	}
+}
+
+// UpdateTenant replaces a tenant's name, host and overrides
+func UpdateTenant(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		var body tenantBody
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		tenant, err := authService.UpdateTenant(ctx, user.ID, ctx.Param("tenant_id"), &model.Tenant{
+			Name:     body.Name,
+			Host:     body.Host,
+			Settings: body.Settings,
+		})
+		if err != nil {
+			respondTenantError(ctx, err, "Error updating tenant")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Tenant updated",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"tenant": tenant,
+			},
+		})
+	}
+}
+
+// DeleteTenant removes a tenant, its requests fall back to the global configuration
+func DeleteTenant(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.DeleteTenant(ctx, user.ID, ctx.Param("tenant_id")); err != nil {
+			respondTenantError(ctx, err, "Error deleting tenant")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Tenant deleted",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
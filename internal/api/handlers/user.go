@@ -71,6 +71,16 @@ func SignUp(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		if err := authService.CreateUser(ctx, user); err != nil {
+			if respondPasswordPolicy(ctx, err) {
+				return
+			}
+			if err == service.ErrSignupDisabled {
+				ctx.JSON(http.StatusForbidden, model.Response{
+					Message:    "Sign up is disabled",
+					StatusCode: http.StatusForbidden,
+				})
+				return
+			}
 			log.Errorf("Error creating user: %v", err)
 			ctx.JSON(http.StatusInternalServerError, model.Response{
 				Message:    "Error creating user",
@@ -134,19 +144,23 @@ func Login(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		// Authenticate user
-		user, err := authService.Login(ctx, body.Email, body.Password)
+		user, mfaToken, err := authService.Login(ctx, body.Email, body.Password)
 		if err != nil {
 			switch err {
-			case service.ErrUserNotFound, service.ErrEmailNotVerified, service.ErrPhoneNotVerified:
-				ctx.JSON(http.StatusNotFound, model.Response{
-					Message:    "User not found",
-					StatusCode: http.StatusNotFound,
-				})
-			case service.ErrInvalidCredentials:
+			// Unknown, locked and wrong password all answer alike so the response doesn't confirm the email exists
+			case service.ErrUserNotFound, service.ErrInvalidCredentials, service.ErrTooManyAttempts:
 				ctx.JSON(http.StatusUnauthorized, model.Response{
 					Message:    "Invalid credentials",
 					StatusCode: http.StatusUnauthorized,
 				})
+			// Only returned once the password was right
+			case service.ErrEmailNotVerified, service.ErrPhoneNotVerified:
+				ctx.JSON(http.StatusForbidden, model.Response{
+					Message:    err.Error(),
+					StatusCode: http.StatusForbidden,
+				})
+			case service.ErrAccountSuspended, service.ErrAccountBanned, service.ErrAccountPendingDeletion:
+				respondAccountStatus(ctx, err)
 			default:
 				log.Errorf("Error during login: %v", err)
 				ctx.JSON(http.StatusInternalServerError, model.Response{
@@ -158,39 +172,111 @@ func Login(authService service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// Generate tokens
-		accessToken, refreshToken, err := authService.GenerateTokens(ctx, user)
-		if err != nil {
-			log.Errorf("Error generating tokens: %v", err)
-			ctx.JSON(http.StatusInternalServerError, model.Response{
-				Message:    "Error generating tokens",
-				StatusCode: http.StatusInternalServerError,
-				Error:      err,
-			})
+		// Password was right but a second factor is needed before any session exists
+		if mfaToken != "" {
+			respondMFARequired(ctx, user, mfaToken)
 			return
 		}
 
-		// Set cookies
-		ctx.SetCookie("access_token", accessToken, int(time.Hour*24), "/", "", false, true)
-		ctx.SetCookie("refresh_token", refreshToken, int(time.Hour*24*7), "/", "", false, true)
+		respondWithTokens(ctx, authService, user, "Login successful", nil)
+	}
+}
 
-		ctx.JSON(http.StatusOK, model.Response{
-			Message:    "Login successful",
-			StatusCode: http.StatusOK,
-			Data: gin.H{
-				"user": gin.H{
-					"id":              user.ID,
-					"name":            user.Name,
-					"email":           user.Email,
-					"role":            user.Role,
-					"phone":           user.Phone,
-					"profile_picture": user.ProfilePicture,
-				},
-				"access_token":  accessToken,
-				"refresh_token": refreshToken,
-			},
+// respondAccountStatus answers 403 when err says the account isn't active and reports whether it did
+func respondAccountStatus(ctx *gin.Context, err error) bool {
+	switch err {
+	case service.ErrAccountSuspended, service.ErrAccountBanned, service.ErrAccountPendingDeletion, service.ErrAccountLocked:
+		ctx.JSON(http.StatusForbidden, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusForbidden,
+		})
+		return true
+	}
+	return false
+}
+
+// respondPasswordPolicy answers 400 with the broken rules per field when err is a password policy violation and
+// reports whether it did
+func respondPasswordPolicy(ctx *gin.Context, err error) bool {
+	var policyErr *service.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	ctx.JSON(http.StatusBadRequest, model.Response{
+		Message:    "Password does not meet the password policy",
+		StatusCode: http.StatusBadRequest,
+		Data: gin.H{
+			"errors": policyErr.Violations,
+		},
+	})
+	return true
+}
+
+// respondMFARequired answers a login that stops short of a session: the MFA pending token goes to /mfa/verify,
+// or to /mfa/enroll first when the account has no second factor yet
+func respondMFARequired(ctx *gin.Context, user *model.User, mfaToken string) {
+	ctx.JSON(http.StatusOK, model.Response{
+		Message:    "Two-factor authentication required",
+		StatusCode: http.StatusOK,
+		Data:       mfaRequiredData(user, mfaToken),
+	})
+}
+
+// mfaRequiredData describes the pending second step, logins finishing with a redirect pass it as query parameters
+func mfaRequiredData(user *model.User, mfaToken string) gin.H {
+	return gin.H{
+		"mfa_required":            true,
+		"mfa_enrollment_required": !user.MFAEnabled,
+		"mfa_token":               mfaToken,
+	}
+}
+
+// respondWithTokens opens a session for an authenticated user and writes the token response, extra is merged into the data
+func respondWithTokens(ctx *gin.Context, authService service.AuthService, user *model.User, message string, extra gin.H) {
+	// Generate tokens
+	accessToken, refreshToken, err := authService.GenerateTokens(ctx, user)
+	if err != nil {
+		if respondAccountStatus(ctx, err) {
+			return
+		}
+		log.Errorf("Error generating tokens: %v", err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    "Error generating tokens",
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
 		})
+		return
+	}
+
+	setTokenCookies(ctx, accessToken, refreshToken)
+
+	data := gin.H{
+		"user": gin.H{
+			"id":              user.ID,
+			"name":            user.Name,
+			"email":           user.Email,
+			"role":            user.Role,
+			"phone":           user.Phone,
+			"profile_picture": user.ProfilePicture,
+		},
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
 	}
+	for key, value := range extra {
+		data[key] = value
+	}
+
+	ctx.JSON(http.StatusOK, model.Response{
+		Message:    message,
+		StatusCode: http.StatusOK,
+		Data:       data,
+	})
+}
+
+// setTokenCookies writes the access/refresh pair as HttpOnly cookies
+func setTokenCookies(ctx *gin.Context, accessToken, refreshToken string) {
+	ctx.SetCookie("access_token", accessToken, int(time.Hour*24), "/", "", false, true)
+	ctx.SetCookie("refresh_token", refreshToken, int(time.Hour*24*7), "/", "", false, true)
 }
 
 // Logout handles user logout
@@ -242,7 +328,21 @@ func Logout(authService service.AuthService) gin.HandlerFunc {
 // RefreshToken handles token refresh
 func RefreshToken(authService service.AuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		refreshToken := ctx.GetHeader("Authorization")
+		var body struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		// Body is optional, the token may come from the cookie or the Authorization header instead
+		_ = ctx.ShouldBindJSON(&body)
+
+		refreshToken := body.RefreshToken
+		if refreshToken == "" {
+			if cookie, err := ctx.Cookie("refresh_token"); err == nil {
+				refreshToken = cookie
+			}
+		}
+		if refreshToken == "" {
+			refreshToken = utils.ExtractTokenFromHeader(ctx.GetHeader("Authorization"))
+		}
 		if refreshToken == "" {
 			ctx.JSON(http.StatusBadRequest, model.Response{
 				Message:    "Refresh token is required",
@@ -254,11 +354,25 @@ func RefreshToken(authService service.AuthService) gin.HandlerFunc {
 		accessToken, newRefreshToken, err := authService.RefreshToken(ctx, refreshToken)
 		if err != nil {
 			log.Errorf("Error refreshing token: %v", err)
-			ctx.JSON(http.StatusUnauthorized, model.Response{
-				Message:    "Invalid refresh token",
-				StatusCode: http.StatusUnauthorized,
-				Error:      err,
-			})
+			switch err {
+			case service.ErrTokenReused:
+				// The family is revoked, drop whatever the client still holds
+				ctx.SetCookie("access_token", "", -1, "/", "", false, true)
+				ctx.SetCookie("refresh_token", "", -1, "/", "", false, true)
+				ctx.JSON(http.StatusUnauthorized, model.Response{
+					Message:    "Refresh token reuse detected, please log in again",
+					StatusCode: http.StatusUnauthorized,
+					Error:      err,
+				})
+			case service.ErrAccountSuspended, service.ErrAccountBanned, service.ErrAccountPendingDeletion:
+				respondAccountStatus(ctx, err)
+			default:
+				ctx.JSON(http.StatusUnauthorized, model.Response{
+					Message:    "Invalid refresh token",
+					StatusCode: http.StatusUnauthorized,
+					Error:      err,
+				})
+			}
 			return
 		}
 
@@ -394,7 +508,7 @@ func ResetPassword(authService service.AuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var body struct {
 			Token       string `json:"token" binding:"required"`
-			NewPassword string `json:"new_password" binding:"required,min=8"`
+			NewPassword string `json:"new_password" binding:"required"`
 		}
 
 		if err := ctx.ShouldBindJSON(&body); err != nil {
@@ -407,6 +521,9 @@ func ResetPassword(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		if err := authService.ResetPassword(ctx, body.Token, body.NewPassword); err != nil {
+			if respondPasswordPolicy(ctx, err) {
+				return
+			}
 			log.Errorf("Error resetting password: %v", err)
 			ctx.JSON(http.StatusBadRequest, model.Response{
 				Message:    "Invalid or expired reset token",
@@ -423,6 +540,46 @@ func ResetPassword(authService service.AuthService) gin.HandlerFunc {
 	}
 }
 
+// UnlockAccount lifts a login lock through the emailed unlock link
+func UnlockAccount(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			Token string `json:"token" binding:"required"`
+		}
+
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, model.Response{
+				Message:    "Invalid request body",
+				StatusCode: http.StatusBadRequest,
+				Error:      err,
+			})
+			return
+		}
+
+		if err := authService.UnlockAccount(ctx, body.Token); err != nil {
+			if err == service.ErrInvalidToken {
+				ctx.JSON(http.StatusBadRequest, model.Response{
+					Message:    "Invalid or expired unlock link",
+					StatusCode: http.StatusBadRequest,
+				})
+				return
+			}
+			log.Errorf("Error unlocking account: %v", err)
+			ctx.JSON(http.StatusInternalServerError, model.Response{
+				Message:    "Error unlocking account",
+				StatusCode: http.StatusInternalServerError,
+				Error:      err,
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Account unlocked",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
+
 // GetProfile handles profile retrieval
 func GetProfile(authService service.AuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -446,6 +603,13 @@ func GetProfile(authService service.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		var recoveryCodesRemaining int64
+		if user.MFAEnabled {
+			if recoveryCodesRemaining, err = authService.CountRecoveryCodes(ctx, userID); err != nil {
+				log.Errorf("Error counting recovery codes: %v", err)
+			}
+		}
+
 		ctx.JSON(http.StatusOK, model.Response{
 			Message:    "Profile retrieved successfully",
 			StatusCode: http.StatusOK,
@@ -463,6 +627,8 @@ func GetProfile(authService service.AuthService) gin.HandlerFunc {
 					"last_login_at":     user.LastLoginAt,
 					"email_verified_at": user.EmailVerifiedAt,
 					"phone_verified_at": user.PhoneVerifiedAt,
+					"mfa_enabled":       user.MFAEnabled,
+					"recovery_codes":    recoveryCodesRemaining,
 				},
 			},
 		})
@@ -549,7 +715,7 @@ func ChangePassword(authService service.AuthService) gin.HandlerFunc {
 
 		var body struct {
 			CurrentPassword string `json:"current_password" binding:"required"`
-			NewPassword     string `json:"new_password" binding:"required,min=8"`
+			NewPassword     string `json:"new_password" binding:"required"`
 		}
 
 		if err := ctx.ShouldBindJSON(&body); err != nil {
@@ -562,6 +728,9 @@ func ChangePassword(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		if err := authService.UpdatePassword(ctx, userID, body.CurrentPassword, body.NewPassword); err != nil {
+			if respondPasswordPolicy(ctx, err) {
+				return
+			}
 			log.Errorf("Error changing password: %v", err)
 			ctx.JSON(http.StatusBadRequest, model.Response{
 				Message:    "Error changing password",
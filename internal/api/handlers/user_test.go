@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// signInService fails the password check with login and token issuance and refresh with tokens
+type signInService struct {
+	service.AuthService
+	login  error
+	tokens error
+}
+
+func (s *signInService) Login(ctx context.Context, email, password string) (*model.User, string, error) {
+	if s.login != nil {
+		return nil, "", s.login
+	}
+	id := uuid.New()
+	return &model.User{ID: &id, IsEmailVerified: true}, "", nil
+}
+
+func (s *signInService) GenerateTokens(ctx context.Context, user *model.User) (string, string, error) {
+	if s.tokens != nil {
+		return "", "", s.tokens
+	}
+	return "access", "refresh", nil
+}
+
+func (s *signInService) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	if s.tokens != nil {
+		return "", "", s.tokens
+	}
+	return "access", "refresh", nil
+}
+
+func TestAccountStatusRefused(t *testing.T) {
+	login := request{method: http.MethodPost, route: "/login", path: "/login", body: `{"email":"jane@example.com","password":"secret"}`}
+	refresh := request{method: http.MethodPost, route: "/refresh", path: "/refresh", body: `{"refresh_token":"refresh"}`}
+
+	tests := []struct {
+		name        string
+		call        request
+		authService *signInService
+		status      int
+	}{
+		{"login", login, &signInService{}, http.StatusOK},
+		{"login while suspended", login, &signInService{login: service.ErrAccountSuspended}, http.StatusForbidden},
+		{"login while banned", login, &signInService{login: service.ErrAccountBanned}, http.StatusForbidden},
+		// Lockouts answer like a wrong password, the status is only told to someone who knows it
+		{"login while locked", login, &signInService{login: service.ErrTooManyAttempts}, http.StatusUnauthorized},
+		{"tokens refused to a deleted account", login, &signInService{tokens: service.ErrAccountPendingDeletion}, http.StatusForbidden},
+		{"refresh", refresh, &signInService{}, http.StatusOK},
+		{"refresh while banned", refresh, &signInService{tokens: service.ErrAccountBanned}, http.StatusForbidden},
+		{"refresh a revoked token", refresh, &signInService{tokens: service.ErrSessionRevoked}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Login(tt.authService)
+			if tt.call.route == refresh.route {
+				handler = RefreshToken(tt.authService)
+			}
+			recorder := tt.call.serve(t, handler)
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if issued := cookie(recorder, "access_token") != nil; issued != (tt.status == http.StatusOK) {
+				t.Errorf("access token cookie set = %v, want it only for a successful sign in", issued)
+			}
+		})
+	}
+}
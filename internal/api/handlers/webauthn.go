@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// respondWebAuthnError maps WebAuthn ceremony errors to responses
+func respondWebAuthnError(ctx *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrWebAuthnDisabled:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    "WebAuthn is not enabled",
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrInvalidToken:
+		ctx.JSON(http.StatusBadRequest, model.Response{
+			Message:    "Unknown or expired challenge",
+			StatusCode: http.StatusBadRequest,
+		})
+	case service.ErrInvalidCredentials, service.ErrUserNotFound:
+		ctx.JSON(http.StatusUnauthorized, model.Response{
+			Message:    "Invalid credentials",
+			StatusCode: http.StatusUnauthorized,
+		})
+	case service.ErrAuthenticatorCloned:
+		ctx.JSON(http.StatusUnauthorized, model.Response{
+			Message:    "This authenticator can no longer be used, please contact support",
+			StatusCode: http.StatusUnauthorized,
+		})
+	case service.ErrCredentialNotFound:
+		ctx.JSON(http.StatusNotFound, model.Response{
+			Message:    "Credential not found",
+			StatusCode: http.StatusNotFound,
+		})
+	case service.ErrLastLoginMethod:
+		ctx.JSON(http.StatusConflict, model.Response{
+			Message:    err.Error(),
+			StatusCode: http.StatusConflict,
+		})
+	default:
+		log.Errorf("%s: %v", message, err)
+		ctx.JSON(http.StatusInternalServerError, model.Response{
+			Message:    message,
+			StatusCode: http.StatusInternalServerError,
+			Error:      err,
+		})
+	}
+}
+
+// BeginWebAuthnRegistration returns the credential creation options for the current user
+func BeginWebAuthnRegistration(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		options, err := authService.BeginWebAuthnRegistration(ctx, user.ID)
+		if err != nil {
+			respondWebAuthnError(ctx, err, "Error starting registration")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Registration options created",
+			StatusCode: http.StatusOK,
+			Data:       options,
+		})
+	}
+}
+
+// FinishWebAuthnRegistration verifies the authenticator response (the raw PublicKeyCredential JSON as body)
+func FinishWebAuthnRegistration(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		credential, err := authService.FinishWebAuthnRegistration(ctx, user.ID, ctx.Query("name"), ctx.Request.Body)
+		if err != nil {
+			respondWebAuthnError(ctx, err, "Error finishing registration")
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, model.Response{
+			Message:    "Authenticator registered successfully",
+			StatusCode: http.StatusCreated,
+			Data: gin.H{
+				"credential": credential,
+			},
+		})
+	}
+}
+
+// BeginWebAuthnLogin returns the assertion options, the email is optional for discoverable passkeys
+func BeginWebAuthnLogin(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var body struct {
+			Email string `json:"email"`
+		}
+		// Body is optional, an empty one starts a discoverable login
+		_ = ctx.ShouldBindJSON(&body)
+
+		options, err := authService.BeginWebAuthnLogin(ctx, body.Email)
+		if err != nil {
+			respondWebAuthnError(ctx, err, "Error starting login")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Login options created",
+			StatusCode: http.StatusOK,
+			Data:       options,
+		})
+	}
+}
+
+// FinishWebAuthnLogin verifies the assertion (the raw PublicKeyCredential JSON as body) and issues tokens, or an
+// MFA pending token when the authenticator didn't verify the user
+func FinishWebAuthnLogin(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, mfaToken, err := authService.FinishWebAuthnLogin(ctx, ctx.Request.Body)
+		if err != nil {
+			respondWebAuthnError(ctx, err, "Error finishing login")
+			return
+		}
+
+		if mfaToken != "" {
+			respondMFARequired(ctx, user, mfaToken)
+			return
+		}
+
+		respondWithTokens(ctx, authService, user, "Login successful", nil)
+	}
+}
+
+// ListWebAuthnCredentials returns the authenticators of the current user
+func ListWebAuthnCredentials(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		credentials, err := authService.ListWebAuthnCredentials(ctx, user.ID)
+		if err != nil {
+			respondWebAuthnError(ctx, err, "Error listing authenticators")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Authenticators retrieved successfully",
+			StatusCode: http.StatusOK,
+			Data: gin.H{
+				"credentials": credentials,
+			},
+		})
+	}
+}
+
+// DeleteWebAuthnCredential removes one of the current user's authenticators
+func DeleteWebAuthnCredential(authService service.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, err := utils.GetUserContext(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, model.Response{
+				Message:    "Unauthorized",
+				StatusCode: http.StatusUnauthorized,
+			})
+			return
+		}
+
+		if err := authService.DeleteWebAuthnCredential(ctx, user.ID, ctx.Param("credential_id")); err != nil {
+			respondWebAuthnError(ctx, err, "Error removing authenticator")
+			return
+		}
+
+		ctx.JSON(http.StatusOK, model.Response{
+			Message:    "Authenticator removed successfully",
+			StatusCode: http.StatusOK,
+		})
+	}
+}
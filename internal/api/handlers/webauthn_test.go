@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// passkeyService finishes every login with user, mfaToken or err, and issues tokens for whoever gets through
+type passkeyService struct {
+	service.AuthService
+	user     *model.User
+	mfaToken string
+	err      error
+	issued   bool
+}
+
+func (s *passkeyService) FinishWebAuthnLogin(ctx context.Context, body io.Reader) (*model.User, string, error) {
+	return s.user, s.mfaToken, s.err
+}
+
+func (s *passkeyService) GenerateTokens(ctx context.Context, user *model.User) (string, string, error) {
+	s.issued = true
+	return "access", "refresh", nil
+}
+
+func TestFinishWebAuthnLogin(t *testing.T) {
+	id := uuid.New()
+	call := request{method: http.MethodPost, route: "/webauthn/login/finish", path: "/webauthn/login/finish", body: "{}"}
+
+	tests := []struct {
+		name     string
+		user     *model.User
+		mfaToken string
+		err      error
+		status   int
+	}{
+		{"verified", &model.User{ID: &id}, "", nil, http.StatusOK},
+		{"second factor pending", &model.User{ID: &id, MFAEnabled: true}, "mfa-token", nil, http.StatusOK},
+		{"disabled", nil, "", service.ErrWebAuthnDisabled, http.StatusNotFound},
+		{"unknown or replayed challenge", nil, "", service.ErrInvalidToken, http.StatusBadRequest},
+		{"bad assertion", nil, "", service.ErrInvalidCredentials, http.StatusUnauthorized},
+		{"cloned authenticator", nil, "", service.ErrAuthenticatorCloned, http.StatusUnauthorized},
+		{"store failure", nil, "", io.ErrUnexpectedEOF, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := &passkeyService{user: tt.user, mfaToken: tt.mfaToken, err: tt.err}
+			recorder := call.serve(t, FinishWebAuthnLogin(authService))
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+			if authService.issued != (tt.err == nil && tt.mfaToken == "") {
+				t.Errorf("tokens issued = %v, want them only for a verified assertion needing no second step", authService.issued)
+			}
+			if tt.mfaToken != "" && !strings.Contains(recorder.Body.String(), `"mfa_token":"`+tt.mfaToken+`"`) {
+				t.Errorf("body = %s, want the MFA pending token", recorder.Body.String())
+			}
+		})
+	}
+}
+
+type credentialService struct {
+	service.AuthService
+	err error
+}
+
+func (s *credentialService) DeleteWebAuthnCredential(ctx context.Context, userID, credentialID string) error {
+	return s.err
+}
+
+func TestDeleteWebAuthnCredential(t *testing.T) {
+	user := &model.UserCtxData{ID: uuid.NewString()}
+
+	tests := []struct {
+		name   string
+		user   *model.UserCtxData
+		err    error
+		status int
+	}{
+		{"removed", user, nil, http.StatusOK},
+		{"not the user's", user, service.ErrCredentialNotFound, http.StatusNotFound},
+		{"anonymous", nil, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := request{method: http.MethodDelete, route: "/webauthn/credentials/:credential_id", path: "/webauthn/credentials/" + uuid.NewString(), user: tt.user}
+			if recorder := call.serve(t, DeleteWebAuthnCredential(&credentialService{err: tt.err})); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ func AttachDeviceLog() gin.HandlerFunc {
 		marshaledData, err := json.Marshal(model.LogData{
 			ClientIp:          c.ClientIP(),
 			UserAgent:         c.Request.UserAgent(),
+			DeviceName:        c.GetHeader("X-Device-Name"), // optional, set by clients that know a friendlier name
 			RequestedResource: c.Request.URL.Path,
 			At:                time.Now(),
 		})
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
 	"github.com/minilikmila/standard-auth-go/internal/domain/model"
@@ -18,6 +20,22 @@ func Authenticate(authService service.AuthService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var accessToken string
 		header := ctx.GetHeader("Authorization")
+		// Personal API keys act for their owner with the key's permissions only
+		if len(header) > 7 && strings.EqualFold(header[:7], "ApiKey ") {
+			userData, err := authService.AuthenticateAPIKey(ctx, strings.TrimSpace(header[7:]))
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid api key!"})
+				return
+			}
+			marshaled_ctx, err := json.Marshal(userData)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+				return
+			}
+			ctx.Set("user_data", marshaled_ctx)
+			ctx.Next()
+			return
+		}
 		if header != "" {
 			accessToken = utils.ExtractTokenFromHeader(header)
 		} else if cookie, err := ctx.Cookie("access_token"); err == nil {
@@ -29,30 +47,78 @@ func Authenticate(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		// Validate token
-		userId, err := authService.ValidateToken(ctx, accessToken)
+		tokenInfo, err := authService.ValidateAccessToken(ctx, accessToken)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token!"})
 			return
 		}
+		// Client credentials tokens act for the client, its scopes are its permissions as far as its owner still has them
+		if tokenInfo.UserID == uuid.Nil {
+			clientData, err := authService.AuthenticateClientToken(ctx, tokenInfo)
+			if err == service.ErrInvalidToken {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token!"})
+				return
+			}
+			if err != nil {
+				logrus.Errorln("Failed to resolve client access : ", err)
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+				return
+			}
+			marshaled_ctx, err := json.Marshal(clientData)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+				return
+			}
+			ctx.Set("user_data", marshaled_ctx)
+			ctx.Next()
+			return
+		}
+
+		userId := tokenInfo.UserID.String()
+		sessionId := ""
+		if tokenInfo.SessionID != nil {
+			sessionId = tokenInfo.SessionID.String()
+		}
 
 		user, err := authService.GetProfile(ctx, userId)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-		// TODO: validate token type is "access_token" not refresh
-		// TODO: compare the attached token in the header role with user actual token and to reject unauthorized access.
-		// TODO: decode and extract token
+		// Checked on every request so a suspension or ban cuts off tokens already handed out
+		if err := authService.CheckAccountStatus(user); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		// Roles and permissions are resolved once here, everything later in the request reads them from the context
+		roles, permissions, err := authService.GetUserAccess(ctx, user)
+		if err != nil {
+			logrus.Errorln("Failed to resolve user access : ", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		}
 
 		// put bytes of user data in the context
-		marshaled_ctx, err := json.Marshal(model.UserCtxData{
-			ID:   userId,
-			Role: user.Role,
-		})
-		logrus.Infoln("User data : ", model.UserCtxData{
-			ID:   userId,
-			Role: user.Role,
-		})
+		userData := model.UserCtxData{
+			ID:          userId,
+			SessionID:   sessionId,
+			Role:        user.Role,
+			Roles:       roles,
+			Permissions: permissions,
+		}
+
+		// Tokens switched to an organization only work while the user is still a member
+		if tokenInfo.OrgID != nil {
+			membership, err := authService.GetMembership(ctx, tokenInfo.OrgID.String(), userId)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token!"})
+				return
+			}
+			userData.OrgID = membership.OrganizationID.String()
+			userData.OrgRole = membership.Role
+		}
+		marshaled_ctx, err := json.Marshal(userData)
+		logrus.Infoln("User data : ", userData)
 
 		if err != nil {
 			fmt.Println("decoding error :", err)
@@ -65,3 +131,19 @@ func Authenticate(authService service.AuthService) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// DenyAPIKeys keeps API keys off the routes acting on the owner's own account, a leaked key must not be able to
+// mint keys, drop MFA, change the password or sign the owner out
+func DenyAPIKeys() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Read directly, GetUserContext hides API key callers behind the same error as anonymous ones
+		var user model.UserCtxData
+		if raw, ok := ctx.Get("user_data"); ok {
+			if data, ok := raw.([]byte); ok && json.Unmarshal(data, &user) == nil && user.APIKeyID != "" {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden: not available to api keys"})
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// apiKeyService knows a single key, every other method is left unimplemented
+type apiKeyService struct {
+	service.AuthService
+	key  string
+	user *model.UserCtxData
+}
+
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*model.UserCtxData, error) {
+	if key != s.key {
+		return nil, service.ErrInvalidToken
+	}
+	return s.user, nil
+}
+
+func TestDenyAPIKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   *model.UserCtxData
+		status int
+	}{
+		{"user session", &model.UserCtxData{ID: "user", SessionID: "session"}, http.StatusOK},
+		{"api key", &model.UserCtxData{ID: "user", APIKeyID: "key", Permissions: []string{"users:read"}}, http.StatusForbidden},
+		{"client token", &model.UserCtxData{ClientID: "client"}, http.StatusOK},
+		{"no user", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder := serveAs(t, tt.user, "/", "/", DenyAPIKeys()); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authService := &apiKeyService{
+		key:  "sak_abc_secret",
+		user: &model.UserCtxData{ID: "owner", APIKeyID: "key", Permissions: []string{model.PermissionRolesRead}},
+	}
+
+	// Guarded the way routes.go guards them
+	router := gin.New()
+	v2 := router.Group("/", Authenticate(authService))
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	v2.GET("/profile/:id", DenyAPIKeys(), Authorize([]string{"admin", "user"}, nil, CustomAuthorizePolicy), ok)
+	v2.POST("/api-keys", DenyAPIKeys(), ok)
+	v2.GET("/roles", Authorize(nil, []string{model.PermissionRolesRead}, nil), ok)
+	v2.POST("/roles", Authorize(nil, []string{model.PermissionRolesWrite}, nil), ok)
+	v2.GET("/admin/users", Authorize([]string{"admin"}, nil, nil), ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{"permission the key carries", http.MethodGet, "/roles", "sak_abc_secret", http.StatusOK},
+		{"permission the key lacks", http.MethodPost, "/roles", "sak_abc_secret", http.StatusForbidden},
+		{"owner's profile", http.MethodGet, "/profile/owner", "sak_abc_secret", http.StatusForbidden},
+		{"key management", http.MethodPost, "/api-keys", "sak_abc_secret", http.StatusForbidden},
+		{"role gated route", http.MethodGet, "/admin/users", "sak_abc_secret", http.StatusForbidden},
+		{"unknown key", http.MethodGet, "/roles", "sak_abc_other", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "ApiKey "+tt.key)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.status {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+// revokedService reports every access token's session as revoked
+type revokedService struct {
+	service.AuthService
+}
+
+func (s *revokedService) ValidateAccessToken(ctx context.Context, token string) (*model.TokenInfo, error) {
+	return nil, service.ErrSessionRevoked
+}
+
+func TestAuthenticateRevokedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", Authenticate(&revokedService{}), func(ctx *gin.Context) {
+		t.Error("request of a revoked session reached the handler")
+	})
+
+	for _, set := range []func(req *http.Request){
+		func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") },
+		func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "access_token", Value: "token"}) },
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		set(req)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+// clientTokenService takes every access token for a client credentials token, resolved to access
+type clientTokenService struct {
+	service.AuthService
+	access *model.UserCtxData
+}
+
+func (s *clientTokenService) ValidateAccessToken(ctx context.Context, token string) (*model.TokenInfo, error) {
+	return &model.TokenInfo{ClientID: "etl", Scope: model.PermissionRolesRead + " " + model.PermissionRolesWrite}, nil
+}
+
+func (s *clientTokenService) AuthenticateClientToken(ctx context.Context, info *model.TokenInfo) (*model.UserCtxData, error) {
+	if s.access == nil {
+		return nil, service.ErrInvalidToken
+	}
+	return s.access, nil
+}
+
+func TestClientTokenPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	narrowed := &model.UserCtxData{ClientID: "etl", Permissions: []string{model.PermissionRolesRead}}
+
+	tests := []struct {
+		name   string
+		access *model.UserCtxData
+		path   string
+		status int
+	}{
+		{"scope the owner holds", narrowed, "/roles/read", http.StatusOK},
+		{"scope the owner lost", narrowed, "/roles/write", http.StatusForbidden},
+		{"owner gone", nil, "/roles/read", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			v2 := router.Group("/", Authenticate(&clientTokenService{access: tt.access}))
+			ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+			v2.GET("/roles/read", Authorize(nil, []string{model.PermissionRolesRead}, nil), ok)
+			v2.GET("/roles/write", Authorize(nil, []string{model.PermissionRolesWrite}, nil), ok)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
@@ -3,7 +3,6 @@ package middleware
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/minilikmila/standard-auth-go/internal/domain/model"
@@ -29,11 +28,11 @@ func Authorize(
 			return
 		}
 
-		// Role check
+		// Role check, any of the user's roles will do
 		if len(allowedRoles) > 0 {
 			allowed := false
 			for _, role := range allowedRoles {
-				if strings.EqualFold(role, userData.Role) {
+				if userData.HasRole(role) {
 					allowed = true
 					break
 				}
@@ -45,17 +44,9 @@ func Authorize(
 		}
 
 		// Permission check
-		if len(requiredPermissions) > 0 {
-			permMap := make(map[string]struct{}, len(userData.Permissions))
-			for _, p := range userData.Permissions {
-				permMap[p] = struct{}{}
-			}
-			for _, rp := range requiredPermissions {
-				if _, ok := permMap[rp]; !ok {
-					ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden: permission"})
-					return
-				}
-			}
+		if len(requiredPermissions) > 0 && !model.ScopesCover(userData.Permissions, requiredPermissions) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden: permission"})
+			return
 		}
 
 		// Custom policy check
@@ -70,9 +61,28 @@ func Authorize(
 
 // UserOrAdminPolicy allows admin to access any resource, and users to access only their own resource (by id param)
 func CustomAuthorizePolicy(user model.UserCtxData, ctx *gin.Context) bool {
-	if strings.EqualFold(user.Role, "admin") {
+	if user.HasRole("admin") {
 		return true
 	}
 	idParam := ctx.Param("id")
-	return strings.EqualFold(user.Role, "user") && idParam == user.ID
+	return user.HasRole("user") && idParam == user.ID
+}
+
+// OrgRolePolicy lets members holding one of the roles act on the organization in the :org_id param, through a token
+// switched to that organization. Holders of organizations:write act on every organization.
+func OrgRolePolicy(roles ...string) func(user model.UserCtxData, ctx *gin.Context) bool {
+	return func(user model.UserCtxData, ctx *gin.Context) bool {
+		if user.HasPermission(model.PermissionOrganizationsWrite) {
+			return true
+		}
+		if user.OrgID == "" || user.OrgID != ctx.Param("org_id") {
+			return false
+		}
+		for _, role := range roles {
+			if role == user.OrgRole {
+				return true
+			}
+		}
+		return false
+	}
 }
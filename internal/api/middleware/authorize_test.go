@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+func TestAuthorize(t *testing.T) {
+	editor := &model.UserCtxData{ID: "jane", Role: "user", Roles: []string{"user", "Editor"}, Permissions: []string{"posts:read", "posts:write"}}
+
+	tests := []struct {
+		name        string
+		user        *model.UserCtxData
+		roles       []string
+		permissions []string
+		policy      func(model.UserCtxData, *gin.Context) bool
+		status      int
+	}{
+		{"assigned role", editor, []string{"admin", "editor"}, nil, nil, http.StatusOK},
+		{"primary role", editor, []string{"user"}, nil, nil, http.StatusOK},
+		{"missing role", editor, []string{"admin"}, nil, nil, http.StatusForbidden},
+		{"every permission", editor, nil, []string{"posts:read", "posts:write"}, nil, http.StatusOK},
+		{"one permission missing", editor, nil, []string{"posts:read", "posts:delete"}, nil, http.StatusForbidden},
+		{"role without the permission", editor, []string{"editor"}, []string{"roles:write"}, nil, http.StatusForbidden},
+		{"policy refuses", editor, []string{"editor"}, nil, func(model.UserCtxData, *gin.Context) bool { return false }, http.StatusForbidden},
+		{"no user", nil, nil, nil, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder := serveAs(t, tt.user, "/", "/", Authorize(tt.roles, tt.permissions, tt.policy)); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestCustomAuthorizePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		user   *model.UserCtxData
+		path   string
+		status int
+	}{
+		{"own profile", &model.UserCtxData{ID: "jane", Role: "user"}, "/profile/jane", http.StatusOK},
+		{"someone else's profile", &model.UserCtxData{ID: "jane", Role: "user"}, "/profile/john", http.StatusForbidden},
+		{"admin", &model.UserCtxData{ID: "root", Roles: []string{"admin"}}, "/profile/john", http.StatusOK},
+		{"neither user nor admin", &model.UserCtxData{ID: "jane", Role: "guest"}, "/profile/jane", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveAs(t, tt.user, "/profile/:id", tt.path, Authorize(nil, nil, CustomAuthorizePolicy))
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestOrgRolePolicy(t *testing.T) {
+	managers := Authorize(nil, nil, OrgRolePolicy(model.OrgRoleOwner, model.OrgRoleAdmin))
+
+	tests := []struct {
+		name   string
+		user   *model.UserCtxData
+		status int
+	}{
+		{"admin of the organization", &model.UserCtxData{ID: "jane", OrgID: "acme", OrgRole: model.OrgRoleAdmin}, http.StatusOK},
+		{"member of the organization", &model.UserCtxData{ID: "jane", OrgID: "acme", OrgRole: model.OrgRoleMember}, http.StatusForbidden},
+		{"admin of another organization", &model.UserCtxData{ID: "jane", OrgID: "globex", OrgRole: model.OrgRoleAdmin}, http.StatusForbidden},
+		{"token not switched to an organization", &model.UserCtxData{ID: "jane", OrgRole: model.OrgRoleOwner}, http.StatusForbidden},
+		{"global admin role", &model.UserCtxData{ID: "root", Roles: []string{"admin"}}, http.StatusForbidden},
+		{"organizations:write", &model.UserCtxData{ID: "root", Permissions: []string{model.PermissionOrganizationsWrite}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder := serveAs(t, tt.user, "/orgs/:org_id/members", "/orgs/acme/members", managers); recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
+
+// accessService signs in user with every token, resolving access to roles and permissions
+type accessService struct {
+	service.AuthService
+	user        *model.User
+	status      error
+	roles       []string
+	permissions []string
+	err         error
+	resolved    int
+
+	orgID      *uuid.UUID        // org_id claim of the token
+	membership *model.Membership // nil when the user isn't a member
+}
+
+func (s *accessService) ValidateAccessToken(ctx context.Context, token string) (*model.TokenInfo, error) {
+	return &model.TokenInfo{UserID: *s.user.ID, OrgID: s.orgID}, nil
+}
+
+func (s *accessService) GetMembership(ctx context.Context, orgID, userID string) (*model.Membership, error) {
+	if s.membership == nil || s.membership.OrganizationID.String() != orgID {
+		return nil, service.ErrNotMember
+	}
+	return s.membership, nil
+}
+
+func (s *accessService) GetProfile(ctx context.Context, userID string) (*model.User, error) {
+	return s.user, nil
+}
+
+func (s *accessService) CheckAccountStatus(user *model.User) error {
+	return s.status
+}
+
+func (s *accessService) GetUserAccess(ctx context.Context, user *model.User) ([]string, []string, error) {
+	s.resolved++
+	return s.roles, s.permissions, s.err
+}
+
+// authenticated sends a bearer request through Authenticate and the middleware that follow it, the user data
+// reaching the handler is returned for requests getting through
+func authenticated(t *testing.T, authService service.AuthService, middleware ...gin.HandlerFunc) (*httptest.ResponseRecorder, *model.UserCtxData) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var userData *model.UserCtxData
+	chain := append([]gin.HandlerFunc{Authenticate(authService)}, middleware...)
+	chain = append(chain, func(ctx *gin.Context) {
+		userData = &model.UserCtxData{}
+		if err := json.Unmarshal(ctx.MustGet("user_data").([]byte), userData); err != nil {
+			t.Fatal(err)
+		}
+		ctx.Status(http.StatusOK)
+	})
+
+	router := gin.New()
+	router.GET("/", chain...)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder, userData
+}
+
+func TestAuthenticateResolvesAccess(t *testing.T) {
+	id := uuid.New()
+	user := &model.User{ID: &id, Role: "user"}
+
+	authService := &accessService{user: user, roles: []string{"user", "editor"}, permissions: []string{"posts:write"}}
+	recorder, userData := authenticated(t, authService,
+		Authorize([]string{"editor"}, nil, nil),
+		Authorize(nil, []string{"posts:write"}, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if userData.ID != id.String() || userData.Role != "user" || len(userData.Roles) != 2 || len(userData.Permissions) != 1 {
+		t.Errorf("user data = %+v", userData)
+	}
+	if authService.resolved != 1 {
+		t.Errorf("access resolved %d times, want once per request", authService.resolved)
+	}
+
+	recorder, _ = authenticated(t, &accessService{user: user, err: errors.New("store down")})
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("status when access can't be resolved = %d, want %d", recorder.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestAuthenticateOrganization(t *testing.T) {
+	id, orgID := uuid.New(), uuid.New()
+	user := &model.User{ID: &id, Role: "user"}
+	admin := &model.Membership{OrganizationID: orgID, UserID: id, Role: model.OrgRoleAdmin}
+
+	recorder, userData := authenticated(t, &accessService{user: user, orgID: &orgID, membership: admin})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if userData.OrgID != orgID.String() || userData.OrgRole != model.OrgRoleAdmin {
+		t.Errorf("user data = %+v, want the membership of the token's organization", userData)
+	}
+
+	// Removed from the organization after switching to it
+	if recorder, _ := authenticated(t, &accessService{user: user, orgID: &orgID}); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("status of a former member = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	recorder, userData = authenticated(t, &accessService{user: user, membership: admin})
+	if recorder.Code != http.StatusOK || userData.OrgID != "" || userData.OrgRole != "" {
+		t.Errorf("token without org_id: status %d, user data %+v", recorder.Code, userData)
+	}
+}
+
+func TestAuthenticateAccountStatus(t *testing.T) {
+	id := uuid.New()
+	user := &model.User{ID: &id, Role: "user"}
+
+	for _, status := range []error{service.ErrAccountSuspended, service.ErrAccountBanned, service.ErrAccountPendingDeletion} {
+		authService := &accessService{user: user, status: status}
+		if recorder, _ := authenticated(t, authService); recorder.Code != http.StatusForbidden {
+			t.Errorf("%v: status = %d, want %d", status, recorder.Code, http.StatusForbidden)
+		}
+		if authService.resolved != 0 {
+			t.Errorf("%v: access resolved for an inactive account", status)
+		}
+	}
+}
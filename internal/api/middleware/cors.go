@@ -2,14 +2,15 @@ package middleware
 
 import "github.com/gin-gonic/gin"
 
-func CorsMiddleware() gin.HandlerFunc {
+// CorsMiddleware also allows the tenant header so browser apps can pick their tenant
+func CorsMiddleware(tenantHeader string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 
 		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		// ctx.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, Cache-Control, X-Requested-With, X-Forwarded-Proto")
-		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
+		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, Cache-Control, X-Requested-With, X-Forwarded-Proto, X-Device-Name, "+tenantHeader)
+		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if ctx.Request.Method == "OPTIONS" {
 			ctx.AbortWithStatus(200)
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// serveAs sends a GET for path through the middleware mounted on route, with user in the context the way
+// Authenticate puts it there. Requests getting through the middleware are answered 200.
+func serveAs(t *testing.T, user *model.UserCtxData, route, path string, middleware ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	chain := []gin.HandlerFunc{func(ctx *gin.Context) {
+		if user != nil {
+			data, err := json.Marshal(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx.Set("user_data", data)
+		}
+		ctx.Next()
+	}}
+	chain = append(chain, middleware...)
+	chain = append(chain, func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	router := gin.New()
+	router.GET(route, chain...)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	return recorder
+}
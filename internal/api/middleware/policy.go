@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/minilikmila/standard-auth-go/internal/auth/policy"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// PolicyCheck is a custom policy for Authorize backed by the policy engine. The route params are the resource
+// attributes, e.g. resource.id for /profile/:id.
+func PolicyCheck(engine *policy.Engine, action string) func(user model.UserCtxData, ctx *gin.Context) bool {
+	return func(user model.UserCtxData, ctx *gin.Context) bool {
+		decision := engine.Evaluate(PolicyRequest(engine, user, ctx, action))
+		if !decision.Allowed {
+			logrus.WithField("user", user.ID).Infoln("Policy check failed : ", decision.Reason)
+		}
+		return decision.Allowed
+	}
+}
+
+// PolicyRequest describes the current request to the policy engine
+func PolicyRequest(engine *policy.Engine, user model.UserCtxData, ctx *gin.Context, action string) policy.Request {
+	resource := map[string]interface{}{}
+	for _, param := range ctx.Params {
+		resource[param.Key] = param.Value
+	}
+	return policy.Request{
+		Subject:  policy.Subject(user),
+		Action:   action,
+		Resource: resource,
+		Env:      engine.Env(time.Now(), ctx.ClientIP()),
+	}
+}
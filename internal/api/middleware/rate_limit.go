@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/auth/ratelimit"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// maxPeekedBody bounds how much of a request body is read looking for the email or phone
+const maxPeekedBody = 1 << 20
+
+// RateLimit throttles the route per client IP and per email or phone number in the JSON body, answering 429
+// with Retry-After once a bucket is empty. The store failing lets requests through rather than locking everyone out.
+func RateLimit(store ratelimit.Store, route string, limit config.RateLimit) gin.HandlerFunc {
+	if limit.Window <= 0 || (limit.Requests <= 0 && limit.IdentifierRequests <= 0) {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	window := limit.Window * time.Second
+
+	return func(ctx *gin.Context) {
+		if limit.Requests > 0 {
+			if !take(ctx, store, route+":ip:"+clientIP(ctx), limit.Requests, window) {
+				return
+			}
+		}
+		if limit.IdentifierRequests > 0 {
+			// Hashed so a shared store doesn't hold addresses
+			if identifier := requestIdentifier(ctx); identifier != "" {
+				if !take(ctx, store, route+":id:"+utils.HashToken(identifier), limit.IdentifierRequests, window) {
+					return
+				}
+			}
+		}
+		ctx.Next()
+	}
+}
+
+// take spends a request from the key's bucket and aborts with 429 when it is empty
+func take(ctx *gin.Context, store ratelimit.Store, key string, limit int, window time.Duration) bool {
+	allowed, retryAfter, err := store.Take(ctx, key, limit, window)
+	if err != nil {
+		logrus.Errorf("Error checking rate limit: %v", err)
+		return true
+	}
+	if allowed {
+		return true
+	}
+
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, model.Response{
+		Message:    "Too many requests, try again later",
+		StatusCode: http.StatusTooManyRequests,
+	})
+	return false
+}
+
+// clientIP is the address AttachDeviceLog recorded for the request
+func clientIP(ctx *gin.Context) string {
+	var device model.LogData
+	if raw, ok := ctx.Get("log_data"); ok {
+		if data, ok := raw.([]byte); ok && json.Unmarshal(data, &device) == nil && device.ClientIp != "" {
+			return device.ClientIp
+		}
+	}
+	return ctx.ClientIP()
+}
+
+// requestIdentifier is the email, or else the phone number, the JSON body targets. The body is put back for
+// the handler.
+func requestIdentifier(ctx *gin.Context) string {
+	if ctx.Request.Body == nil {
+		return ""
+	}
+	raw, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxPeekedBody))
+	if err != nil {
+		return ""
+	}
+	ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(raw), ctx.Request.Body))
+
+	var body struct {
+		Email string `json:"email"`
+		Phone string `json:"phone"`
+	}
+	if json.Unmarshal(raw, &body) != nil {
+		return ""
+	}
+	if email := strings.ToLower(strings.TrimSpace(body.Email)); email != "" {
+		return "email:" + email
+	}
+	if phone := strings.TrimSpace(body.Phone); phone != "" {
+		return "phone:" + phone
+	}
+	return ""
+}
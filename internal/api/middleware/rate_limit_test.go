@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/auth/ratelimit"
+)
+
+// rateLimitedRouter serves POST /login behind RateLimit, echoing the body the handler received
+func rateLimitedRouter(t *testing.T, limit config.RateLimit, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(AttachDeviceLog())
+	router.POST("/login", RateLimit(ratelimit.NewMemoryStore(), "login", limit), func(ctx *gin.Context) {
+		body, _ := io.ReadAll(ctx.Request.Body)
+		ctx.String(http.StatusOK, string(body))
+	})
+	return router
+}
+
+type loginRequest struct {
+	remoteAddr string
+	forwarded  string
+	body       string
+	status     int
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          config.RateLimit
+		trustedProxies []string
+		requests       []loginRequest
+	}{
+		{
+			"per ip",
+			config.RateLimit{Requests: 2, Window: 60},
+			nil,
+			[]loginRequest{
+				{"198.51.100.1:1000", "", `{}`, http.StatusOK},
+				{"198.51.100.1:1001", "", `{}`, http.StatusOK},
+				{"198.51.100.1:1002", "", `{}`, http.StatusTooManyRequests},
+				{"198.51.100.2:1000", "", `{}`, http.StatusOK},
+			},
+		},
+		{
+			"forwarded header ignored from untrusted peers",
+			config.RateLimit{Requests: 1, Window: 60},
+			nil,
+			[]loginRequest{
+				{"198.51.100.1:1000", "203.0.113.1", `{}`, http.StatusOK},
+				{"198.51.100.1:1000", "203.0.113.2", `{}`, http.StatusTooManyRequests},
+			},
+		},
+		{
+			"forwarded header believed from trusted proxies",
+			config.RateLimit{Requests: 1, Window: 60},
+			[]string{"10.0.0.0/8"},
+			[]loginRequest{
+				{"10.0.0.5:1000", "203.0.113.1", `{}`, http.StatusOK},
+				{"10.0.0.5:1000", "203.0.113.2", `{}`, http.StatusOK},
+				{"10.0.0.5:1000", "203.0.113.1", `{}`, http.StatusTooManyRequests},
+			},
+		},
+		{
+			"per email across ips",
+			config.RateLimit{IdentifierRequests: 1, Window: 60},
+			nil,
+			[]loginRequest{
+				{"198.51.100.1:1000", "", `{"email": "jane@example.com"}`, http.StatusOK},
+				{"198.51.100.2:1000", "", `{"email": " JANE@example.com "}`, http.StatusTooManyRequests},
+				{"198.51.100.2:1000", "", `{"email": "john@example.com"}`, http.StatusOK},
+				{"198.51.100.2:1000", "", `{"phone": "+15550100"}`, http.StatusOK},
+				{"198.51.100.3:1000", "", `{"phone": "+15550100"}`, http.StatusTooManyRequests},
+			},
+		},
+		{
+			"off without a window",
+			config.RateLimit{Requests: 1},
+			nil,
+			[]loginRequest{
+				{"198.51.100.1:1000", "", `{}`, http.StatusOK},
+				{"198.51.100.1:1000", "", `{}`, http.StatusOK},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := rateLimitedRouter(t, tt.limit, tt.trustedProxies)
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(r.body))
+				req.RemoteAddr = r.remoteAddr
+				if r.forwarded != "" {
+					req.Header.Set("X-Forwarded-For", r.forwarded)
+				}
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, req)
+
+				if recorder.Code != r.status {
+					t.Fatalf("request %d: status = %d, want %d", i, recorder.Code, r.status)
+				}
+				switch r.status {
+				case http.StatusTooManyRequests:
+					if recorder.Header().Get("Retry-After") == "" {
+						t.Errorf("request %d: 429 without Retry-After", i)
+					}
+				case http.StatusOK:
+					if recorder.Body.String() != r.body {
+						t.Errorf("request %d: handler got body %q, want %q", i, recorder.Body.String(), r.body)
+					}
+				}
+			}
+		})
+	}
+}
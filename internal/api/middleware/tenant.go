@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/service"
+)
+
+// ResolveTenant matches the request to a tenant by the tenant header or, without one, by its host and stores it
+// under "tenant" for the services. Requests matching no tenant run with the global configuration, a tenant
+// header naming an unknown tenant is rejected.
+func ResolveTenant(authService service.AuthService, header string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tenant, err := authService.ResolveTenant(ctx, ctx.GetHeader(header), ctx.Request.Host)
+		if err != nil {
+			if err == service.ErrTenantNotFound {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, model.Response{
+					Message:    "Unknown tenant",
+					StatusCode: http.StatusBadRequest,
+				})
+				return
+			}
+			logrus.Errorf("Error resolving tenant: %v", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, model.Response{
+				Message:    "Error resolving tenant",
+				StatusCode: http.StatusInternalServerError,
+			})
+			return
+		}
+
+		if tenant != nil {
+			ctx.Set("tenant", tenant)
+		}
+		ctx.Next()
+	}
+}
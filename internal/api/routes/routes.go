@@ -1,26 +1,35 @@
 package routes
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 
 	config "github.com/minilikmila/standard-auth-go/configs"
 	"github.com/minilikmila/standard-auth-go/internal/api/handlers"
 	"github.com/minilikmila/standard-auth-go/internal/api/middleware"
+	"github.com/minilikmila/standard-auth-go/internal/auth/policy"
+	"github.com/minilikmila/standard-auth-go/internal/auth/ratelimit"
 	"github.com/minilikmila/standard-auth-go/internal/domain/model"
 	database_ "github.com/minilikmila/standard-auth-go/internal/infrastructure/database"
 	"github.com/minilikmila/standard-auth-go/internal/service"
 )
 
-func InitRoute(db *database_.Repository, config *config.Config, envMode string, authService service.AuthService) *gin.Engine {
+func InitRoute(db *database_.Repository, config *config.Config, envMode string, authService service.AuthService, policies *policy.Engine, limiter ratelimit.Store) *gin.Engine {
 	gin.SetMode(envMode)
 	fmt.Println("Gin mode: ", gin.Mode())
 	route := gin.New()
+	// X-Forwarded-For is only believed from the configured proxies, otherwise any client could pick its own IP
+	if err := route.SetTrustedProxies(config.TrustedProxies); err != nil {
+		logrus.Fatalf("Invalid trusted proxies: %v", err)
+	}
 	route.Use(gin.Recovery())
 	route.Use(middleware.AttachDeviceLog())
-	route.Use(middleware.CorsMiddleware())
+	route.Use(middleware.CorsMiddleware(config.TenantHeader))
+	route.Use(middleware.ResolveTenant(authService, config.TenantHeader))
 
 	// GROUPING ROUTES
 	v1 := route.Group("/api/v1/auth")
@@ -28,25 +37,163 @@ func InitRoute(db *database_.Repository, config *config.Config, envMode string,
 
 	// MIDDLEWARE
 	v2.Use(middleware.Authenticate(authService))
+	// API keys carry permissions and no roles, they only reach routes guarded by permissions. Routes acting on the
+	// signed-in user's own account refuse them outright.
+	noAPIKeys := middleware.DenyAPIKeys()
 
-	// Auth routes
-	v1.POST("/sign-up", handlers.SignUp(authService))
-	v1.POST("/login", handlers.Login(authService))
+	// Auth routes, throttled per route by config.RateLimits
+	limit := func(route string) gin.HandlerFunc {
+		return middleware.RateLimit(limiter, route, config.RateLimits[route])
+	}
+	v1.POST("/sign-up", limit("sign-up"), handlers.SignUp(authService))
+	v1.POST("/login", limit("login"), handlers.Login(authService))
 	v1.POST("/logout", handlers.Logout(authService))
-	// v1.POST("/refresh-token", handlers.RefreshToken(authService))
-	v1.POST("/verify-email", handlers.VerifyEmail(authService))
-	v1.POST("/verify-phone", handlers.VerifyPhone(authService))
-	v1.POST("/forgot-password", handlers.ForgotPassword(authService))
-	v1.POST("/reset-password", handlers.ResetPassword(authService))
+	v1.POST("/refresh-token", handlers.RefreshToken(authService))
+	v1.POST("/verify-email", limit("verify-email"), handlers.VerifyEmail(authService))
+	v1.POST("/verify-phone", limit("verify-phone"), handlers.VerifyPhone(authService))
+	v1.POST("/forgot-password", limit("forgot-password"), handlers.ForgotPassword(authService))
+	v1.POST("/reset-password", limit("reset-password"), handlers.ResetPassword(authService))
+	v1.POST("/unlock", limit("unlock"), handlers.UnlockAccount(authService))
+	v1.POST("/magic-link", limit("magic-link"), handlers.SendMagicLink(authService))
+	v1.POST("/magic-link/verify", limit("magic-link/verify"), handlers.VerifyMagicLink(authService))
+	v1.POST("/otp", limit("otp"), handlers.SendLoginOTP(authService))
+	v1.POST("/otp/verify", limit("otp/verify"), handlers.VerifyLoginOTP(authService))
+	v1.POST("/mfa/verify", limit("mfa/verify"), handlers.VerifyMFA(authService))
+	v1.POST("/mfa/enroll", limit("mfa/enroll"), handlers.EnrollTOTPWithMFAToken(authService))
+	v1.POST("/mfa/enroll/confirm", limit("mfa/enroll/confirm"), handlers.ConfirmTOTPWithMFAToken(authService))
+	v1.POST("/webauthn/register/begin", middleware.Authenticate(authService), middleware.DenyAPIKeys(), handlers.BeginWebAuthnRegistration(authService))
+	v1.POST("/webauthn/register/finish", middleware.Authenticate(authService), middleware.DenyAPIKeys(), handlers.FinishWebAuthnRegistration(authService))
+	v1.POST("/webauthn/login/begin", limit("webauthn/login/begin"), handlers.BeginWebAuthnLogin(authService))
+	v1.POST("/webauthn/login/finish", limit("webauthn/login/finish"), handlers.FinishWebAuthnLogin(authService))
+
+	// Social login, every globally enabled provider gets its own start/callback pair, tenants narrow them per request
+	v1.GET("/oauth/providers", handlers.ListOAuthProviders(authService))
+	for _, provider := range authService.OAuthProviders(context.Background()) {
+		v1.GET("/oauth/"+provider+"/start", handlers.OAuthStart(authService, provider))
+		v1.GET("/oauth/"+provider+"/callback", handlers.OAuthCallback(authService, provider, config.SocialAuthRedirectUrl))
+	}
 
 	// Profile routes
-	v2.GET("/profile/:id", middleware.Authorize([]string{"admin", "user"},
-		nil,
-		middleware.CustomAuthorizePolicy), handlers.GetProfile(authService))
-	v2.PUT("/profile/:id", middleware.Authorize([]string{"admin", "user"},
-		nil,
-		middleware.CustomAuthorizePolicy), handlers.UpdateProfile(authService))
-	v2.PATCH("/change-password/:id", handlers.ChangePassword(authService))
+	// A policy file replaces the built-in owner-or-admin rule
+	profileRead := middleware.Authorize([]string{"admin", "user"}, nil, middleware.CustomAuthorizePolicy)
+	profileUpdate := profileRead
+	if policies != nil {
+		profileRead = middleware.Authorize(nil, nil, middleware.PolicyCheck(policies, "profile:read"))
+		profileUpdate = middleware.Authorize(nil, nil, middleware.PolicyCheck(policies, "profile:update"))
+	}
+	v2.GET("/profile/:id", noAPIKeys, profileRead, handlers.GetProfile(authService))
+	v2.PUT("/profile/:id", noAPIKeys, profileUpdate, handlers.UpdateProfile(authService))
+	v2.PATCH("/change-password/:id", noAPIKeys, handlers.ChangePassword(authService))
+
+	// Session routes
+	v2.GET("/sessions", noAPIKeys, handlers.ListSessions(authService))
+	v2.DELETE("/sessions", noAPIKeys, handlers.RevokeOtherSessions(authService))
+	v2.DELETE("/sessions/:session_id", noAPIKeys, handlers.RevokeSession(authService))
+
+	// Two-factor routes
+	v2.POST("/mfa/totp/enroll", noAPIKeys, handlers.EnrollTOTP(authService))
+	v2.POST("/mfa/totp/confirm", noAPIKeys, handlers.ConfirmTOTP(authService))
+	v2.POST("/mfa/totp/disable", noAPIKeys, handlers.DisableTOTP(authService))
+	v2.POST("/mfa/recovery-codes", noAPIKeys, handlers.RegenerateRecoveryCodes(authService))
+
+	// WebAuthn credential routes
+	v2.GET("/webauthn/credentials", noAPIKeys, handlers.ListWebAuthnCredentials(authService))
+	v2.DELETE("/webauthn/credentials/:credential_id", noAPIKeys, handlers.DeleteWebAuthnCredential(authService))
+
+	// Linked identity routes
+	v2.GET("/identities", noAPIKeys, handlers.ListIdentities(authService))
+	v2.POST("/identities/:provider/link", noAPIKeys, handlers.LinkIdentity(authService))
+	v2.DELETE("/identities/:identity_id", noAPIKeys, handlers.UnlinkIdentity(authService))
+
+	// Personal API keys
+	v2.GET("/api-keys", noAPIKeys, handlers.ListAPIKeys(authService))
+	v2.POST("/api-keys", noAPIKeys, handlers.CreateAPIKey(authService))
+	v2.DELETE("/api-keys/:key_id", noAPIKeys, handlers.RevokeAPIKey(authService))
+
+	// Roles and permissions
+	rolesRead := middleware.Authorize(nil, []string{model.PermissionRolesRead}, nil)
+	rolesWrite := middleware.Authorize(nil, []string{model.PermissionRolesWrite}, nil)
+	v2.GET("/roles", rolesRead, handlers.ListRoles(authService))
+	v2.POST("/roles", rolesWrite, handlers.CreateRole(authService))
+	v2.DELETE("/roles/:role", rolesWrite, handlers.DeleteRole(authService))
+	v2.PUT("/roles/:role/permissions/:permission", rolesWrite, handlers.GrantRolePermission(authService))
+	v2.DELETE("/roles/:role/permissions/:permission", rolesWrite, handlers.RevokeRolePermission(authService))
+	v2.GET("/permissions", rolesRead, handlers.ListPermissions(authService))
+	v2.POST("/permissions", rolesWrite, handlers.CreatePermission(authService))
+	v2.DELETE("/permissions/:permission", rolesWrite, handlers.DeletePermission(authService))
+	v2.GET("/users/:user_id/roles", rolesRead, handlers.ListUserRoles(authService))
+	v2.PUT("/users/:user_id/roles/:role", rolesWrite, handlers.AssignRole(authService))
+	v2.DELETE("/users/:user_id/roles/:role", rolesWrite, handlers.UnassignRole(authService))
+
+	// Organizations, members are managed through a token switched to the organization
+	orgMembers := middleware.Authorize(nil, nil, middleware.OrgRolePolicy(model.OrgRoleOwner, model.OrgRoleAdmin, model.OrgRoleMember))
+	orgManagers := middleware.Authorize(nil, nil, middleware.OrgRolePolicy(model.OrgRoleOwner, model.OrgRoleAdmin))
+	v2.POST("/orgs", noAPIKeys, handlers.CreateOrganization(authService))
+	v2.GET("/orgs", noAPIKeys, handlers.ListOrganizations(authService))
+	v2.POST("/orgs/switch", noAPIKeys, handlers.SwitchOrganization(authService))
+	v2.POST("/orgs/invitations/accept", noAPIKeys, handlers.AcceptInvitation(authService))
+	v2.GET("/orgs/:org_id/members", orgMembers, handlers.ListMembers(authService))
+	v2.POST("/orgs/:org_id/invitations", orgManagers, handlers.InviteMember(authService))
+	v2.PUT("/orgs/:org_id/members/:user_id", orgManagers, handlers.UpdateMemberRole(authService))
+	v2.DELETE("/orgs/:org_id/members/:user_id", orgManagers, handlers.RemoveMember(authService))
+
+	// Admin user management, for the configured admin roles
+	adminUsers := v2.Group("/admin/users", middleware.Authorize(config.AdminRoles, nil, nil))
+	adminUsers.GET("", handlers.ListUsers(authService))
+	adminUsers.POST("", handlers.AdminCreateUser(authService))
+	adminUsers.GET("/:user_id", handlers.GetUser(authService))
+	adminUsers.PUT("/:user_id/role", handlers.UpdateUserRole(authService))
+	adminUsers.PUT("/:user_id/status", handlers.SetUserStatus(authService))
+	adminUsers.POST("/:user_id/unlock", handlers.UnlockUser(authService))
+	adminUsers.POST("/:user_id/verify", handlers.VerifyUser(authService))
+	adminUsers.POST("/:user_id/password-reset", handlers.SendUserPasswordReset(authService))
+	adminUsers.DELETE("/:user_id", handlers.DeleteUser(authService))
+	adminUsers.POST("/:user_id/restore", handlers.RestoreUser(authService))
+
+	// Tenants and their configuration overrides
+	tenantsRead := middleware.Authorize(nil, []string{model.PermissionTenantsRead}, nil)
+	tenantsWrite := middleware.Authorize(nil, []string{model.PermissionTenantsWrite}, nil)
+	v2.GET("/tenants", tenantsRead, handlers.ListTenants(authService))
+	v2.POST("/tenants", tenantsWrite, handlers.CreateTenant(authService))
+	v2.GET("/tenants/:tenant_id", tenantsRead, handlers.GetTenant(authService))
+	v2.PUT("/tenants/:tenant_id", tenantsWrite, handlers.UpdateTenant(authService))
+	v2.DELETE("/tenants/:tenant_id", tenantsWrite, handlers.DeleteTenant(authService))
+
+	// Attribute based policies
+	if policies != nil {
+		policiesRead := middleware.Authorize(nil, []string{model.PermissionPoliciesRead}, nil)
+		v2.GET("/policies", policiesRead, handlers.ListPolicies(policies))
+		v2.POST("/policies/explain", policiesRead, handlers.ExplainPolicy(policies))
+	}
+
+	// Public keys for resource servers verifying our tokens
+	route.GET("/.well-known/jwks.json", handlers.JWKS(authService))
+
+	// Signing key rotation
+	v2.GET("/keys", middleware.Authorize(nil, []string{model.PermissionSigningKeysRead}, nil), handlers.ListSigningKeys(authService))
+	v2.POST("/keys", middleware.Authorize(nil, []string{model.PermissionSigningKeysWrite}, nil), handlers.CreateSigningKey(authService))
+	v2.POST("/keys/promote", middleware.Authorize(nil, []string{model.PermissionSigningKeysWrite}, nil), handlers.PromoteSigningKey(authService))
+	v2.DELETE("/keys/:kid", middleware.Authorize(nil, []string{model.PermissionSigningKeysWrite}, nil), handlers.RetireSigningKey(authService))
+
+	// OAuth clients, machine clients use the token endpoint even without the OpenID Connect provider
+	route.POST("/oauth/token", limit("oauth/token"), handlers.OIDCToken(authService))
+	route.POST("/oauth/introspect", handlers.OAuthIntrospect(authService))
+	route.POST("/oauth/revoke", handlers.OAuthRevoke(authService))
+	v2.POST("/clients", middleware.Authorize(nil, []string{model.PermissionClientsWrite}, nil), handlers.CreateClient(authService))
+	v2.GET("/clients", middleware.Authorize(nil, []string{model.PermissionClientsRead}, nil), handlers.ListClients(authService))
+	v2.DELETE("/clients/:client_id", middleware.Authorize(nil, []string{model.PermissionClientsWrite}, nil), handlers.DeleteClient(authService))
+
+	// OpenID Connect provider, the protocol endpoints live at the issuer root
+	if config.OIDC.Enabled {
+		route.GET("/.well-known/openid-configuration", handlers.OpenIDConfiguration(authService))
+		route.GET("/oauth/authorize", handlers.OIDCAuthorize(authService, config.OIDC.Issuer, config.OIDC.LoginURL, config.OIDC.ConsentURL))
+		route.GET("/userinfo", handlers.OIDCUserInfo(authService))
+		route.POST("/userinfo", handlers.OIDCUserInfo(authService))
+
+		v2.POST("/oauth/consent", noAPIKeys, handlers.OIDCConsent(authService))
+		v2.GET("/consents", noAPIKeys, handlers.ListConsents(authService))
+		v2.DELETE("/consents/:client_id", noAPIKeys, handlers.RevokeConsent(authService))
+	}
 
 	v1.GET("/health", checkHealth)
 
@@ -15,3 +15,16 @@ func ReadKeys(private_key_path string, public_key_path string) ([]byte, []byte,
 
 	return privateKey, publicKey, nil
 }
+
+// ReadPublicKeys reads PEM encoded public keys, one per file
+func ReadPublicKeys(paths []string) ([][]byte, error) {
+	keys := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
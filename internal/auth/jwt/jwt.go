@@ -13,16 +13,29 @@ import (
 
 type JWTClaims struct {
 	jwt.RegisteredClaims
-	UserID    string      `json:"user_id"`
+	UserID    string      `json:"user_id,omitempty"`
 	Name      *string     `json:"name"`
 	Phone     *string     `json:"phone_number,omitempty"`
 	Email     *string     `json:"email"`
 	Metadata  interface{} `json:"metadata,omitempty"`
 	Role      string      `json:"role"`
 	TokenType string      `json:"token_type"`
+	SessionID string      `json:"sid,omitempty"`
+	OrgID     string      `json:"org_id,omitempty"`
+	ClientID  string      `json:"client_id,omitempty"`
+	Scope     string      `json:"scope,omitempty"`
 	config    *config.Config
 }
 
+// IDTokenClaims are the claims of an OpenID Connect ID token
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	model.UserClaims
+	AuthTime        int64  `json:"auth_time,omitempty"`
+	Nonce           string `json:"nonce,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+}
+
 func New(user *model.User, metadata interface{}, config *config.Config) *JWTClaims {
 	now := time.Now()
 	//  j, _:=time.ParseDuration(config.Config.JWT.Exp)
@@ -42,6 +55,10 @@ func New(user *model.User, metadata interface{}, config *config.Config) *JWTClai
 		metadata,
 		user.Role,
 		"access",
+		"",
+		"",
+		"",
+		"",
 		config,
 	}
 }
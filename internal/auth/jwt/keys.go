@@ -0,0 +1,161 @@
+package jwt_
+
+import (
+	"crypto"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	authcrypto "github.com/minilikmila/standard-auth-go/internal/auth/crypto"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+var (
+	ErrUnknownKey          = errors.New("token signed with an unknown key")
+	ErrUnexpectedMethod    = errors.New("unexpected signing method")
+	ErrUnsupportedSigning  = errors.New("unsupported signing method")
+	ErrKeyRotationDisabled = errors.New("key rotation requires jwt keys_dir")
+	ErrNoActiveKey         = errors.New("key set has no active key")
+	ErrNoNextKey           = errors.New("no next key to promote, generate one first")
+	ErrNextKeyExists       = errors.New("a next key is already waiting to be promoted")
+	ErrKeyNotFound         = errors.New("signing key not found")
+	ErrActiveKey           = errors.New("the active key can't be retired, promote another key first")
+)
+
+// verificationKey is a key tokens are accepted from, only with the algorithm it was published for and, for a
+// retiring key, only until its tokens have expired
+type verificationKey struct {
+	alg      string
+	key      interface{}
+	notAfter *time.Time
+}
+
+// keySet holds the parsed signing key and every key tokens are verified with, indexed by kid
+type keySet struct {
+	kid     string
+	alg     string
+	signKey interface{}
+	keys    map[string]verificationKey
+	jwks    model.JWKSet
+}
+
+func newKeySet() *keySet {
+	return &keySet{
+		keys: map[string]verificationKey{},
+		jwks: model.JWKSet{Keys: []model.JWK{}},
+	}
+}
+
+// loadKeySet parses the configured keys. HMAC secrets are never published, their kid is key_id or empty.
+func loadKeySet(cfg *config.JWTConfig) (*keySet, error) {
+	if cfg.KeysDir != "" {
+		return loadKeyDir(cfg)
+	}
+
+	set := newKeySet()
+	set.alg = cfg.Alg
+
+	if isHMAC(cfg.Alg) {
+		set.kid = cfg.KeyID
+		set.signKey = []byte(cfg.Secret)
+		set.keys[set.kid] = verificationKey{alg: cfg.Alg, key: set.signKey}
+		return set, nil
+	}
+
+	signer, err := parsePrivateKey(cfg.Alg, cfg.GetSignKey().([]byte))
+	if err != nil {
+		return nil, err
+	}
+	set.signKey = signer
+
+	publicKey, err := authcrypto.ParsePublicKeyFromPemString(cfg.GetDecodeKey().([]byte))
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := set.add(publicKey, cfg.KeyID, cfg.Alg, nil)
+	if err != nil {
+		return nil, err
+	}
+	set.kid = jwk.Kid
+
+	if err := set.addVerificationKeys(cfg); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// addVerificationKeys trusts the extra public keys from verification_key_paths
+func (k *keySet) addVerificationKeys(cfg *config.JWTConfig) error {
+	for _, pem := range cfg.GetVerificationKeys() {
+		publicKey, err := authcrypto.ParsePublicKeyFromPemString(pem)
+		if err != nil {
+			return err
+		}
+		if _, err := k.add(publicKey, "", cfg.Alg, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add trusts a public key and publishes it, a key already in the set is skipped
+func (k *keySet) add(publicKey interface{}, kid, alg string, notAfter *time.Time) (model.JWK, error) {
+	jwk, err := authcrypto.NewJWK(publicKey, kid, alg)
+	if err != nil {
+		return jwk, err
+	}
+	if _, ok := k.keys[jwk.Kid]; ok {
+		return jwk, nil
+	}
+	k.keys[jwk.Kid] = verificationKey{alg: alg, key: publicKey, notAfter: notAfter}
+	k.jwks.Keys = append(k.jwks.Keys, jwk)
+	return jwk, nil
+}
+
+// sign signs the claims with the signing key and stamps its kid
+func (k *keySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.alg), claims)
+	if k.kid != "" {
+		token.Header["kid"] = k.kid
+	}
+	return token.SignedString(k.signKey)
+}
+
+// keyFunc selects the verification key by the token's kid. Tokens issued before kids were stamped carry none
+// and are checked against the signing key.
+func (k *keySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = k.kid
+	}
+	key, ok := k.keys[kid]
+	if !ok || (key.notAfter != nil && time.Now().After(*key.notAfter)) {
+		return nil, ErrUnknownKey
+	}
+	if t.Method.Alg() != key.alg {
+		return nil, ErrUnexpectedMethod
+	}
+	return key.key, nil
+}
+
+func isHMAC(alg string) bool {
+	switch jwt.GetSigningMethod(alg) {
+	case jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512:
+		return true
+	}
+	return false
+}
+
+// parsePrivateKey parses the PEM private key for the algorithm's key type
+func parsePrivateKey(alg string, pem []byte) (crypto.Signer, error) {
+	switch jwt.GetSigningMethod(alg) {
+	case jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512:
+		return authcrypto.ParseRSAPrivateKeyFromPemString(pem)
+	case jwt.SigningMethodES256, jwt.SigningMethodES384, jwt.SigningMethodES512:
+		return authcrypto.ParseECDSAPrivateKeyFromPemString(pem)
+	default:
+		return nil, ErrUnsupportedSigning
+	}
+}
@@ -0,0 +1,144 @@
+package jwt_
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	config "github.com/minilikmila/standard-auth-go/configs"
+)
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signWith signs claims the way another key holder would, with an arbitrary kid header
+func signWith(t *testing.T, method jwt.SigningMethod, key interface{}, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestKeySetKeyFunc(t *testing.T) {
+	active, previous, retired, stranger := newECKey(t), newECKey(t), newECKey(t), newECKey(t)
+	expired := time.Now().Add(-time.Minute)
+
+	set := newKeySet()
+	set.alg = "ES256"
+	set.signKey = active
+	jwk, err := set.add(&active.PublicKey, "", "ES256", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.kid = jwk.Kid
+	if _, err := set.add(&previous.PublicKey, "previous", "ES256", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := set.add(&retired.PublicKey, "retired", "ES256", &expired); err != nil {
+		t.Fatal(err)
+	}
+
+	stamped, err := set.sign(jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"signed by the set", stamped, nil},
+		{"older token without kid", signWith(t, jwt.SigningMethodES256, active, ""), nil},
+		{"trusted verification key", signWith(t, jwt.SigningMethodES256, previous, "previous"), nil},
+		{"kid of another key", signWith(t, jwt.SigningMethodES256, stranger, "previous"), jwt.ErrECDSAVerification},
+		{"unknown kid", signWith(t, jwt.SigningMethodES256, stranger, "stranger"), ErrUnknownKey},
+		{"retired key past its date", signWith(t, jwt.SigningMethodES256, retired, "retired"), ErrUnknownKey},
+		{"algorithm switched", signWith(t, jwt.SigningMethodHS256, []byte("public key bytes"), jwk.Kid), ErrUnexpectedMethod},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, set.keyFunc)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("token refused: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("parse error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(stamped, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != jwk.Kid {
+		t.Errorf("signed token kid = %v, want %s", parsed.Header["kid"], jwk.Kid)
+	}
+}
+
+func TestKeySetAddPublishesOnce(t *testing.T) {
+	key := newECKey(t)
+	set := newKeySet()
+	first, err := set.add(&key.PublicKey, "", "ES256", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := set.add(&key.PublicKey, "", "ES256", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.jwks.Keys) != 1 || set.jwks.Keys[0].Kid != first.Kid {
+		t.Errorf("JWKS = %+v, want the key published once under its thumbprint", set.jwks.Keys)
+	}
+	if first.Use != "sig" || first.Alg != "ES256" || first.Kty != "EC" {
+		t.Errorf("JWK = %+v, want an EC signing key for ES256", first)
+	}
+
+	if _, err := set.add("not a key", "", "ES256", nil); err == nil {
+		t.Error("add accepted an unsupported key type")
+	}
+}
+
+func TestKeySetHMACNeverPublished(t *testing.T) {
+	set, err := loadKeySet(&config.JWTConfig{Alg: "HS256", Secret: "secret", KeyID: "hmac"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.jwks.Keys) != 0 {
+		t.Errorf("JWKS = %+v, an HMAC secret must never be published", set.jwks.Keys)
+	}
+	token, err := set.sign(jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(token, set.keyFunc); err != nil {
+		t.Errorf("HMAC token refused: %v", err)
+	}
+}
+
+func TestParsePrivateKeyUnsupported(t *testing.T) {
+	for _, alg := range []string{"HS256", "none", ""} {
+		if _, err := parsePrivateKey(alg, nil); err != ErrUnsupportedSigning {
+			t.Errorf("parsePrivateKey(%q) = %v, want %v", alg, err, ErrUnsupportedSigning)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package jwt_
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/sirupsen/logrus"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	authcrypto "github.com/minilikmila/standard-auth-go/internal/auth/crypto"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// keyManifest lists the keys of keys_dir and their states, each private key sits next to it as <kid>.pem
+const keyManifest = "keys.json"
+
+type manifest struct {
+	Keys []model.SigningKey `json:"keys"`
+}
+
+func readManifest(dir string) (*manifest, error) {
+	content, err := os.ReadFile(filepath.Join(dir, keyManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		return &manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// writeManifest replaces the manifest atomically so instances reloading concurrently never read half of it
+func writeManifest(dir string, m *manifest) error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, keyManifest+".tmp")
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, keyManifest))
+}
+
+func keyPath(dir, kid string) string {
+	return filepath.Join(dir, kid+".pem")
+}
+
+// loadKeyDir builds the key set from keys_dir, retiring keys past their date are left out
+func loadKeyDir(cfg *config.JWTConfig) (*keySet, error) {
+	m, err := readManifest(cfg.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+
+	set := newKeySet()
+	now := time.Now()
+	for _, key := range m.Keys {
+		if key.State == model.SigningKeyRetiring && key.RetireAt != nil && now.After(*key.RetireAt) {
+			continue
+		}
+		content, err := os.ReadFile(keyPath(cfg.KeysDir, key.Kid))
+		if err != nil {
+			return nil, err
+		}
+		signer, err := parsePrivateKey(key.Alg, content)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := set.add(signer.Public(), key.Kid, key.Alg, key.RetireAt); err != nil {
+			return nil, err
+		}
+		if key.State == model.SigningKeyActive {
+			set.kid = key.Kid
+			set.alg = key.Alg
+			set.signKey = signer
+		}
+	}
+	if set.signKey == nil {
+		return nil, ErrNoActiveKey
+	}
+
+	if err := set.addVerificationKeys(cfg); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// seedKeyDir makes an empty keys_dir usable: the configured key pair becomes the active key, so tokens issued
+// before rotation was turned on stay valid, or a fresh key is generated when there is none
+func seedKeyDir(cfg *config.JWTConfig) error {
+	if err := os.MkdirAll(cfg.KeysDir, 0700); err != nil {
+		return err
+	}
+	m, err := readManifest(cfg.KeysDir)
+	if err != nil || len(m.Keys) > 0 {
+		return err
+	}
+
+	var key *model.SigningKey
+	if content, ok := cfg.GetSignKey().([]byte); ok {
+		key, err = importKey(cfg.KeysDir, cfg.Alg, cfg.KeyID, content)
+	} else {
+		key, err = generateKey(cfg.KeysDir, cfg.Alg)
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key.State = model.SigningKeyActive
+	key.PromotedAt = &now
+	logrus.Infof("Seeded signing key %s in %s", key.Kid, cfg.KeysDir)
+	return writeManifest(cfg.KeysDir, &manifest{Keys: []model.SigningKey{*key}})
+}
+
+// importKey copies an existing PEM private key into the directory
+func importKey(dir, alg, kid string, content []byte) (*model.SigningKey, error) {
+	signer, err := parsePrivateKey(alg, content)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := authcrypto.NewJWK(signer.Public(), kid, alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyPath(dir, jwk.Kid), content, 0600); err != nil {
+		return nil, err
+	}
+	return &model.SigningKey{Kid: jwk.Kid, Alg: alg, CreatedAt: time.Now()}, nil
+}
+
+// generateKey creates a private key for the algorithm and stores it in the directory, named by its thumbprint
+func generateKey(dir, alg string) (*model.SigningKey, error) {
+	var signer crypto.Signer
+	var block *pem.Block
+
+	switch jwt.GetSigningMethod(alg) {
+	case jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		signer = key
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case jwt.SigningMethodES256, jwt.SigningMethodES384, jwt.SigningMethodES512:
+		curve := map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()}[alg]
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		signer = key
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		return nil, ErrUnsupportedSigning
+	}
+
+	jwk, err := authcrypto.NewJWK(signer.Public(), "", alg)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyPath(dir, jwk.Kid), pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, err
+	}
+	return &model.SigningKey{Kid: jwk.Kid, Alg: alg, CreatedAt: time.Now()}, nil
+}
+
+// ReloadKeys rereads the key set, a broken directory leaves the current keys in place
+func (s *JWTService) ReloadKeys() error {
+	keys, err := loadKeySet(&s.config.JWT)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// WatchKeys reloads keys_dir on an interval, so keys rotated by another instance or by hand are picked up
+// without a restart. It blocks, run it in its own goroutine.
+func (s *JWTService) WatchKeys(interval time.Duration) {
+	if s.config.JWT.KeysDir == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.ReloadKeys(); err != nil {
+			logrus.Errorf("Failed to reload signing keys: %v", err)
+		}
+	}
+}
+
+// SigningKeys lists the keys of the rotated key set
+func (s *JWTService) SigningKeys() ([]model.SigningKey, error) {
+	if s.config.JWT.KeysDir == "" {
+		return nil, ErrKeyRotationDisabled
+	}
+	m, err := readManifest(s.config.JWT.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+	return m.Keys, nil
+}
+
+// GenerateSigningKey adds a next key. It is published right away but only signs once promoted, which gives
+// verifiers caching the JWKS time to pick it up.
+func (s *JWTService) GenerateSigningKey() (*model.SigningKey, error) {
+	return s.updateKeys(func(m *manifest) (*model.SigningKey, error) {
+		for _, key := range m.Keys {
+			if key.State == model.SigningKeyNext {
+				return nil, ErrNextKeyExists
+			}
+		}
+		key, err := generateKey(s.config.JWT.KeysDir, s.config.JWT.Alg)
+		if err != nil {
+			return nil, err
+		}
+		key.State = model.SigningKeyNext
+		m.Keys = append(m.Keys, *key)
+		return key, nil
+	})
+}
+
+// PromoteSigningKey makes the next key active. The previous active key retires and keeps verifying until every
+// token it signed has expired, so nobody is logged out. Keys already past retirement are removed.
+func (s *JWTService) PromoteSigningKey() (*model.SigningKey, error) {
+	return s.updateKeys(func(m *manifest) (*model.SigningKey, error) {
+		next := -1
+		for i, key := range m.Keys {
+			if key.State == model.SigningKeyNext {
+				next = i
+			}
+		}
+		if next < 0 {
+			return nil, ErrNoNextKey
+		}
+
+		now := time.Now()
+		retireAt := now.Add(s.tokenLifetime())
+		keys := make([]model.SigningKey, 0, len(m.Keys))
+		var promoted model.SigningKey
+		for i, key := range m.Keys {
+			switch {
+			case i == next:
+				key.State = model.SigningKeyActive
+				key.PromotedAt = &now
+				promoted = key
+			case key.State == model.SigningKeyActive:
+				key.State = model.SigningKeyRetiring
+				key.RetireAt = &retireAt
+			case key.State == model.SigningKeyRetiring && key.RetireAt != nil && now.After(*key.RetireAt):
+				removeKeyFile(s.config.JWT.KeysDir, key.Kid)
+				continue
+			}
+			keys = append(keys, key)
+		}
+		m.Keys = keys
+		return &promoted, nil
+	})
+}
+
+// RetireSigningKey drops a next or retiring key immediately, e.g. a compromised one. Tokens it signed stop
+// verifying at once.
+func (s *JWTService) RetireSigningKey(kid string) error {
+	_, err := s.updateKeys(func(m *manifest) (*model.SigningKey, error) {
+		for i, key := range m.Keys {
+			if key.Kid != kid {
+				continue
+			}
+			if key.State == model.SigningKeyActive {
+				return nil, ErrActiveKey
+			}
+			m.Keys = append(m.Keys[:i], m.Keys[i+1:]...)
+			removeKeyFile(s.config.JWT.KeysDir, kid)
+			return &key, nil
+		}
+		return nil, ErrKeyNotFound
+	})
+	return err
+}
+
+// updateKeys applies a change to the manifest and reloads the key set. Changes from this process are serialized,
+// run rotations from one instance at a time.
+func (s *JWTService) updateKeys(change func(m *manifest) (*model.SigningKey, error)) (*model.SigningKey, error) {
+	if s.config.JWT.KeysDir == "" {
+		return nil, ErrKeyRotationDisabled
+	}
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	m, err := readManifest(s.config.JWT.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+	key, err := change(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeManifest(s.config.JWT.KeysDir, m); err != nil {
+		return nil, err
+	}
+	return key, s.ReloadKeys()
+}
+
+func removeKeyFile(dir, kid string) {
+	if err := os.Remove(keyPath(dir, kid)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logrus.Errorf("Failed to remove signing key %s: %v", kid, err)
+	}
+}
+
+// tokenLifetime is how long a retiring key keeps verifying, the longest any token we sign stays valid
+func (s *JWTService) tokenLifetime() time.Duration {
+	lifetime := 24 * time.Hour // email verification tokens
+	for _, expiry := range []time.Duration{
+		s.config.JWT.Exp * time.Minute,
+		s.config.JWT.RefreshExp * time.Minute,
+		s.config.OIDC.IDTokenExpiry * time.Minute,
+	} {
+		if expiry > lifetime {
+			lifetime = expiry
+		}
+	}
+	return lifetime
+}
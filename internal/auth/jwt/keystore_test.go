@@ -0,0 +1,241 @@
+package jwt_
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func newRotatingService(t *testing.T, dir string) *JWTService {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.JWT.Alg = "ES256"
+	cfg.JWT.KeysDir = dir
+	s, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+	return s
+}
+
+func issue(t *testing.T, s *JWTService) string {
+	t.Helper()
+	id := uuid.New()
+	token, err := s.GenerateToken(&model.User{ID: &id}, uuid.New(), nil, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func keysByState(t *testing.T, s *JWTService) map[model.SigningKeyState][]string {
+	t.Helper()
+	keys, err := s.SigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := map[model.SigningKeyState][]string{}
+	for _, key := range keys {
+		states[key.State] = append(states[key.State], key.Kid)
+	}
+	return states
+}
+
+func TestKeyRotation(t *testing.T) {
+	s := newRotatingService(t, t.TempDir())
+
+	seeded := keysByState(t, s)[model.SigningKeyActive]
+	if len(seeded) != 1 || s.keySet().kid != seeded[0] {
+		t.Fatalf("seeded keys_dir has active keys %v, signing with %s", seeded, s.keySet().kid)
+	}
+	before := issue(t, s)
+
+	next, err := s.GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GenerateSigningKey(); err != ErrNextKeyExists {
+		t.Errorf("second next key = %v, want %v", err, ErrNextKeyExists)
+	}
+	if len(s.JWKS().Keys) != 2 {
+		t.Errorf("JWKS has %d keys, the next key must be published before it signs", len(s.JWKS().Keys))
+	}
+	if s.keySet().kid != seeded[0] {
+		t.Error("next key signs before it is promoted")
+	}
+
+	promoted, err := s.PromoteSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if promoted.Kid != next.Kid || s.keySet().kid != next.Kid {
+		t.Errorf("promoted %s, signing with %s, want %s", promoted.Kid, s.keySet().kid, next.Kid)
+	}
+	states := keysByState(t, s)
+	if len(states[model.SigningKeyRetiring]) != 1 || states[model.SigningKeyRetiring][0] != seeded[0] {
+		t.Errorf("key states after promotion = %v, want the old active key retiring", states)
+	}
+	if _, err := s.ValidateToken(before); err != nil {
+		t.Errorf("token signed before the promotion refused: %v", err)
+	}
+	if _, err := s.ValidateToken(issue(t, s)); err != nil {
+		t.Errorf("token signed by the promoted key refused: %v", err)
+	}
+	if _, err := s.PromoteSigningKey(); err != ErrNoNextKey {
+		t.Errorf("promotion without a next key = %v, want %v", err, ErrNoNextKey)
+	}
+
+	if err := s.RetireSigningKey(next.Kid); err != ErrActiveKey {
+		t.Errorf("retiring the active key = %v, want %v", err, ErrActiveKey)
+	}
+	if err := s.RetireSigningKey("unknown"); err != ErrKeyNotFound {
+		t.Errorf("retiring an unknown key = %v, want %v", err, ErrKeyNotFound)
+	}
+	if err := s.RetireSigningKey(seeded[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ValidateToken(before); err == nil {
+		t.Error("token of a retired key still verifies")
+	}
+	if _, err := os.Stat(keyPath(s.config.JWT.KeysDir, seeded[0])); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("retired key file left behind: %v", err)
+	}
+}
+
+func TestReloadKeysFromAnotherInstance(t *testing.T) {
+	dir := t.TempDir()
+	first := newRotatingService(t, dir)
+	second := newRotatingService(t, dir)
+	if first.keySet().kid != second.keySet().kid {
+		t.Fatal("instances sharing keys_dir seeded different keys")
+	}
+
+	next, err := first.GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.PromoteSigningKey(); err != nil {
+		t.Fatal(err)
+	}
+	token := issue(t, first)
+	if _, err := second.ValidateToken(token); err == nil {
+		t.Fatal("second instance verified a key it hasn't loaded yet")
+	}
+	if err := second.ReloadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if second.keySet().kid != next.Kid {
+		t.Errorf("second instance signs with %s after reload, want %s", second.keySet().kid, next.Kid)
+	}
+	if _, err := second.ValidateToken(token); err != nil {
+		t.Errorf("token refused after reload: %v", err)
+	}
+}
+
+func TestLoadKeyDir(t *testing.T) {
+	dir := t.TempDir()
+	s := newRotatingService(t, dir)
+	if _, err := s.GenerateSigningKey(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PromoteSigningKey(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrite := func(t *testing.T, change func(key *model.SigningKey)) *config.JWTConfig {
+		t.Helper()
+		edited := &manifest{Keys: append([]model.SigningKey(nil), m.Keys...)}
+		for i := range edited.Keys {
+			change(&edited.Keys[i])
+		}
+		if err := writeManifest(dir, edited); err != nil {
+			t.Fatal(err)
+		}
+		return &config.JWTConfig{KeysDir: dir}
+	}
+
+	t.Run("retiring key kept until its date", func(t *testing.T) {
+		set, err := loadKeyDir(rewrite(t, func(*model.SigningKey) {}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(set.keys) != 2 {
+			t.Errorf("loaded %d keys, want the active and the retiring one", len(set.keys))
+		}
+	})
+	t.Run("retiring key past its date", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute)
+		set, err := loadKeyDir(rewrite(t, func(key *model.SigningKey) {
+			if key.State == model.SigningKeyRetiring {
+				key.RetireAt = &past
+			}
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(set.keys) != 1 || len(set.jwks.Keys) != 1 {
+			t.Errorf("loaded %d keys, want only the active one", len(set.keys))
+		}
+	})
+	t.Run("no active key", func(t *testing.T) {
+		_, err := loadKeyDir(rewrite(t, func(key *model.SigningKey) {
+			if key.State == model.SigningKeyActive {
+				key.State = model.SigningKeyNext
+			}
+		}))
+		if err != ErrNoActiveKey {
+			t.Errorf("loadKeyDir = %v, want %v", err, ErrNoActiveKey)
+		}
+	})
+	t.Run("missing key file", func(t *testing.T) {
+		_, err := loadKeyDir(rewrite(t, func(key *model.SigningKey) {
+			if key.State == model.SigningKeyRetiring {
+				key.Kid = "missing"
+			}
+		}))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("loadKeyDir = %v, want a missing file error", err)
+		}
+	})
+	t.Run("corrupt manifest", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, keyManifest), []byte("{"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadKeyDir(&config.JWTConfig{KeysDir: dir}); err == nil {
+			t.Error("loadKeyDir accepted a corrupt manifest")
+		}
+		if err := s.ReloadKeys(); err == nil || s.keySet() == nil {
+			t.Error("a broken keys_dir must fail the reload and keep the current keys")
+		}
+	})
+}
+
+func TestKeyRotationDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.JWT.Alg = "HS256"
+	cfg.JWT.Secret = "secret"
+	s, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SigningKeys(); err != ErrKeyRotationDisabled {
+		t.Errorf("SigningKeys = %v, want %v", err, ErrKeyRotationDisabled)
+	}
+	if _, err := s.GenerateSigningKey(); err != ErrKeyRotationDisabled {
+		t.Errorf("GenerateSigningKey = %v, want %v", err, ErrKeyRotationDisabled)
+	}
+	if err := s.RetireSigningKey("kid"); err != ErrKeyRotationDisabled {
+		t.Errorf("RetireSigningKey = %v, want %v", err, ErrKeyRotationDisabled)
+	}
+}
@@ -3,12 +3,12 @@ package jwt_
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	config "github.com/minilikmila/standard-auth-go/configs"
-	"github.com/minilikmila/standard-auth-go/internal/auth/crypto"
 	"github.com/minilikmila/standard-auth-go/internal/domain/model"
 	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
 	"github.com/sirupsen/logrus"
@@ -24,7 +24,10 @@ import (
 
 // JWTService implements the JWT service interface
 type JWTService struct {
-	config *config.Config
+	config   *config.Config
+	mu       sync.RWMutex
+	keys     *keySet
+	rotateMu sync.Mutex
 }
 
 // RedisClient interface for token blacklisting
@@ -34,15 +37,46 @@ type RedisClient interface {
 	Del(ctx context.Context, key string) error
 }
 
-// NewJWTService creates a new JWT service instance
-func NewJWTService(config *config.Config) *JWTService {
+// NewJWTService creates a new JWT service instance, the configured keys are parsed once here
+func NewJWTService(config *config.Config) (*JWTService, error) {
+	if config.JWT.KeysDir != "" {
+		if err := seedKeyDir(&config.JWT); err != nil {
+			return nil, err
+		}
+	}
+	keys, err := loadKeySet(&config.JWT)
+	if err != nil {
+		return nil, err
+	}
 	return &JWTService{
 		config: config,
+		keys:   keys,
+	}, nil
+}
+
+// keySet returns the current keys, they are swapped as a whole on reload
+func (s *JWTService) keySet() *keySet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys
+}
+
+// JWKS returns the public keys tokens are verified with
+func (s *JWTService) JWKS() model.JWKSet {
+	return s.keySet().jwks
+}
+
+// lifetime converts an expiry in minutes, zero falls back to the configured one
+func lifetime(expiry, fallback time.Duration) time.Duration {
+	if expiry <= 0 {
+		expiry = fallback
 	}
+	return expiry * time.Minute
 }
 
-// GenerateToken generates a new access token
-func (s *JWTService) GenerateToken(user *model.User, metadata interface{}) (string, error) {
+// GenerateToken generates a new access token bound to the given session, scoped to the organization when orgID
+// isn't nil. The expiry is in minutes, zero uses the configured one.
+func (s *JWTService) GenerateToken(user *model.User, sessionID uuid.UUID, orgID *uuid.UUID, expiry time.Duration, metadata interface{}) (string, error) {
 	logrus.Infoln("key : --- ", s.config.JWT.Alg)
 
 	claims := JWTClaims{
@@ -50,10 +84,12 @@ func (s *JWTService) GenerateToken(user *model.User, metadata interface{}) (stri
 		Email:     user.Email,
 		Role:      user.Role,
 		TokenType: enum.AccessToken,
+		SessionID: sessionIDClaim(sessionID),
+		OrgID:     orgIDClaim(orgID),
 		Name:      user.Name,
 		Metadata:  metadata,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.config.JWT.Exp) * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(lifetime(expiry, s.config.JWT.Exp))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    s.config.JWT.Iss,
@@ -62,35 +98,19 @@ func (s *JWTService) GenerateToken(user *model.User, metadata interface{}) (stri
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.config.JWT.Alg), claims)
-
-	switch jwt.GetSigningMethod(s.config.JWT.Alg) {
-	case jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512:
-		return token.SignedString([]byte(s.config.JWT.Secret))
-	case jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512:
-		privateKey, err := crypto.ParseRSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
-		if err != nil {
-			return "", err
-		}
-		return token.SignedString(privateKey)
-	case jwt.SigningMethodES256, jwt.SigningMethodES384, jwt.SigningMethodES512:
-		privateKey, err := crypto.ParseECDSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
-		if err != nil {
-			return "", err
-		}
-		return token.SignedString(privateKey)
-	default:
-		return "", errors.New("unsupported signing method")
-	}
+	return s.signClaims(claims)
 }
 
-// GenerateRefreshToken generates a new refresh token
-func (s *JWTService) GenerateRefreshToken(user *model.User) (string, error) {
+// GenerateRefreshToken generates a new refresh token bound to the given session, the expiry works like
+// GenerateToken's
+func (s *JWTService) GenerateRefreshToken(user *model.User, sessionID uuid.UUID, expiry time.Duration) (string, error) {
 	claims := JWTClaims{
 		UserID:    user.ID.String(),
 		TokenType: enum.RefreshToken,
+		SessionID: sessionIDClaim(sessionID),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour * 30)), // 30 days
+			ID:        uuid.NewString(), // unique per token, refresh tokens are persisted and rotated by hash
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(lifetime(expiry, s.config.JWT.RefreshExp))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    s.config.JWT.Iss,
@@ -99,26 +119,7 @@ func (s *JWTService) GenerateRefreshToken(user *model.User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.config.JWT.Alg), claims)
-
-	switch jwt.GetSigningMethod(s.config.JWT.Alg) {
-	case jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512:
-		return token.SignedString([]byte(s.config.JWT.Secret))
-	case jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512:
-		privateKey, err := crypto.ParseRSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
-		if err != nil {
-			return "", err
-		}
-		return token.SignedString(privateKey)
-	case jwt.SigningMethodES256, jwt.SigningMethodES384, jwt.SigningMethodES512:
-		privateKey, err := crypto.ParseECDSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
-		if err != nil {
-			return "", err
-		}
-		return token.SignedString(privateKey)
-	default:
-		return "", errors.New("unsupported signing method")
-	}
+	return s.signClaims(claims)
 }
 
 // GeneratePasswordResetToken generates a token for password reset
@@ -138,26 +139,7 @@ func (s *JWTService) GeneratePasswordResetToken(user *model.User) (string, error
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.config.JWT.Alg), claims)
-
-	switch jwt.GetSigningMethod(s.config.JWT.Alg) {
-	case jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512:
-		return token.SignedString([]byte(s.config.JWT.Secret))
-	case jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512:
-		privateKey, err := crypto.ParseRSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
-		if err != nil {
-			return "", err
-		}
-		return token.SignedString(privateKey)
-	case jwt.SigningMethodES256, jwt.SigningMethodES384, jwt.SigningMethodES512:
-		privateKey, err := crypto.ParseECDSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
-		if err != nil {
-			return "", err
-		}
-		return token.SignedString(privateKey)
-	default:
-		return "", errors.New("unsupported signing method")
-	}
+	return s.signClaims(claims)
 }
 
 // GenerateEmailVerificationToken generates a token for email verification
@@ -177,78 +159,200 @@ func (s *JWTService) GenerateEmailVerificationToken(user *model.User) (string, e
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.config.JWT.Alg), claims)
+	return s.signClaims(claims)
+}
 
-	switch jwt.GetSigningMethod(s.config.JWT.Alg) {
-	case jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512:
-		return token.SignedString([]byte(s.config.JWT.Secret))
-	case jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512:
-		privateKey, err := crypto.ParseRSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
+// GenerateMFAToken generates the short-lived token handed out between the password and the second factor
+func (s *JWTService) GenerateMFAToken(user *model.User) (string, error) {
+	claims := JWTClaims{
+		UserID:    user.ID.String(),
+		TokenType: enum.MFAPendingToken,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)), // 5 minutes
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    s.config.JWT.Iss,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{s.config.JWT.Aud},
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// GenerateClientAccessToken generates an access token issued to a client on behalf of the user. It carries the
+// client and the granted scope, and stays bound to the session the user authorized it from.
+func (s *JWTService) GenerateClientAccessToken(user *model.User, sessionID uuid.UUID, clientID, scope string) (string, error) {
+	claims := JWTClaims{
+		UserID:    user.ID.String(),
+		TokenType: enum.AccessToken,
+		SessionID: sessionIDClaim(sessionID),
+		ClientID:  clientID,
+		Scope:     scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.config.JWT.Exp) * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    s.config.JWT.Iss,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{clientID},
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// GenerateClientCredentialsToken generates an access token for a client acting on its own behalf, it carries
+// the client and its scope instead of a user
+func (s *JWTService) GenerateClientCredentialsToken(clientID, scope string) (string, error) {
+	claims := JWTClaims{
+		TokenType: enum.AccessToken,
+		ClientID:  clientID,
+		Scope:     scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.config.JWT.Exp) * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    s.config.JWT.Iss,
+			Subject:   clientID,
+			Audience:  jwt.ClaimStrings{s.config.JWT.Aud},
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// GenerateIDToken generates an OpenID Connect ID token for the client, releasing user claims for the granted scopes
+func (s *JWTService) GenerateIDToken(user *model.User, clientID, nonce string, authTime time.Time, scopes []string) (string, error) {
+	claims := IDTokenClaims{
+		UserClaims:      model.NewUserClaims(user, scopes),
+		AuthTime:        authTime.Unix(),
+		Nonce:           nonce,
+		AuthorizedParty: clientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.config.OIDC.IDTokenExpiry) * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    s.config.OIDC.Issuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{clientID},
+		},
+	}
+
+	return s.signClaims(claims)
+}
+
+// signClaims signs the claims with the current signing key, every token we issue goes through here
+func (s *JWTService) signClaims(claims jwt.Claims) (string, error) {
+	return s.keySet().sign(claims)
+}
+
+// ValidateToken validates a JWT token and returns the user ID
+func (s *JWTService) ValidateToken(token string) (uuid.UUID, error) {
+	claims, err := s.parseClaims(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(claims.UserID)
+}
+
+// InspectToken validates a token and returns what it was issued for
+func (s *JWTService) InspectToken(token string) (*model.TokenInfo, error) {
+	claims, err := s.parseClaims(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Client credentials tokens have no user
+	userID := uuid.Nil
+	if claims.UserID != "" || claims.ClientID == "" {
+		userID, err = uuid.Parse(claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	info := &model.TokenInfo{
+		UserID:    userID,
+		TokenType: claims.TokenType,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+	}
+	if claims.SessionID != "" {
+		sessionID, err := uuid.Parse(claims.SessionID)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		return token.SignedString(privateKey)
-	case jwt.SigningMethodES256, jwt.SigningMethodES384, jwt.SigningMethodES512:
-		privateKey, err := crypto.ParseECDSAPrivateKeyFromPemString(s.config.JWT.GetSignKey().([]byte))
+		info.SessionID = &sessionID
+	}
+	if claims.OrgID != "" {
+		orgID, err := uuid.Parse(claims.OrgID)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		return token.SignedString(privateKey)
-	default:
-		return "", errors.New("unsupported signing method")
+		info.OrgID = &orgID
+	}
+	if claims.IssuedAt != nil {
+		info.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		info.ExpiresAt = claims.ExpiresAt.Time
 	}
+	return info, nil
 }
 
-// ValidateToken validates a JWT token and returns the user ID
-func (s *JWTService) ValidateToken(token string) (uuid.UUID, error) {
-	var err error
-	var jwtToken *jwt.Token
+// sessionIDClaim leaves the sid claim out for tokens that aren't tied to a session
+func sessionIDClaim(sessionID uuid.UUID) string {
+	if sessionID == uuid.Nil {
+		return ""
+	}
+	return sessionID.String()
+}
 
-	signingMethod := jwt.GetSigningMethod(s.config.JWT.Alg)
-	if signingMethod == nil {
-		return uuid.Nil, errors.New("unsupported signing method")
+// orgIDClaim leaves the org_id claim out for tokens outside an organization
+func orgIDClaim(orgID *uuid.UUID) string {
+	if orgID == nil {
+		return ""
 	}
+	return orgID.String()
+}
 
+// parseClaims verifies the token signature and standard claims and returns the decoded claims
+func (s *JWTService) parseClaims(token string) (*JWTClaims, error) {
 	claims := &JWTClaims{}
-
-	switch signingMethod {
-	case jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512:
-		jwtToken, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
-			return []byte(s.config.JWT.Secret), nil
-		})
-	case jwt.SigningMethodRS256, jwt.SigningMethodRS384, jwt.SigningMethodRS512:
-		jwtToken, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
-			pubKey, err := crypto.ParseRSAPublicKeyFromPemString(s.config.JWT.GetDecodeKey().([]byte))
-			if err != nil {
-				return uuid.Nil, err
-			}
-			return pubKey, nil
-		})
-	case jwt.SigningMethodES256, jwt.SigningMethodES384, jwt.SigningMethodES512:
-		jwtToken, err = jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
-			pubKey, err := crypto.ParseECDSAPublicKeyFromPemString(s.config.JWT.GetDecodeKey().([]byte))
-			if err != nil {
-				return nil, err
-			}
-			return pubKey, nil
-		})
-	default:
-		logrus.Infoln("Signin Method unsupported : --- ", signingMethod)
-		return uuid.Nil, errors.New("unsupported signing method")
-	}
+	jwtToken, err := jwt.ParseWithClaims(token, claims, s.keySet().keyFunc)
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
 	parsedClaims, ok := jwtToken.Claims.(*JWTClaims)
 	if !ok || !jwtToken.Valid {
-		return uuid.Nil, errors.New("invalid token")
+		return nil, errors.New("invalid token")
+	}
+	return parsedClaims, nil
+}
+
+// validateTokenOfType validates a token and rejects any other token type
+func (s *JWTService) validateTokenOfType(tokenString, tokenType string) (uuid.UUID, error) {
+	claims, err := s.parseClaims(tokenString)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if claims.TokenType != tokenType {
+		return uuid.Nil, errors.New("invalid token type")
 	}
-	return uuid.Parse(parsedClaims.UserID)
+	return uuid.Parse(claims.UserID)
 }
 
 // ValidateRefreshToken validates a refresh token
 func (s *JWTService) ValidateRefreshToken(tokenString string) (uuid.UUID, error) {
-	return s.ValidateToken(tokenString)
+	return s.validateTokenOfType(tokenString, enum.RefreshToken)
+}
+
+// ValidateMFAToken validates an MFA pending token
+func (s *JWTService) ValidateMFAToken(tokenString string) (uuid.UUID, error) {
+	return s.validateTokenOfType(tokenString, enum.MFAPendingToken)
 }
 
 // ValidatePasswordResetToken validates a password reset token
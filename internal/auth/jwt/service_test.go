@@ -0,0 +1,109 @@
+package jwt_
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+)
+
+// newHMACService signs with secret, so tests need no key files
+func newHMACService(t *testing.T, secret string) *JWTService {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.JWT.Alg = "HS256"
+	cfg.JWT.Secret = secret
+	s, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+	return s
+}
+
+func TestInspectToken(t *testing.T) {
+	s := newHMACService(t, "secret")
+	id := uuid.New()
+	user := &model.User{ID: &id}
+	sessionID := uuid.New()
+
+	sign := func(token string, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		userID    uuid.UUID
+		tokenType string
+		clientID  string
+		scope     string
+		session   bool
+	}{
+		{"access token", sign(s.GenerateToken(user, sessionID, nil, 0, nil)), id, enum.AccessToken, "", "", true},
+		{"refresh token", sign(s.GenerateRefreshToken(user, sessionID, 0)), id, enum.RefreshToken, "", "", true},
+		{"client access token", sign(s.GenerateClientAccessToken(user, sessionID, "app", "openid email")), id, enum.AccessToken, "app", "openid email", true},
+		{"client credentials token", sign(s.GenerateClientCredentialsToken("gateway", "users:read")), uuid.Nil, enum.AccessToken, "gateway", "users:read", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := s.InspectToken(tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.UserID != tt.userID || info.TokenType != tt.tokenType || info.ClientID != tt.clientID || info.Scope != tt.scope {
+				t.Errorf("info = %+v", info)
+			}
+			if (info.SessionID != nil && *info.SessionID == sessionID) != tt.session {
+				t.Errorf("session = %v, want it only on tokens of a session", info.SessionID)
+			}
+			if info.IssuedAt.IsZero() || !info.ExpiresAt.After(time.Now()) {
+				t.Errorf("issued at %v, expires at %v", info.IssuedAt, info.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestInspectTokenRejects(t *testing.T) {
+	s := newHMACService(t, "secret")
+	other := newHMACService(t, "another-secret")
+	id := uuid.New()
+	registered := func(expiresAt time.Time) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expiresAt), IssuedAt: jwt.NewNumericDate(time.Now())}
+	}
+
+	tests := []struct {
+		name   string
+		claims JWTClaims
+		signer *JWTService
+	}{
+		{"expired", JWTClaims{UserID: id.String(), TokenType: enum.AccessToken, RegisteredClaims: registered(time.Now().Add(-time.Minute))}, s},
+		{"signed by another key", JWTClaims{UserID: id.String(), TokenType: enum.AccessToken, RegisteredClaims: registered(time.Now().Add(time.Hour))}, other},
+		{"neither user nor client", JWTClaims{TokenType: enum.AccessToken, RegisteredClaims: registered(time.Now().Add(time.Hour))}, s},
+		{"malformed user", JWTClaims{UserID: "jane", TokenType: enum.AccessToken, RegisteredClaims: registered(time.Now().Add(time.Hour))}, s},
+		{"malformed session", JWTClaims{UserID: id.String(), SessionID: "current", TokenType: enum.AccessToken, RegisteredClaims: registered(time.Now().Add(time.Hour))}, s},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.signer.signClaims(tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info, err := s.InspectToken(token); err == nil {
+				t.Errorf("InspectToken accepted the token: %+v", info)
+			}
+		})
+	}
+
+	if _, err := s.InspectToken("not-a-token"); err == nil {
+		t.Error("InspectToken accepted garbage")
+	}
+}
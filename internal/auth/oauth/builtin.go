@@ -0,0 +1,211 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+)
+
+// builtin is the definition of a well known provider, plain OAuth2 providers carry a profile reader
+type builtin struct {
+	endpoints Endpoints
+	profile   profileFunc
+}
+
+// Apple is not built in: it needs a client secret JWT signed per request and posts the callback as a form.
+var builtins = map[string]builtin{
+	enum.GoogleLogin: {
+		endpoints: Endpoints{
+			Issuer:   "https://accounts.google.com",
+			AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL: "https://oauth2.googleapis.com/token",
+			JWKSURL:  "https://www.googleapis.com/oauth2/v3/certs",
+		},
+	},
+	enum.LinkedinLogin: {
+		endpoints: Endpoints{
+			Issuer:   "https://www.linkedin.com/oauth",
+			AuthURL:  "https://www.linkedin.com/oauth/v2/authorization",
+			TokenURL: "https://www.linkedin.com/oauth/v2/accessToken",
+			JWKSURL:  "https://www.linkedin.com/oauth/openid/jwks",
+		},
+	},
+	enum.SlackLogin: {
+		endpoints: Endpoints{
+			Issuer:   "https://slack.com",
+			AuthURL:  "https://slack.com/openid/connect/authorize",
+			TokenURL: "https://slack.com/api/openid.connect.token",
+			JWKSURL:  "https://slack.com/openid/connect/keys",
+		},
+	},
+	enum.GithubLogin: {
+		endpoints: Endpoints{
+			AuthURL:     "https://github.com/login/oauth/authorize",
+			TokenURL:    "https://github.com/login/oauth/access_token",
+			UserInfoURL: "https://api.github.com/user",
+			Scopes:      []string{"read:user", "user:email"},
+		},
+		profile: githubProfile,
+	},
+	enum.DiscordLogin: {
+		endpoints: Endpoints{
+			AuthURL:     "https://discord.com/oauth2/authorize",
+			TokenURL:    "https://discord.com/api/oauth2/token",
+			UserInfoURL: "https://discord.com/api/users/@me",
+			Scopes:      []string{"identify", "email"},
+		},
+		profile: discordProfile,
+	},
+	enum.FacebookLogin: {
+		endpoints: Endpoints{
+			AuthURL:     "https://www.facebook.com/v19.0/dialog/oauth",
+			TokenURL:    "https://graph.facebook.com/v19.0/oauth/access_token",
+			UserInfoURL: "https://graph.facebook.com/v19.0/me?fields=id,name,email,picture",
+			Scopes:      []string{"public_profile", "email"},
+		},
+		profile: facebookProfile,
+	},
+	enum.TwitterLogin: {
+		endpoints: Endpoints{
+			AuthURL:     "https://twitter.com/i/oauth2/authorize",
+			TokenURL:    "https://api.twitter.com/2/oauth2/token",
+			UserInfoURL: "https://api.twitter.com/2/users/me?user.fields=profile_image_url,confirmed_email",
+			Scopes:      []string{"users.read", "tweet.read", "users.email"},
+		},
+		profile: twitterProfile,
+	},
+}
+
+// NewBuiltin builds the well known provider called name, config overrides win over its default endpoints
+func NewBuiltin(name string, cfg config.SocialAuthConfig, redirectURL string) (Provider, error) {
+	definition, ok := builtins[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	endpoints := definition.endpoints.override(cfg)
+	if definition.profile == nil {
+		return NewOIDCProvider(name, endpoints, cfg, redirectURL), nil
+	}
+	return newOAuth2Provider(name, endpoints, cfg, redirectURL, definition.profile), nil
+}
+
+// IsBuiltin reports whether name is reserved by a built-in provider
+func IsBuiltin(name string) bool {
+	_, ok := builtins[name]
+	return ok
+}
+
+// githubProfile reads the user and, since the public email may be hidden, the primary verified address
+func githubProfile(ctx context.Context, client *http.Client, endpoints Endpoints) (*Profile, error) {
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, client, endpoints.UserInfoURL, &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, endpoints.UserInfoURL+"/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Name:    valueOr(user.Name, user.Login),
+		Picture: user.AvatarURL,
+	}
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+		}
+	}
+	return profile, nil
+}
+
+func discordProfile(ctx context.Context, client *http.Client, endpoints Endpoints) (*Profile, error) {
+	var user struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+		Avatar     string `json:"avatar"`
+		Email      string `json:"email"`
+		Verified   bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, endpoints.UserInfoURL, &user); err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: user.Verified,
+		Name:          valueOr(user.GlobalName, user.Username),
+	}
+	if user.Avatar != "" {
+		profile.Picture = fmt.Sprintf("https://cdn.discordapp.com/avatars/%s/%s.png", user.ID, user.Avatar)
+	}
+	return profile, nil
+}
+
+// facebookProfile never reports the email as verified, the Graph API doesn't say whether the address was confirmed.
+// Facebook accounts are only used through an identity the user linked while signed in.
+func facebookProfile(ctx context.Context, client *http.Client, endpoints Endpoints) (*Profile, error) {
+	var user struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Email   string `json:"email"`
+		Picture struct {
+			Data struct {
+				URL string `json:"url"`
+			} `json:"data"`
+		} `json:"picture"`
+	}
+	if err := getJSON(ctx, client, endpoints.UserInfoURL, &user); err != nil {
+		return nil, err
+	}
+
+	return &Profile{
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: false,
+		Name:          user.Name,
+		Picture:       user.Picture.Data.URL,
+	}, nil
+}
+
+// twitterProfile never reports the email as verified, confirmed_email isn't an assertion the address belongs to the
+// account holder we can link on
+func twitterProfile(ctx context.Context, client *http.Client, endpoints Endpoints) (*Profile, error) {
+	var response struct {
+		Data struct {
+			ID              string `json:"id"`
+			Name            string `json:"name"`
+			Username        string `json:"username"`
+			ProfileImageURL string `json:"profile_image_url"`
+			ConfirmedEmail  string `json:"confirmed_email"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, client, endpoints.UserInfoURL, &response); err != nil {
+		return nil, err
+	}
+
+	return &Profile{
+		Subject:       response.Data.ID,
+		Email:         response.Data.ConfirmedEmail,
+		EmailVerified: false,
+		Name:          valueOr(response.Data.Name, response.Data.Username),
+		Picture:       response.Data.ProfileImageURL,
+	}, nil
+}
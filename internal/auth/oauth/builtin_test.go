@@ -0,0 +1,204 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+)
+
+func TestBuiltinProfiles(t *testing.T) {
+	tests := []struct {
+		name string
+		apis map[string]interface{}
+		want Profile
+	}{
+		{
+			name: enum.GithubLogin,
+			apis: map[string]interface{}{
+				"/api/user": map[string]interface{}{"id": 42, "login": "jane", "avatar_url": "https://avatars.example.com/42"},
+				"/api/user/emails": []map[string]interface{}{
+					{"email": "old@example.com", "primary": false, "verified": true},
+					{"email": "jane@example.com", "primary": true, "verified": true},
+				},
+			},
+			want: Profile{Subject: "42", Email: "jane@example.com", EmailVerified: true, Name: "jane", Picture: "https://avatars.example.com/42"},
+		},
+		{
+			name: enum.DiscordLogin,
+			apis: map[string]interface{}{
+				"/api/user": map[string]interface{}{"id": "7", "username": "jane", "global_name": "Jane", "avatar": "abc", "email": "jane@example.com", "verified": true},
+			},
+			want: Profile{Subject: "7", Email: "jane@example.com", EmailVerified: true, Name: "Jane", Picture: "https://cdn.discordapp.com/avatars/7/abc.png"},
+		},
+		{
+			name: enum.FacebookLogin,
+			apis: map[string]interface{}{
+				"/api/user": map[string]interface{}{"id": "9", "name": "Jane", "email": "jane@example.com", "picture": map[string]interface{}{"data": map[string]string{"url": "https://pictures.example.com/9"}}},
+			},
+			want: Profile{Subject: "9", Email: "jane@example.com", Name: "Jane", Picture: "https://pictures.example.com/9"},
+		},
+		{
+			name: enum.TwitterLogin,
+			apis: map[string]interface{}{
+				"/api/user": map[string]interface{}{"data": map[string]string{"id": "3", "username": "jane", "confirmed_email": "jane@example.com"}},
+			},
+			want: Profile{Subject: "3", Email: "jane@example.com", Name: "jane"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			idp.apis = tt.apis
+			cfg := idp.config()
+			cfg.UserInfoURL = idp.URL + "/api/user"
+			provider, err := NewBuiltin(tt.name, cfg, "https://auth.example.com/callback")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			profile, err := provider.Exchange(context.Background(), "good-code", "verifier", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.want.Provider = tt.name
+			if *profile != tt.want {
+				t.Errorf("profile = %+v, want %+v", *profile, tt.want)
+			}
+			if idp.gotVerifier != "verifier" {
+				t.Errorf("token endpoint got code_verifier %q, want the PKCE verifier", idp.gotVerifier)
+			}
+		})
+	}
+}
+
+func TestBuiltinProfileErrors(t *testing.T) {
+	user := map[string]interface{}{"id": 42, "login": "jane"}
+
+	tests := []struct {
+		name string
+		code string
+		apis map[string]interface{}
+		want error // nil when any error will do
+	}{
+		{"bad code", "bad-code", map[string]interface{}{"/api/user": user, "/api/user/emails": []interface{}{}}, nil},
+		{"no user", "good-code", map[string]interface{}{}, ErrUserInfoResponse},
+		{"no emails", "good-code", map[string]interface{}{"/api/user": user}, ErrUserInfoResponse},
+		{"malformed user", "good-code", map[string]interface{}{"/api/user": "jane", "/api/user/emails": []interface{}{}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			idp.apis = tt.apis
+			cfg := idp.config()
+			cfg.UserInfoURL = idp.URL + "/api/user"
+			provider, err := NewBuiltin(enum.GithubLogin, cfg, "https://auth.example.com/callback")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			profile, err := provider.Exchange(context.Background(), tt.code, "verifier", "")
+			if err == nil {
+				t.Fatalf("Exchange accepted the response, profile %+v", profile)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Exchange = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOAuth2AuthCodeURL(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider, err := NewBuiltin(enum.GithubLogin, idp.config(), "https://auth.example.com/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consent, err := url.Parse(provider.AuthCodeURL("state", GenerateVerifier(), "nonce"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := consent.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("state") != "state" {
+		t.Errorf("consent URL %s misses the state or PKCE challenge", consent)
+	}
+	if query.Has("nonce") {
+		t.Errorf("consent URL %s carries a nonce, a plain OAuth2 provider has no ID token for it", consent)
+	}
+	if query.Get("scope") != "read:user user:email" {
+		t.Errorf("scope = %q, want the github defaults", query.Get("scope"))
+	}
+}
+
+func TestNewBuiltin(t *testing.T) {
+	if _, err := NewBuiltin("corp", config.SocialAuthConfig{}, ""); err != ErrUnknownProvider {
+		t.Errorf("NewBuiltin(corp) = %v, want %v", err, ErrUnknownProvider)
+	}
+	if IsBuiltin("corp") {
+		t.Error("IsBuiltin(corp) = true")
+	}
+	for name := range builtins {
+		if !IsBuiltin(name) {
+			t.Errorf("IsBuiltin(%s) = false", name)
+		}
+		provider, err := NewBuiltin(name, config.SocialAuthConfig{ClientID: "client-id"}, "")
+		if err != nil || provider.Name() != name {
+			t.Errorf("NewBuiltin(%s) = %v, %v", name, provider, err)
+		}
+	}
+	if IsBuiltin("apple") {
+		t.Error("apple is built in although it can't run the plain code flow")
+	}
+}
+
+func TestEndpointsOverride(t *testing.T) {
+	defaults := builtins[enum.GithubLogin].endpoints
+
+	if got := defaults.override(config.SocialAuthConfig{}); !reflect.DeepEqual(got, defaults) {
+		t.Errorf("override without overrides = %+v, want the defaults", got)
+	}
+
+	got := defaults.override(config.SocialAuthConfig{TokenURL: "https://idp.example.com/token", Scopes: []string{"read:user"}})
+	want := defaults
+	want.TokenURL = "https://idp.example.com/token"
+	want.Scopes = []string{"read:user"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("override = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiscoverOIDCProvider(t *testing.T) {
+	idp := newFakeIdP(t)
+
+	// Only the issuer is configured, everything else comes from the discovery document
+	cfg := config.SocialAuthConfig{ClientID: idp.clientID, SecretID: "client-secret", Enabled: true, Issuer: idp.URL}
+	provider, err := DiscoverOIDCProvider(context.Background(), "corp", cfg, "https://auth.example.com/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.idToken = map[string]interface{}{"nonce": "nonce", "email": "jane@corp.example.com", "email_verified": true}
+	profile, err := provider.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Provider != "corp" || profile.Email != "jane@corp.example.com" || !profile.EmailVerified {
+		t.Errorf("profile = %+v", *profile)
+	}
+
+	// An issuer without a discovery document is refused
+	cfg.Issuer = idp.URL + "/tenant"
+	if _, err := DiscoverOIDCProvider(context.Background(), "corp", cfg, ""); err == nil {
+		t.Error("DiscoverOIDCProvider accepted an issuer without a discovery document")
+	}
+
+	// Nothing is fetched when every endpoint is configured
+	idp.Close()
+	if _, err := DiscoverOIDCProvider(context.Background(), "corp", idp.config(), ""); err != nil {
+		t.Errorf("DiscoverOIDCProvider with every endpoint configured = %v", err)
+	}
+}
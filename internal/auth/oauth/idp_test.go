@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	authcrypto "github.com/minilikmila/standard-auth-go/internal/auth/crypto"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// testKey is shared by every fake IdP, RSA keys are slow to generate
+var testKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+// fakeIdP is a local identity provider: it redeems the code "good-code", signs ID tokens and serves JSON APIs
+type fakeIdP struct {
+	*httptest.Server
+	t *testing.T
+
+	clientID string
+	key      *rsa.PrivateKey // signs the ID tokens, the JWKS always publishes testKey
+	idToken  jwt.MapClaims   // claims of the next ID token over the defaults, nil sends none
+	apis     map[string]interface{}
+
+	gotVerifier string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	idp := &fakeIdP{t: t, clientID: "client-id", key: testKey(), apis: map[string]interface{}{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwk, err := authcrypto.NewJWK(&testKey().PublicKey, "test", "RS256")
+		if err != nil {
+			t.Error(err)
+		}
+		idp.json(w, model.JWKSet{Keys: []model.JWK{jwk}})
+	})
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		idp.json(w, map[string]interface{}{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, ok := idp.apis[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		idp.json(w, body)
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// config points a provider at the fake IdP
+func (idp *fakeIdP) config() config.SocialAuthConfig {
+	return config.SocialAuthConfig{
+		ClientID: idp.clientID,
+		SecretID: "client-secret",
+		Enabled:  true,
+		Issuer:   idp.URL,
+		AuthURL:  idp.URL + "/authorize",
+		TokenURL: idp.URL + "/token",
+		JWKSURL:  idp.URL + "/jwks",
+	}
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "good-code" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+	idp.gotVerifier = r.PostForm.Get("code_verifier")
+
+	response := map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	}
+	if idp.idToken != nil {
+		claims := jwt.MapClaims{
+			"iss": idp.URL,
+			"aud": idp.clientID,
+			"sub": "subject",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range idp.idToken {
+			claims[name] = value
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(idp.key)
+		if err != nil {
+			idp.t.Error(err)
+		}
+		response["id_token"] = signed
+	}
+	idp.json(w, response)
+}
+
+func (idp *fakeIdP) json(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		idp.t.Error(err)
+	}
+}
@@ -0,0 +1,53 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/oauth2"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+)
+
+// profileFunc reads the signed in user from a plain OAuth2 provider's API with the token carrying client
+type profileFunc func(ctx context.Context, client *http.Client, endpoints Endpoints) (*Profile, error)
+
+// oauth2Provider is a provider without ID tokens, the profile comes from its userinfo API
+type oauth2Provider struct {
+	name      string
+	endpoints Endpoints
+	oauth2    *oauth2.Config
+	profile   profileFunc
+}
+
+func newOAuth2Provider(name string, endpoints Endpoints, cfg config.SocialAuthConfig, redirectURL string, profile profileFunc) Provider {
+	return &oauth2Provider{
+		name:      name,
+		endpoints: endpoints,
+		oauth2:    endpoints.oauth2Config(cfg, redirectURL),
+		profile:   profile,
+	}
+}
+
+func (p *oauth2Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL ignores the nonce, there is no ID token to carry it
+func (p *oauth2Provider) AuthCodeURL(state, codeVerifier, nonce string) string {
+	return p.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier))
+}
+
+func (p *oauth2Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Profile, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := p.profile(ctx, p.oauth2.Client(ctx, token), p.endpoints)
+	if err != nil {
+		return nil, err
+	}
+	profile.Provider = p.name
+	return profile, nil
+}
@@ -0,0 +1,110 @@
+package oauth
+
+import (
+	"context"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+)
+
+// oidcProvider reads the profile from the verified ID token
+type oidcProvider struct {
+	name     string
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider builds an OpenID Connect provider from fully known endpoints
+func NewOIDCProvider(name string, endpoints Endpoints, cfg config.SocialAuthConfig, redirectURL string) Provider {
+	if len(endpoints.Scopes) == 0 {
+		endpoints.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	// The key set refreshes itself in the background for the lifetime of the process
+	keySet := oidc.NewRemoteKeySet(context.Background(), endpoints.JWKSURL)
+
+	return &oidcProvider{
+		name:     name,
+		oauth2:   endpoints.oauth2Config(cfg, redirectURL),
+		verifier: oidc.NewVerifier(endpoints.Issuer, keySet, &oidc.Config{ClientID: cfg.ClientID}),
+	}
+}
+
+// DiscoverOIDCProvider builds an OpenID Connect provider, filling the endpoints missing from config
+// through the issuer's discovery document
+func DiscoverOIDCProvider(ctx context.Context, name string, cfg config.SocialAuthConfig, redirectURL string) (Provider, error) {
+	endpoints := Endpoints{}.override(cfg)
+	if endpoints.AuthURL == "" || endpoints.TokenURL == "" || endpoints.JWKSURL == "" {
+		discovered, err := oidc.NewProvider(ctx, endpoints.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		var document struct {
+			JWKSURL string `json:"jwks_uri"`
+		}
+		if err := discovered.Claims(&document); err != nil {
+			return nil, err
+		}
+		endpoints.AuthURL = valueOr(endpoints.AuthURL, discovered.Endpoint().AuthURL)
+		endpoints.TokenURL = valueOr(endpoints.TokenURL, discovered.Endpoint().TokenURL)
+		endpoints.JWKSURL = valueOr(endpoints.JWKSURL, document.JWKSURL)
+	}
+	return NewOIDCProvider(name, endpoints, cfg, redirectURL), nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state, codeVerifier, nonce string) string {
+	return p.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier), oidc.Nonce(nonce))
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Profile, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	var claims struct {
+		Email         string       `json:"email"`
+		EmailVerified flexibleBool `json:"email_verified"`
+		Name          string       `json:"name"`
+		Picture       string       `json:"picture"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &Profile{
+		Provider:      p.name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+	}, nil
+}
+
+// flexibleBool accepts both true and "true", some providers send email_verified as a string
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	*b = string(data) == `true` || string(data) == `"true"`
+	return nil
+}
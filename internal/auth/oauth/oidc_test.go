@@ -0,0 +1,130 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+)
+
+func TestGoogleAuthCodeURL(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider, err := NewBuiltin(enum.GoogleLogin, idp.config(), "https://auth.example.com/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := GenerateVerifier()
+	consent, err := url.Parse(provider.AuthCodeURL("state", verifier, "nonce"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(consent.String(), idp.URL+"/authorize?") {
+		t.Errorf("consent URL %s doesn't use the configured endpoint", consent)
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	query := consent.Query()
+	for param, want := range map[string]string{
+		"client_id":             idp.clientID,
+		"redirect_uri":          "https://auth.example.com/callback",
+		"response_type":         "code",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(sum[:]),
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+	} {
+		if got := query.Get(param); got != want {
+			t.Errorf("%s = %q, want %q", param, got, want)
+		}
+	}
+}
+
+func TestGoogleExchange(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider, err := NewBuiltin(enum.GoogleLogin, idp.config(), "https://auth.example.com/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp.idToken = jwt.MapClaims{"nonce": "nonce", "email": "jane@example.com", "email_verified": "true", "name": "Jane"}
+	profile, err := provider.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idp.gotVerifier != "verifier" {
+		t.Errorf("token endpoint got code_verifier %q, want the PKCE verifier", idp.gotVerifier)
+	}
+	want := Profile{Provider: enum.GoogleLogin, Subject: "subject", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}
+	if *profile != want {
+		t.Errorf("profile = %+v, want %+v", *profile, want)
+	}
+
+	// Only a literal true counts
+	for _, verified := range []interface{}{false, "false", nil} {
+		idp.idToken = jwt.MapClaims{"nonce": "nonce", "email": "jane@example.com", "email_verified": verified}
+		profile, err := provider.Exchange(context.Background(), "good-code", "verifier", "nonce")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profile.EmailVerified {
+			t.Errorf("email_verified %v read as verified", verified)
+		}
+	}
+}
+
+func TestGoogleExchangeRejects(t *testing.T) {
+	stranger, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		idToken jwt.MapClaims
+		key     *rsa.PrivateKey
+		want    error // nil when any error will do
+	}{
+		{"bad code", "bad-code", jwt.MapClaims{"nonce": "nonce"}, nil, nil},
+		{"no id token", "good-code", nil, nil, ErrMissingIDToken},
+		{"nonce of another login", "good-code", jwt.MapClaims{"nonce": "other"}, nil, ErrNonceMismatch},
+		{"no nonce", "good-code", jwt.MapClaims{}, nil, ErrNonceMismatch},
+		{"other audience", "good-code", jwt.MapClaims{"nonce": "nonce", "aud": "someone-else"}, nil, nil},
+		{"other issuer", "good-code", jwt.MapClaims{"nonce": "nonce", "iss": "https://evil.example.com"}, nil, nil},
+		{"expired", "good-code", jwt.MapClaims{"nonce": "nonce", "exp": time.Now().Add(-time.Hour).Unix()}, nil, nil},
+		{"signed by an unknown key", "good-code", jwt.MapClaims{"nonce": "nonce"}, stranger, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			if tt.key != nil {
+				idp.key = tt.key
+			}
+			idp.idToken = tt.idToken
+			provider, err := NewBuiltin(enum.GoogleLogin, idp.config(), "https://auth.example.com/callback")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			profile, err := provider.Exchange(context.Background(), tt.code, "verifier", "nonce")
+			if err == nil {
+				t.Fatalf("Exchange accepted the response, profile %+v", profile)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Exchange = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+)
+
+var (
+	ErrMissingIDToken   = errors.New("token response has no id_token")
+	ErrNonceMismatch    = errors.New("id_token nonce mismatch")
+	ErrUnknownProvider  = errors.New("unknown oauth provider")
+	ErrUserInfoResponse = errors.New("unexpected userinfo response")
+)
+
+// Profile is the normalized identity returned by a provider
+type Profile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// Provider runs the authorization code flow with PKCE against one identity provider
+type Provider interface {
+	Name() string
+	// AuthCodeURL returns the consent page URL for the state, PKCE verifier and nonce
+	AuthCodeURL(state, codeVerifier, nonce string) string
+	// Exchange redeems the code and returns the normalized profile of the signed in user
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Profile, error)
+}
+
+// Endpoints describes where a provider lives. Issuer and JWKSURL are only used by OpenID Connect providers.
+type Endpoints struct {
+	Issuer      string
+	AuthURL     string
+	TokenURL    string
+	JWKSURL     string
+	UserInfoURL string
+	Scopes      []string
+}
+
+// override applies the non-empty endpoint overrides from config
+func (e Endpoints) override(cfg config.SocialAuthConfig) Endpoints {
+	e.Issuer = valueOr(cfg.Issuer, e.Issuer)
+	e.AuthURL = valueOr(cfg.AuthURL, e.AuthURL)
+	e.TokenURL = valueOr(cfg.TokenURL, e.TokenURL)
+	e.JWKSURL = valueOr(cfg.JWKSURL, e.JWKSURL)
+	e.UserInfoURL = valueOr(cfg.UserInfoURL, e.UserInfoURL)
+	if len(cfg.Scopes) > 0 {
+		e.Scopes = cfg.Scopes
+	}
+	return e
+}
+
+func (e Endpoints) oauth2Config(cfg config.SocialAuthConfig, redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.SecretID,
+		RedirectURL:  redirectURL,
+		Scopes:       e.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  e.AuthURL,
+			TokenURL: e.TokenURL,
+		},
+	}
+}
+
+// GenerateVerifier returns a new PKCE code verifier
+func GenerateVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// getJSON fetches url with the token carrying client and decodes the JSON body into out
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrUserInfoResponse, url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func valueOr(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
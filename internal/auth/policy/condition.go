@@ -0,0 +1,197 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// operators a condition can use, "left op right"
+var operators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"in": true, "not_in": true, "contains": true,
+}
+
+// attribute roots a condition operand can reference, anything else is a JSON literal
+var roots = []string{"subject.", "resource.", "env."}
+
+// operand is either an attribute reference like subject.id or a literal
+type operand struct {
+	ref     string
+	literal interface{}
+}
+
+// condition is a parsed "left op right" expression, e.g. resource.id == subject.id
+type condition struct {
+	source      string
+	left, right operand
+	op          string
+}
+
+// parseCondition parses "left op right". The left operand is an attribute, the right one an attribute or a JSON
+// literal such as "user", 9 or ["sat", "sun"].
+func parseCondition(source string) (condition, error) {
+	parts := strings.SplitN(strings.TrimSpace(source), " ", 3)
+	if len(parts) != 3 {
+		return condition{}, fmt.Errorf("condition %q: expected \"left op right\"", source)
+	}
+	if !operators[parts[1]] {
+		return condition{}, fmt.Errorf("condition %q: unknown operator %q", source, parts[1])
+	}
+	left, err := parseOperand(parts[0])
+	if err != nil {
+		return condition{}, fmt.Errorf("condition %q: %w", source, err)
+	}
+	if left.ref == "" {
+		return condition{}, fmt.Errorf("condition %q: left side must be an attribute", source)
+	}
+	right, err := parseOperand(strings.TrimSpace(parts[2]))
+	if err != nil {
+		return condition{}, fmt.Errorf("condition %q: %w", source, err)
+	}
+	return condition{source: source, left: left, op: parts[1], right: right}, nil
+}
+
+func parseOperand(s string) (operand, error) {
+	for _, root := range roots {
+		if strings.HasPrefix(s, root) && len(s) > len(root) {
+			return operand{ref: s}, nil
+		}
+	}
+	var literal interface{}
+	if err := json.Unmarshal([]byte(s), &literal); err != nil {
+		return operand{}, fmt.Errorf("%q is neither an attribute nor a JSON literal", s)
+	}
+	return operand{literal: literal}, nil
+}
+
+// resolve looks the operand up in the request, unknown attributes are an error rather than a silent mismatch
+func (o operand) resolve(req Request) (interface{}, error) {
+	if o.ref == "" {
+		return o.literal, nil
+	}
+	root, name, _ := strings.Cut(o.ref, ".")
+	var attributes map[string]interface{}
+	switch root {
+	case "subject":
+		attributes = req.Subject
+	case "resource":
+		attributes = req.Resource
+	case "env":
+		attributes = req.Env
+	}
+	value, ok := attributes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown attribute %s", o.ref)
+	}
+	return normalize(value), nil
+}
+
+// evaluate reports whether the condition holds for the request
+func (c condition) evaluate(req Request) (bool, error) {
+	left, err := c.left.resolve(req)
+	if err != nil {
+		return false, err
+	}
+	right, err := c.right.resolve(req)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(left, right, c.op)
+	case "in":
+		return member(right, left)
+	case "not_in":
+		found, err := member(right, left)
+		return !found, err
+	case "contains":
+		if s, ok := left.(string); ok {
+			sub, ok := right.(string)
+			if !ok {
+				return false, fmt.Errorf("contains on a string needs a string")
+			}
+			return strings.Contains(s, sub), nil
+		}
+		return member(left, right)
+	}
+	return false, fmt.Errorf("unknown operator %q", c.op)
+}
+
+// normalize turns the Go values callers put in attributes into the JSON shapes literals decode to
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	}
+	return value
+}
+
+func equal(a, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		y, ok := b.(float64)
+		return ok && x == y
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func compare(a, b interface{}, op string) (bool, error) {
+	var cmp int
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return false, fmt.Errorf("can't compare a number with %v", b)
+		}
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return false, fmt.Errorf("can't compare a string with %v", b)
+		}
+		cmp = strings.Compare(x, y)
+	default:
+		return false, fmt.Errorf("can't order %v", a)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+func member(list, value interface{}) (bool, error) {
+	items, ok := list.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("%v is not a list", list)
+	}
+	for _, item := range items {
+		if equal(item, value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
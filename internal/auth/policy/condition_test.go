@@ -0,0 +1,73 @@
+package policy
+
+import "testing"
+
+func TestParseConditionRejects(t *testing.T) {
+	for _, source := range []string{
+		"subject.id",
+		"subject.id ==",
+		"subject.id ~ 1",
+		`"user" == subject.role`,
+		"subject.id == not-json",
+		"subject. == 1",
+	} {
+		if _, err := parseCondition(source); err == nil {
+			t.Errorf("parseCondition(%q) accepted an invalid condition", source)
+		}
+	}
+}
+
+func TestConditionEvaluate(t *testing.T) {
+	req := Request{
+		Subject: map[string]interface{}{
+			"id":    "u1",
+			"role":  "user",
+			"roles": []string{"user", "support"},
+			"level": 3,
+			"name":  "Jane Doe",
+		},
+		Resource: map[string]interface{}{"id": "u1", "owner": "u2"},
+		Env:      map[string]interface{}{"hour": 9, "weekday": "mon"},
+	}
+
+	tests := []struct {
+		source  string
+		holds   bool
+		wantErr bool
+	}{
+		{"resource.id == subject.id", true, false},
+		{"resource.owner == subject.id", false, false},
+		{"resource.owner != subject.id", true, false},
+		{`subject.role == "user"`, true, false},
+		{"subject.level == 3", true, false},
+		{"subject.level >= 3", true, false},
+		{"subject.level > 3", false, false},
+		{"env.hour < 17", true, false},
+		{"env.hour <= 8", false, false},
+		{`env.weekday in ["sat", "sun"]`, false, false},
+		{`env.weekday not_in ["sat", "sun"]`, true, false},
+		{`subject.roles contains "support"`, true, false},
+		{`subject.roles contains "admin"`, false, false},
+		{`subject.name contains "Doe"`, true, false},
+		{`subject.role > "admin"`, true, false},
+		{`subject.level < "high"`, false, true},
+		{"subject.missing == 1", false, true},
+		{`subject.role in "user"`, false, true},
+		{`subject.name contains 1`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			c, err := parseCondition(tt.source)
+			if err != nil {
+				t.Fatalf("parseCondition: %v", err)
+			}
+			holds, err := c.evaluate(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evaluate error = %v, want error %v", err, tt.wantErr)
+			}
+			if holds != tt.holds {
+				t.Errorf("evaluate = %v, want %v", holds, tt.holds)
+			}
+		})
+	}
+}
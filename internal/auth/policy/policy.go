@@ -0,0 +1,268 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+// Policy allows or denies actions when all of its conditions hold
+type Policy struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description,omitempty"`
+	Effect      Effect   `json:"effect"`
+	Actions     []string `json:"actions"`              // e.g. "profile:update", "profile:*" or "*"
+	Conditions  []string `json:"conditions,omitempty"` // all must hold, e.g. "resource.id == subject.id"
+	conditions  []condition
+}
+
+// document is the policy file
+type document struct {
+	Timezone string   `json:"timezone"` // IANA name the env.hour and env.weekday attributes are in, defaults to UTC
+	Policies []Policy `json:"policies"`
+	location *time.Location
+}
+
+// Request describes what is being attempted. Action is "<resource type>:<verb>", the attribute maps are
+// referenced by conditions as subject.x, resource.x and env.x.
+type Request struct {
+	Subject  map[string]interface{} `json:"subject"`
+	Action   string                 `json:"action"`
+	Resource map[string]interface{} `json:"resource"`
+	Env      map[string]interface{} `json:"env"`
+}
+
+// ConditionResult is the outcome of one condition
+type ConditionResult struct {
+	Condition string `json:"condition"`
+	Holds     bool   `json:"holds"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PolicyResult explains one policy whose actions matched the request
+type PolicyResult struct {
+	ID         string            `json:"id"`
+	Effect     Effect            `json:"effect"`
+	Applies    bool              `json:"applies"`
+	Conditions []ConditionResult `json:"conditions"`
+}
+
+// Decision is the outcome of an evaluation with the reasoning behind it
+type Decision struct {
+	Allowed  bool           `json:"allowed"`
+	Reason   string         `json:"reason"`
+	Request  Request        `json:"request"`
+	Policies []PolicyResult `json:"policies"`
+}
+
+// Engine evaluates the policies of a file with deny-overrides semantics: any applicable deny wins, otherwise
+// an applicable allow is needed, nothing applicable means deny.
+type Engine struct {
+	path string
+	mu   sync.RWMutex
+	doc  *document
+}
+
+// Load reads and validates the policy file
+func Load(path string) (*Engine, error) {
+	doc, err := readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{path: path, doc: doc}, nil
+}
+
+func readDocument(path string) (*document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc document
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("policy file: %w", err)
+	}
+
+	doc.location = time.UTC
+	if doc.Timezone != "" {
+		if doc.location, err = time.LoadLocation(doc.Timezone); err != nil {
+			return nil, fmt.Errorf("policy file: %w", err)
+		}
+	}
+
+	ids := map[string]bool{}
+	for i := range doc.Policies {
+		p := &doc.Policies[i]
+		if p.ID == "" || ids[p.ID] {
+			return nil, fmt.Errorf("policy file: policy %d needs a unique id", i)
+		}
+		ids[p.ID] = true
+		if p.Effect != EffectAllow && p.Effect != EffectDeny {
+			return nil, fmt.Errorf("policy %s: effect must be allow or deny", p.ID)
+		}
+		if len(p.Actions) == 0 {
+			return nil, fmt.Errorf("policy %s: no actions", p.ID)
+		}
+		for _, source := range p.Conditions {
+			c, err := parseCondition(source)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: %w", p.ID, err)
+			}
+			p.conditions = append(p.conditions, c)
+		}
+	}
+	return &doc, nil
+}
+
+// Reload rereads the policy file, a broken file leaves the current policies in place
+func (e *Engine) Reload() error {
+	doc, err := readDocument(e.path)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.doc = doc
+	e.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the policy file on an interval so rules change without a redeploy. It blocks, run it in its own
+// goroutine.
+func (e *Engine) Watch(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := e.Reload(); err != nil {
+			logrus.Errorf("Failed to reload policies: %v", err)
+		}
+	}
+}
+
+// Policies returns the loaded policies
+func (e *Engine) Policies() []Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.doc.Policies
+}
+
+// Env builds the environment attributes for a request made at the time from the ip
+func (e *Engine) Env(at time.Time, ip string) map[string]interface{} {
+	e.mu.RLock()
+	local := at.In(e.doc.location)
+	e.mu.RUnlock()
+	return map[string]interface{}{
+		"time":    local.Format(time.RFC3339),
+		"hour":    local.Hour(),
+		"minute":  local.Minute(),
+		"weekday": strings.ToLower(local.Weekday().String()[:3]),
+		"ip":      ip,
+	}
+}
+
+// Evaluate decides the request. A deny policy whose conditions can't be evaluated, e.g. because an attribute is
+// missing, still applies so that broken rules fail closed.
+func (e *Engine) Evaluate(req Request) Decision {
+	e.mu.RLock()
+	policies := e.doc.Policies
+	e.mu.RUnlock()
+
+	if req.Resource == nil {
+		req.Resource = map[string]interface{}{}
+	}
+	if _, ok := req.Resource["type"]; !ok {
+		resourceType, _, _ := strings.Cut(req.Action, ":")
+		req.Resource["type"] = resourceType
+	}
+
+	decision := Decision{Request: req, Policies: []PolicyResult{}}
+	var allowedBy, deniedBy string
+	for _, p := range policies {
+		if !matchesAction(p.Actions, req.Action) {
+			continue
+		}
+		result := PolicyResult{ID: p.ID, Effect: p.Effect, Applies: true, Conditions: []ConditionResult{}}
+		for _, c := range p.conditions {
+			holds, err := c.evaluate(req)
+			outcome := ConditionResult{Condition: c.source, Holds: holds}
+			if err != nil {
+				outcome.Error = err.Error()
+				holds = p.Effect == EffectDeny
+			}
+			result.Conditions = append(result.Conditions, outcome)
+			if !holds {
+				result.Applies = false
+			}
+		}
+		decision.Policies = append(decision.Policies, result)
+
+		if result.Applies && p.Effect == EffectDeny && deniedBy == "" {
+			deniedBy = p.ID
+		}
+		if result.Applies && p.Effect == EffectAllow && allowedBy == "" {
+			allowedBy = p.ID
+		}
+	}
+
+	switch {
+	case deniedBy != "":
+		decision.Reason = "denied by policy " + deniedBy
+	case allowedBy != "":
+		decision.Allowed = true
+		decision.Reason = "allowed by policy " + allowedBy
+	default:
+		decision.Reason = "no policy allows " + req.Action
+	}
+	return decision
+}
+
+// matchesAction matches exact actions, "*" and prefix wildcards like "profile:*"
+func matchesAction(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == action {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(action, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject builds the subject attributes from the authenticated request context
+func Subject(user model.UserCtxData) map[string]interface{} {
+	roles := user.Roles
+	if len(roles) == 0 && user.Role != "" {
+		roles = []string{user.Role}
+	}
+	permissions := user.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return map[string]interface{}{
+		"id":          user.ID,
+		"role":        user.Role,
+		"roles":       roles,
+		"permissions": permissions,
+		"client_id":   user.ClientID,
+		"api_key_id":  user.APIKeyID,
+		"session_id":  user.SessionID,
+		"org_id":      user.OrgID,
+		"org_role":    user.OrgRole,
+	}
+}
@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// load writes the policy file into a temporary directory and loads it
+func load(t *testing.T, content string) (*Engine, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policies.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+const testPolicies = `{
+	"policies": [
+		{"id": "owner-update", "effect": "allow", "actions": ["profile:update"], "conditions": ["resource.id == subject.id"]},
+		{"id": "admin-all", "effect": "allow", "actions": ["profile:*"], "conditions": ["subject.roles contains \"admin\""]},
+		{"id": "no-weekend", "effect": "deny", "actions": ["profile:update"], "conditions": ["env.weekday in [\"sat\", \"sun\"]"]},
+		{"id": "banned", "effect": "deny", "actions": ["*"], "conditions": ["subject.status == \"banned\""]}
+	]
+}`
+
+func TestEvaluate(t *testing.T) {
+	engine, err := load(t, testPolicies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weekday := map[string]interface{}{"weekday": "mon"}
+	weekend := map[string]interface{}{"weekday": "sat"}
+	user := func(id string, roles ...string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "roles": roles, "status": "active"}
+	}
+
+	tests := []struct {
+		name    string
+		req     Request
+		allowed bool
+		reason  string
+	}{
+		{
+			"owner allowed",
+			Request{Subject: user("u1"), Action: "profile:update", Resource: map[string]interface{}{"id": "u1"}, Env: weekday},
+			true, "allowed by policy owner-update",
+		},
+		{
+			"stranger denied by default",
+			Request{Subject: user("u2"), Action: "profile:update", Resource: map[string]interface{}{"id": "u1"}, Env: weekday},
+			false, "no policy allows profile:update",
+		},
+		{
+			"admin allowed through wildcard",
+			Request{Subject: user("u2", "admin"), Action: "profile:read", Resource: map[string]interface{}{"id": "u1"}, Env: weekday},
+			true, "allowed by policy admin-all",
+		},
+		{
+			"deny overrides allow",
+			Request{Subject: user("u1", "admin"), Action: "profile:update", Resource: map[string]interface{}{"id": "u1"}, Env: weekend},
+			false, "denied by policy no-weekend",
+		},
+		{
+			"deny on every action",
+			Request{Subject: map[string]interface{}{"id": "u1", "roles": []string{"admin"}, "status": "banned"}, Action: "profile:read", Env: weekday},
+			false, "denied by policy banned",
+		},
+		{
+			"deny missing an attribute fails closed",
+			Request{Subject: map[string]interface{}{"id": "u1", "roles": []string{"admin"}}, Action: "profile:read", Env: weekday},
+			false, "denied by policy banned",
+		},
+		{
+			"allow missing an attribute doesn't apply",
+			Request{Subject: map[string]interface{}{"id": "u1", "status": "active"}, Action: "profile:read", Env: weekday},
+			false, "no policy allows profile:read",
+		},
+		{
+			"unmatched action",
+			Request{Subject: user("u1", "admin"), Action: "reports:read", Env: weekday},
+			false, "no policy allows reports:read",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.Evaluate(tt.req)
+			if decision.Allowed != tt.allowed || decision.Reason != tt.reason {
+				t.Errorf("Evaluate = (%v, %q), want (%v, %q)", decision.Allowed, decision.Reason, tt.allowed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateExplains(t *testing.T) {
+	engine, err := load(t, testPolicies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision := engine.Evaluate(Request{
+		Subject: map[string]interface{}{"id": "u1", "roles": []string{}, "status": "active"},
+		Action:  "profile:update",
+		Env:     map[string]interface{}{"weekday": "mon"},
+	})
+
+	if got := decision.Request.Resource["type"]; got != "profile" {
+		t.Errorf("resource type = %v, want it derived from the action", got)
+	}
+	// owner-update can't resolve resource.id, admin-all and no-weekend don't hold, banned doesn't hold
+	want := map[string]bool{"owner-update": false, "admin-all": false, "no-weekend": false, "banned": false}
+	if len(decision.Policies) != len(want) {
+		t.Fatalf("explained %d policies, want %d", len(decision.Policies), len(want))
+	}
+	for _, result := range decision.Policies {
+		applies, ok := want[result.ID]
+		if !ok || result.Applies != applies {
+			t.Errorf("policy %s applies = %v, want %v", result.ID, result.Applies, applies)
+		}
+	}
+	if errs := decision.Policies[0].Conditions[0].Error; !strings.Contains(errs, "resource.id") {
+		t.Errorf("owner-update condition error = %q, want the missing attribute named", errs)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not json", `{`},
+		{"missing id", `{"policies": [{"effect": "allow", "actions": ["*"]}]}`},
+		{"duplicate id", `{"policies": [{"id": "a", "effect": "allow", "actions": ["*"]}, {"id": "a", "effect": "deny", "actions": ["*"]}]}`},
+		{"bad effect", `{"policies": [{"id": "a", "effect": "maybe", "actions": ["*"]}]}`},
+		{"no actions", `{"policies": [{"id": "a", "effect": "allow"}]}`},
+		{"bad condition", `{"policies": [{"id": "a", "effect": "allow", "actions": ["*"], "conditions": ["subject.id ~ 1"]}]}`},
+		{"bad timezone", `{"timezone": "Mars/Olympus", "policies": []}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(t, tt.content); err == nil {
+				t.Error("Load accepted an invalid policy file")
+			}
+		})
+	}
+}
+
+func TestMatchesAction(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		action   string
+		want     bool
+	}{
+		{[]string{"profile:update"}, "profile:update", true},
+		{[]string{"profile:update"}, "profile:read", false},
+		{[]string{"profile:*"}, "profile:read", true},
+		{[]string{"profile:*"}, "reports:read", false},
+		{[]string{"*"}, "reports:read", true},
+		{[]string{"reports:read", "profile:*"}, "profile:delete", true},
+		{nil, "profile:read", false},
+	}
+	for _, tt := range tests {
+		if got := matchesAction(tt.patterns, tt.action); got != tt.want {
+			t.Errorf("matchesAction(%v, %q) = %v, want %v", tt.patterns, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	engine, err := load(t, `{"timezone": "America/New_York", "policies": []}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saturday 02:30 UTC is Friday 22:30 in New York
+	env := engine.Env(time.Date(2024, 6, 1, 2, 30, 0, 0, time.UTC), "203.0.113.7")
+	if env["weekday"] != "fri" || env["hour"] != 22 || env["minute"] != 30 || env["ip"] != "203.0.113.7" {
+		t.Errorf("Env = %v, want Friday 22:30 from 203.0.113.7", env)
+	}
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the token buckets. The in-memory one is per process, run several instances against a shared store.
+type Store interface {
+	// Take spends one request from key's bucket, which holds limit requests and refills over window. When the
+	// bucket is empty it reports how long until the next request goes through.
+	Take(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	window time.Duration
+}
+
+// MemoryStore is a Store held in process memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	now := time.Now()
+	rate := float64(limit) / window.Seconds() // tokens per second
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), last: now}
+		s.buckets[key] = b
+	}
+	b.window = window
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > float64(limit) {
+		b.tokens = float64(limit)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// Sweep drops the buckets that refilled completely every interval, they are the same as new ones. It never
+// returns, run it in its own goroutine.
+func (s *MemoryStore) Sweep(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	for range time.Tick(interval) {
+		now := time.Now()
+		s.mu.Lock()
+		for key, b := range s.buckets {
+			if now.Sub(b.last) >= b.window {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
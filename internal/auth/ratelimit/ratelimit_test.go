@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   int
+		window  time.Duration
+		takes   int
+		allowed int
+	}{
+		{"under limit", 5, time.Hour, 3, 3},
+		{"at limit", 5, time.Hour, 5, 5},
+		{"over limit", 5, time.Hour, 8, 5},
+		{"single request", 1, time.Hour, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			allowed := 0
+			for i := 0; i < tt.takes; i++ {
+				ok, retryAfter, err := store.Take(context.Background(), "key", tt.limit, tt.window)
+				if err != nil {
+					t.Fatalf("Take: %v", err)
+				}
+				if ok {
+					allowed++
+					if retryAfter != 0 {
+						t.Errorf("allowed request got retry after %v", retryAfter)
+					}
+				} else if retryAfter <= 0 || retryAfter > tt.window/time.Duration(tt.limit) {
+					t.Errorf("retry after %v, want within (0, %v]", retryAfter, tt.window/time.Duration(tt.limit))
+				}
+			}
+			if allowed != tt.allowed {
+				t.Errorf("allowed %d of %d, want %d", allowed, tt.takes, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTakeKeysAreSeparate(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	if ok, _, _ := store.Take(ctx, "a", 1, time.Hour); !ok {
+		t.Fatal("first request for a was refused")
+	}
+	if ok, _, _ := store.Take(ctx, "a", 1, time.Hour); ok {
+		t.Fatal("second request for a went through")
+	}
+	if ok, _, _ := store.Take(ctx, "b", 1, time.Hour); !ok {
+		t.Fatal("b was refused because of a")
+	}
+}
+
+func TestMemoryStoreTakeRefills(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	window := 50 * time.Millisecond
+	store.Take(ctx, "key", 1, window)
+	if ok, _, _ := store.Take(ctx, "key", 1, window); ok {
+		t.Fatal("empty bucket let a request through")
+	}
+	time.Sleep(window + 10*time.Millisecond)
+	if ok, _, _ := store.Take(ctx, "key", 1, window); !ok {
+		t.Fatal("bucket didn't refill after the window")
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix starts every API key so leaked keys are easy to recognise, e.g. by secret scanners
+const APIKeyPrefix = "sak_"
+
+// APIKey is a long-lived credential a user creates for scripts. Only the hash of the key is stored, the prefix
+// identifies it in listings.
+type APIKey struct {
+	ID          uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name        string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix      string     `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash     string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Permissions string     `json:"permissions" gorm:"type:text"` // space separated
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP  *string    `json:"last_used_ip,omitempty" gorm:"type:varchar(45)"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"not null"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsActive reports whether the key can still be used
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(time.Now()))
+}
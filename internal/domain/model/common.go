@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type SignUpForm struct {
 	Name     string `json:"name" binding:"required"`
@@ -10,6 +13,16 @@ type SignUpForm struct {
 	// SignUpMethod string `json:"signup_method,omitempty"`
 }
 
+// AdminUserForm creates an account on someone's behalf, without a password the user is sent a reset link to set one
+type AdminUserForm struct {
+	Name          string `json:"name" binding:"required"`
+	Email         string `json:"email" binding:"required"`
+	Phone         string `json:"phone"`
+	Password      string `json:"password"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
 type LoginForm struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
@@ -25,12 +38,37 @@ type Response struct {
 type LogData struct {
 	ClientIp          string
 	UserAgent         string
+	DeviceName        string
 	RequestedResource string
 	At                time.Time
 }
 
 type UserCtxData struct {
 	ID          string   `json:"id"`
+	SessionID   string   `json:"session_id,omitempty"`
 	Role        string   `json:"role"`
-	Permissions []string `json:"permissions"`
+	Roles       []string `json:"roles,omitempty"`     // every role of the user, Role included
+	Permissions []string `json:"permissions"`         // resolved once per request by the Authenticate middleware
+	ClientID    string   `json:"client_id,omitempty"` // set instead of ID for client credentials tokens
+	APIKeyID    string   `json:"api_key_id,omitempty"`
+	OrgID       string   `json:"org_id,omitempty"`   // organization the token is switched to
+	OrgRole     string   `json:"org_role,omitempty"` // the user's role in that organization
+}
+
+// HasRole reports whether the user holds the role
+func (u UserCtxData) HasRole(role string) bool {
+	if strings.EqualFold(role, u.Role) {
+		return true
+	}
+	for _, r := range u.Roles {
+		if strings.EqualFold(role, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the user was granted the permission
+func (u UserCtxData) HasPermission(permission string) bool {
+	return ScopesCover(u.Permissions, []string{permission})
 }
@@ -7,10 +7,17 @@ const (
 	RefreshToken           string = "refresh_token"
 	PasswordResetToken     string = "password_reset"
 	EmailVerificationToken string = "email_verification"
+	MFAPendingToken        string = "mfa_pending"
 	GoogleLogin            string = "google"
 	RegularLogin           string = "regular"
 	GoogleSignUp           string = "google"
 	RegularSignUp          string = "regular"
+	AdminSignUp            string = "admin" // created through the admin API
 	LinkedinLogin          string = "linkedin"
 	LinkedinSignUP         string = "linkedin"
+	GithubLogin            string = "github"
+	FacebookLogin          string = "facebook"
+	TwitterLogin           string = "twitter"
+	SlackLogin             string = "slack"
+	DiscordLogin           string = "discord"
 )
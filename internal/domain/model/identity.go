@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links an external provider account to a user, one user can have many
+type Identity struct {
+	ID          uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Provider    string     `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject     string     `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject"`
+	Email       *string    `json:"email,omitempty" gorm:"type:varchar(255)"`
+	LinkedAt    time.Time  `json:"linked_at" gorm:"not null"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+func (Identity) TableName() string {
+	return "identities"
+}
+
+// OAuthResult is the outcome of a provider callback: a sign in (possibly pending a second factor) or a link
+type OAuthResult struct {
+	User     *User
+	MFAToken string
+	Linked   bool
+}
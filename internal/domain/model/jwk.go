@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// JWK is a public key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// SigningKeyState is where a key is in its rotation
+type SigningKeyState string
+
+const (
+	// SigningKeyNext is published so verifiers cache it, but doesn't sign yet
+	SigningKeyNext SigningKeyState = "next"
+	// SigningKeyActive signs every new token, there is exactly one
+	SigningKeyActive SigningKeyState = "active"
+	// SigningKeyRetiring no longer signs but verifies until the tokens it signed have expired
+	SigningKeyRetiring SigningKeyState = "retiring"
+)
+
+// SigningKey describes a key of the rotated key set, the private key itself stays on disk
+type SigningKey struct {
+	Kid        string          `json:"kid"`
+	Alg        string          `json:"alg"`
+	State      SigningKeyState `json:"state"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PromotedAt *time.Time      `json:"promoted_at,omitempty"`
+	RetireAt   *time.Time      `json:"retire_at,omitempty"`
+}
@@ -6,11 +6,54 @@ import (
 	"gorm.io/gorm"
 )
 
+// Audit events
+const (
+	LogEventRecoveryCodeUsed        = "mfa_recovery_code_used"
+	LogEventRecoveryCodesRegenerate = "mfa_recovery_codes_regenerated"
+	LogEventAuthenticatorCloned     = "webauthn_authenticator_cloned"
+	LogEventIdentityLinked          = "identity_linked"
+	LogEventIdentityUnlinked        = "identity_unlinked"
+	LogEventSigningKeyCreated       = "signing_key_created"
+	LogEventSigningKeyPromoted      = "signing_key_promoted"
+	LogEventSigningKeyRetired       = "signing_key_retired"
+	LogEventAPIKeyCreated           = "api_key_created"
+	LogEventAPIKeyRevoked           = "api_key_revoked"
+	LogEventRoleCreated             = "role_created"
+	LogEventRoleDeleted             = "role_deleted"
+	LogEventRolePermissionGranted   = "role_permission_granted"
+	LogEventRolePermissionRevoked   = "role_permission_revoked"
+	LogEventPermissionCreated       = "permission_created"
+	LogEventPermissionDeleted       = "permission_deleted"
+	LogEventRoleAssigned            = "role_assigned"
+	LogEventRoleUnassigned          = "role_unassigned"
+	LogEventOrgCreated              = "organization_created"
+	LogEventOrgMemberInvited        = "organization_member_invited"
+	LogEventOrgMemberJoined         = "organization_member_joined"
+	LogEventOrgMemberRoleChanged    = "organization_member_role_changed"
+	LogEventOrgMemberRemoved        = "organization_member_removed"
+	LogEventOrgSwitched             = "organization_switched"
+	LogEventTenantCreated           = "tenant_created"
+	LogEventTenantUpdated           = "tenant_updated"
+	LogEventTenantDeleted           = "tenant_deleted"
+	LogEventUserCreated             = "user_created"
+	LogEventUserRoleChanged         = "user_role_changed"
+	LogEventUserVerified            = "user_verified"
+	LogEventUserPasswordResetSent   = "user_password_reset_sent"
+	LogEventUserDeleted             = "user_deleted"
+	LogEventUserRestored            = "user_restored"
+	LogEventUserStatusChanged       = "user_status_changed"
+	LogEventAccountLocked           = "account_locked"
+	LogEventAccountUnlocked         = "account_unlocked"
+)
+
 type Log struct {
 	UserId    string    `json:"user_id"`
 	Event     string    `json:"event"`
 	At        time.Time `json:"at"`
 	IPAddress string    `json:"ip_address"`
+	// The account an admin acted on, empty when the event is about UserId itself
+	TargetUserId string            `json:"target_user_id,omitempty" gorm:"index"`
+	Metadata     map[string]string `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
 }
 
 func (Log) TableName() string {
@@ -23,5 +66,5 @@ func (l *Log) Create(db *gorm.DB) error {
 
 func NewLog(user_id string, event string, ip string) Log {
 
-	return Log{user_id, event, time.Now(), ip}
+	return Log{UserId: user_id, Event: event, At: time.Now(), IPAddress: ip}
 }
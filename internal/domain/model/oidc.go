@@ -0,0 +1,168 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client is an application registered to sign its users in through us
+type Client struct {
+	ID           uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	ClientID     string     `json:"client_id" gorm:"type:varchar(64);not null;uniqueIndex"`
+	SecretHash   *string    `json:"-" gorm:"type:varchar(64)"` // nil for public clients, they rely on PKCE alone
+	Name         string     `json:"name" gorm:"type:varchar(100);not null"`
+	RedirectURIs string     `json:"redirect_uris" gorm:"type:text"`                                     // space separated, matched exactly
+	Scopes       string     `json:"scopes" gorm:"type:text"`                                            // space separated
+	GrantTypes   string     `json:"grant_types" gorm:"type:text;not null;default:'authorization_code'"` // space separated
+	OwnerID      *uuid.UUID `json:"owner_id,omitempty" gorm:"type:uuid"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"not null"`
+}
+
+func (Client) TableName() string {
+	return "clients"
+}
+
+// Grant types a client can be registered for
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// IsPublic reports whether the client has no secret
+func (c *Client) IsPublic() bool {
+	return c.SecretHash == nil
+}
+
+// AllowsRedirectURI reports whether uri is one of the registered redirect URIs
+func (c *Client) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range strings.Fields(c.RedirectURIs) {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsGrant reports whether the client was registered for the grant type
+func (c *Client) AllowsGrant(grant string) bool {
+	return ScopesCover(strings.Fields(c.GrantTypes), []string{grant})
+}
+
+// AllowsScopes reports whether every requested scope was granted to the client
+func (c *Client) AllowsScopes(scopes []string) bool {
+	return ScopesCover(strings.Fields(c.Scopes), scopes)
+}
+
+// Consent records the scopes a user approved for a client
+type Consent struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_consent_user_client"`
+	ClientID  string    `json:"client_id" gorm:"type:varchar(64);not null;uniqueIndex:idx_consent_user_client"`
+	Scopes    string    `json:"scopes" gorm:"type:text"` // space separated
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (Consent) TableName() string {
+	return "consents"
+}
+
+// AuthorizeRequest is an OpenID Connect authorization request, bound from the query or a JSON body
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	Prompt              string `form:"prompt" json:"prompt"`
+}
+
+// AuthorizationCode is what an issued code remembers until it is redeemed
+type AuthorizationCode struct {
+	ClientID      string    `json:"client_id"`
+	RedirectURI   string    `json:"redirect_uri"`
+	Scope         string    `json:"scope"`
+	Nonce         string    `json:"nonce"`
+	CodeChallenge string    `json:"code_challenge"`
+	SessionID     string    `json:"session_id"`
+	AuthTime      time.Time `json:"auth_time"`
+}
+
+// TokenResponse is the RFC 6749 token endpoint response
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// IntrospectionResponse is an RFC 7662 introspection answer, an inactive token gets nothing but active=false
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+}
+
+// ScopesCover reports whether every scope in requested is in granted
+func ScopesCover(granted, requested []string) bool {
+	set := make(map[string]struct{}, len(granted))
+	for _, scope := range granted {
+		set[scope] = struct{}{}
+	}
+	for _, scope := range requested {
+		if _, ok := set[scope]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// UserClaims are the standard OpenID Connect profile claims released for the granted scopes, the subject is
+// carried next to them (in the ID token's registered claims or UserInfo)
+type UserClaims struct {
+	Name          *string `json:"name,omitempty"`
+	Picture       *string `json:"picture,omitempty"`
+	Email         *string `json:"email,omitempty"`
+	EmailVerified *bool   `json:"email_verified,omitempty"`
+	Phone         *string `json:"phone_number,omitempty"`
+	PhoneVerified *bool   `json:"phone_number_verified,omitempty"`
+}
+
+// NewUserClaims releases the user's profile, email and phone claims only for the matching scopes
+func NewUserClaims(user *User, scopes []string) UserClaims {
+	claims := UserClaims{}
+	for _, scope := range scopes {
+		switch scope {
+		case "profile":
+			claims.Name = user.Name
+			claims.Picture = user.ProfilePicture
+		case "email":
+			claims.Email = user.Email
+			claims.EmailVerified = &user.IsEmailVerified
+		case "phone":
+			claims.Phone = user.Phone
+			claims.PhoneVerified = &user.IsPhoneVerified
+		}
+	}
+	return claims
+}
+
+// UserInfo is the OpenID Connect userinfo response
+type UserInfo struct {
+	Subject string `json:"sub"`
+	UserClaims
+}
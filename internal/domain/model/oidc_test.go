@@ -0,0 +1,61 @@
+package model
+
+import "testing"
+
+func TestScopesCover(t *testing.T) {
+	tests := []struct {
+		name      string
+		granted   []string
+		requested []string
+		want      bool
+	}{
+		{"subset", []string{"openid", "email", "profile"}, []string{"openid", "email"}, true},
+		{"equal", []string{"openid"}, []string{"openid"}, true},
+		{"nothing requested", []string{"openid"}, nil, true},
+		{"nothing granted", nil, []string{"openid"}, false},
+		{"one missing", []string{"openid", "email"}, []string{"openid", "phone"}, false},
+		{"case sensitive", []string{"openid"}, []string{"OpenID"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopesCover(tt.granted, tt.requested); got != tt.want {
+				t.Errorf("ScopesCover(%v, %v) = %v, want %v", tt.granted, tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientAllows(t *testing.T) {
+	client := &Client{
+		RedirectURIs: "https://app.example.com/cb https://app.example.com/cb2",
+		Scopes:       "openid email",
+		GrantTypes:   GrantAuthorizationCode,
+	}
+
+	redirects := []struct {
+		uri  string
+		want bool
+	}{
+		{"https://app.example.com/cb", true},
+		{"https://app.example.com/cb2", true},
+		{"https://app.example.com/cb/", false},
+		{"https://app.example.com/cb?x=1", false},
+		{"https://evil.example.com/cb", false},
+		{"", false},
+	}
+	for _, tt := range redirects {
+		if got := client.AllowsRedirectURI(tt.uri); got != tt.want {
+			t.Errorf("AllowsRedirectURI(%q) = %v, want %v", tt.uri, got, tt.want)
+		}
+	}
+
+	if !client.AllowsGrant(GrantAuthorizationCode) || client.AllowsGrant(GrantClientCredentials) {
+		t.Error("AllowsGrant doesn't follow the registered grant types")
+	}
+	if !client.AllowsScopes([]string{"openid", "email"}) || client.AllowsScopes([]string{"openid", "phone"}) {
+		t.Error("AllowsScopes doesn't follow the registered scopes")
+	}
+	if !client.IsPublic() {
+		t.Error("client without a secret isn't public")
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles a member can have inside an organization, independent of the user's global roles
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// IsOrgRole reports whether role is one of the organization roles
+func IsOrgRole(role string) bool {
+	return role == OrgRoleOwner || role == OrgRoleAdmin || role == OrgRoleMember
+}
+
+// Organization is a customer company, its users sign in to it through their membership
+type Organization struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	CreatedBy uuid.UUID `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Membership puts a user in an organization with a per-organization role
+type Membership struct {
+	OrganizationID uuid.UUID    `json:"organization_id" gorm:"primaryKey;type:uuid"`
+	UserID         uuid.UUID    `json:"user_id" gorm:"primaryKey;type:uuid;index"`
+	Role           string       `json:"role" gorm:"type:varchar(20);not null"`
+	Organization   Organization `json:"organization,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt      time.Time    `json:"created_at" gorm:"not null"`
+	UpdatedAt      time.Time    `json:"updated_at" gorm:"not null"`
+}
+
+func (Membership) TableName() string {
+	return "memberships"
+}
+
+// OrgInvitation is what an invitation verification carries in its Data
+type OrgInvitation struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+}
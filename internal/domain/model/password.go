@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordHistory keeps the hashes of a user's recent passwords so they can't be reused
+type PasswordHistory struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Password  string    `json:"-" gorm:"type:varchar(255);not null"` // bcrypt hash
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+// PasswordViolation is one password policy rule a password breaks
+type PasswordViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"` // e.g. "min_length", "require_digit" or "breached"
+	Message string `json:"message"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permissions guarding the service's own admin endpoints, admin roles always hold all of them
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesWrite       = "roles:write"
+	PermissionClientsRead      = "clients:read"
+	PermissionClientsWrite     = "clients:write"
+	PermissionSigningKeysRead  = "signing_keys:read"
+	PermissionSigningKeysWrite = "signing_keys:write"
+	PermissionPoliciesRead     = "policies:read"
+	// PermissionOrganizationsWrite manages every organization, not just the ones the holder belongs to
+	PermissionOrganizationsWrite = "organizations:write"
+	PermissionTenantsRead        = "tenants:read"
+	PermissionTenantsWrite       = "tenants:write"
+)
+
+// BuiltinPermissions lists the permissions seeded at migration time
+var BuiltinPermissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionClientsRead,
+	PermissionClientsWrite,
+	PermissionSigningKeysRead,
+	PermissionSigningKeysWrite,
+	PermissionPoliciesRead,
+	PermissionOrganizationsWrite,
+	PermissionTenantsRead,
+	PermissionTenantsWrite,
+}
+
+// IsReadPermission reports whether the permission only grants reading, read-only roles get these
+func IsReadPermission(permission string) bool {
+	return strings.HasSuffix(permission, ":read")
+}
+
+// Permission is a named action, e.g. "reports:read"
+type Permission struct {
+	ID          uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string    `json:"description,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// Role groups permissions, users get them through their roles
+type Role struct {
+	ID          uuid.UUID    `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Name        string       `json:"name" gorm:"type:varchar(50);not null;uniqueIndex"`
+	Description string       `json:"description,omitempty" gorm:"type:text"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"not null"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// UserRole assigns an extra role to a user, on top of User.Role
+type UserRole struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"primaryKey;type:uuid"`
+	RoleID    uuid.UUID `json:"role_id" gorm:"primaryKey;type:uuid"`
+	Role      Role      `json:"role" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
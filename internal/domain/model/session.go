@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a signed-in device. It owns exactly one refresh token family and
+// every access token minted for it carries its ID, so revoking the session cuts both.
+type Session struct {
+	ID       uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID   uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	FamilyID uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex"`
+	// OrganizationID is the organization the session is switched to, its access tokens carry it as org_id
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid"`
+	DeviceName     string     `json:"device_name" gorm:"type:varchar(100)"`
+	IPAddress      string     `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent      string     `json:"user_agent" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"not null"`
+	LastSeenAt     time.Time  `json:"last_seen_at" gorm:"not null"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant is a customer deployment sharing this service, requests are matched to it by host or tenant header
+type Tenant struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Slug      string         `json:"slug" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name      string         `json:"name" gorm:"type:varchar(100);not null"`
+	Host      *string        `json:"host,omitempty" gorm:"type:varchar(255);uniqueIndex"` // lower case, without port
+	Settings  TenantSettings `json:"settings" gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt time.Time      `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"not null"`
+}
+
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+// TenantSettings override the global configuration for one tenant, unset fields fall back to it. Overrides can
+// only tighten it: token lifetimes only shorten, signing keys are kept around for the global ones, and lockout,
+// password policy and sign up only get stricter.
+type TenantSettings struct {
+	DisableSignup   *bool `json:"disable_signup,omitempty"`
+	LockoutAttempts *int  `json:"lockout_attempts,omitempty"`
+	LockoutFor      *int  `json:"lockout_for,omitempty"`     // minutes
+	LockoutMaxFor   *int  `json:"lockout_max_for,omitempty"` // minutes
+	// EnabledProviders narrows the globally enabled social providers, null keeps all of them and [] disables them
+	EnabledProviders   []string `json:"enabled_providers"`
+	CompanyName        *string  `json:"company_name,omitempty"`
+	AppURL             *string  `json:"app_url,omitempty"`
+	AccessTokenExpiry  *int     `json:"access_token_expiry,omitempty"`  // minutes
+	RefreshTokenExpiry *int     `json:"refresh_token_expiry,omitempty"` // minutes
+	PasswordMinLength  *int     `json:"password_min_length,omitempty"`
+	PasswordMaxLength  *int     `json:"password_max_length,omitempty"`
+	PasswordHistory    *int     `json:"password_history,omitempty"`
+	// Character classes and the personal info check can be turned on, not off
+	PasswordRequireUpper         *bool `json:"password_require_upper,omitempty"`
+	PasswordRequireLower         *bool `json:"password_require_lower,omitempty"`
+	PasswordRequireDigit         *bool `json:"password_require_digit,omitempty"`
+	PasswordRequireSymbol        *bool `json:"password_require_symbol,omitempty"`
+	PasswordDisallowPersonalInfo *bool `json:"password_disallow_personal_info,omitempty"`
+}
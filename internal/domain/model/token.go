@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the persisted (hashed) counterpart of an issued refresh token.
+// Every refresh rotates the token inside the same family, so presenting an already-used
+// token means it leaked and the whole family gets revoked.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	FamilyID  uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty" gorm:"type:uuid"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null"`
+}
+
+// IsActive reports whether the token can still be exchanged
+func (t *RefreshToken) IsActive() bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && t.ExpiresAt.After(time.Now())
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// TokenInfo is the verified, transport independent view of a signed token
+type TokenInfo struct {
+	UserID    uuid.UUID
+	SessionID *uuid.UUID
+	OrgID     *uuid.UUID // set when the token is scoped to an organization
+	TokenType string
+	ClientID  string // set when the token was issued to a client
+	Scope     string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	uuid "github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -27,14 +28,62 @@ type User struct {
 	PasswordChangedAt           *time.Time `json:"password_changed_at,omitempty"`
 	IncorrectLoginAttempts      int        `json:"incorrect_login_attempts,omitempty"`
 	LastIncorrectLoginAttemptAt *time.Time `json:"last_incorrect_login_attempt_at,omitempty"`
+	LockedUntil                 *time.Time `json:"locked_until,omitempty"`                     // password login is refused until then
+	TenantID                    *uuid.UUID `json:"tenant_id,omitempty" gorm:"type:uuid;index"` // tenant the account signed up under, its policies apply
 	LastLoginAt                 *time.Time `json:"last_login_at,omitempty"`
+	MFAEnabled                  bool       `json:"mfa_enabled" gorm:"default:false"`
+	MFAEnabledAt                *time.Time `json:"mfa_enabled_at,omitempty"`
+	TOTPSecret                  *string    `json:"-" gorm:"type:varchar(128)"` // sealed with the mfa encryption key
+	TOTPLastStep                int64      `json:"-" gorm:"default:0"`         // last accepted time step, codes can't be replayed
 	CreatedAt                   time.Time  `json:"created_at,omitempty"`
 	UpdatedAt                   time.Time  `json:"updated_at,omitempty"`
 	Role                        string     `json:"role" default:"user"`
+	// Account lifecycle, set by admins. A suspension ends by itself at SuspendedUntil.
+	Status          string     `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	StatusReason    *string    `json:"status_reason,omitempty"`
+	StatusChangedBy *uuid.UUID `json:"status_changed_by,omitempty" gorm:"type:uuid"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty"`
+	// Soft deleted accounts are hidden from every lookup until an admin restores them
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// Relationships
 }
 
+// Account states
+const (
+	UserStatusActive          = "active"
+	UserStatusSuspended       = "suspended"
+	UserStatusBanned          = "banned"
+	UserStatusPendingDeletion = "pending_deletion"
+)
+
+// IsUserStatus reports whether status is one of the account states
+func IsUserStatus(status string) bool {
+	switch status {
+	case UserStatusActive, UserStatusSuspended, UserStatusBanned, UserStatusPendingDeletion:
+		return true
+	}
+	return false
+}
+
+// EffectiveStatus is the account state at the time, an expired suspension counts as active. Rows from before
+// statuses existed have none and are active.
+func (u *User) EffectiveStatus(at time.Time) string {
+	switch {
+	case u.Status == "":
+		return UserStatusActive
+	case u.Status == UserStatusSuspended && u.SuspendedUntil != nil && !at.Before(*u.SuspendedUntil):
+		return UserStatusActive
+	}
+	return u.Status
+}
+
+// IsLocked reports whether sign in is locked out at the time
+func (u *User) IsLocked(at time.Time) bool {
+	return u.LockedUntil != nil && at.Before(*u.LockedUntil)
+}
+
 // IsVerified checks if the user has verified their email and phone
 func (u *User) IsVerified() bool {
 	// This would be implemented in the service layer
@@ -46,3 +95,50 @@ func (u *User) IsVerified() bool {
 func (User) TableName() string {
 	return "users"
 }
+
+// UserFilter narrows the admin user listing, zero fields don't filter
+type UserFilter struct {
+	Email         string     `form:"email"` // case insensitive substring
+	Role          string     `form:"role"`  // User.Role or an assigned role
+	EmailVerified *bool      `form:"email_verified"`
+	PhoneVerified *bool      `form:"phone_verified"`
+	SignUpMethod  string     `form:"signup_method"`
+	Status        string     `form:"status"`        // stored state, a lapsed suspension still reads suspended
+	CreatedAfter  *time.Time `form:"created_after"` // RFC 3339
+	CreatedBefore *time.Time `form:"created_before"`
+	Deleted       bool       `form:"deleted"` // list the soft deleted accounts instead
+}
+
+// Page sizes of paginated listings
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Pagination selects a page of a listing, pages start at 1
+type Pagination struct {
+	Page    int `form:"page"`
+	PerPage int `form:"per_page"`
+}
+
+// Normalized clamps the page into range, filling in the defaults
+func (p Pagination) Normalized() Pagination {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = DefaultPerPage
+	}
+	if p.PerPage > MaxPerPage {
+		p.PerPage = MaxPerPage
+	}
+	return p
+}
+
+// UserPage is one page of users with the total matching the filter
+type UserPage struct {
+	Users   []User `json:"users"`
+	Total   int64  `json:"total"`
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+}
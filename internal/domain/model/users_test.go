@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPaginationNormalized(t *testing.T) {
+	tests := []struct {
+		name string
+		page Pagination
+		want Pagination
+	}{
+		{"defaults", Pagination{}, Pagination{Page: 1, PerPage: DefaultPerPage}},
+		{"in range", Pagination{Page: 3, PerPage: 50}, Pagination{Page: 3, PerPage: 50}},
+		{"negative", Pagination{Page: -2, PerPage: -1}, Pagination{Page: 1, PerPage: DefaultPerPage}},
+		{"too large", Pagination{Page: 2, PerPage: MaxPerPage + 1}, Pagination{Page: 2, PerPage: MaxPerPage}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.page.Normalized(); got != tt.want {
+				t.Errorf("Normalized(%+v) = %+v, want %+v", tt.page, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveStatus(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		user User
+		want string
+	}{
+		{"row from before statuses", User{}, UserStatusActive},
+		{"active", User{Status: UserStatusActive}, UserStatusActive},
+		{"suspended", User{Status: UserStatusSuspended, SuspendedUntil: &later}, UserStatusSuspended},
+		{"suspension over", User{Status: UserStatusSuspended, SuspendedUntil: &earlier}, UserStatusActive},
+		{"suspension ending now", User{Status: UserStatusSuspended, SuspendedUntil: &now}, UserStatusActive},
+		{"banned", User{Status: UserStatusBanned, SuspendedUntil: &earlier}, UserStatusBanned},
+		{"pending deletion", User{Status: UserStatusPendingDeletion}, UserStatusPendingDeletion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.EffectiveStatus(now); got != tt.want {
+				t.Errorf("EffectiveStatus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUserStatus(t *testing.T) {
+	for _, status := range []string{UserStatusActive, UserStatusSuspended, UserStatusBanned, UserStatusPendingDeletion} {
+		if !IsUserStatus(status) {
+			t.Errorf("IsUserStatus(%q) = false", status)
+		}
+	}
+	for _, status := range []string{"", "Active", "deleted", "locked"} {
+		if IsUserStatus(status) {
+			t.Errorf("IsUserStatus(%q) = true", status)
+		}
+	}
+}
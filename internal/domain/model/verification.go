@@ -13,6 +13,24 @@ const (
 	VerificationTypePhone VerificationType = "phone"
 	VerificationTypeReset VerificationType = "reset"
 	VerificationType2FA   VerificationType = "2fa"
+	// VerificationTypeTOTPEnroll holds a generated TOTP secret until the user confirms it with a first code
+	VerificationTypeTOTPEnroll VerificationType = "totp_enroll"
+	// WebAuthn ceremonies keep their challenge in Token and the serialized session in Data
+	VerificationTypeWebAuthnRegister VerificationType = "webauthn_register"
+	VerificationTypeWebAuthnLogin    VerificationType = "webauthn_login"
+	// VerificationTypeMagicLink keeps the hashed link token in Token and the hashed browser nonce in Data
+	VerificationTypeMagicLink VerificationType = "magic_link"
+	// VerificationTypePhoneLogin keeps the hashed one-time passcode in Data, codes are too short for a unique column
+	VerificationTypePhoneLogin VerificationType = "phone_login"
+	// VerificationTypeOAuthState keeps the hashed OAuth state in Token and the PKCE verifier and nonce in Data
+	VerificationTypeOAuthState VerificationType = "oauth_state"
+	// VerificationTypeOIDCCode keeps a hashed authorization code we issued in Token and its request in Data
+	VerificationTypeOIDCCode VerificationType = "oidc_code"
+	// VerificationTypeOrgInvite keeps the hashed invitation token in Token and the OrgInvitation in Data, UserID is
+	// the inviter since the invitee may not have an account yet
+	VerificationTypeOrgInvite VerificationType = "org_invite"
+	// VerificationTypeUnlock keeps the hashed token of the unlock link sent when password login gets locked
+	VerificationTypeUnlock VerificationType = "unlock"
 )
 
 // VerificationStatus represents the status of a verification
@@ -33,7 +51,9 @@ type Verification struct {
 	Type       VerificationType   `json:"type" gorm:"type:varchar(20);not null"`
 	Token      *string            `json:"token,omitempty" gorm:"type:varchar(255);uniqueIndex"`
 	Code       *string            `json:"code,omitempty" gorm:"type:varchar(20);uniqueIndex"`
+	Data       *string            `json:"-" gorm:"type:text"`
 	Status     VerificationStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Attempts   int                `json:"attempts" gorm:"not null;default:0"`
 	SentAt     time.Time          `json:"sent_at" gorm:"not null"`
 	VerifiedAt *time.Time         `json:"verified_at,omitempty"`
 	ExpiresAt  time.Time          `json:"expires_at" gorm:"not null"`
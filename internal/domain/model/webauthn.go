@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a registered passkey or security key
+type WebAuthnCredential struct {
+	ID              uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name            string     `json:"name" gorm:"type:varchar(100)"`
+	CredentialID    []byte     `json:"-" gorm:"type:bytea;not null;uniqueIndex"`
+	PublicKey       []byte     `json:"-" gorm:"type:bytea;not null"`
+	AttestationType string     `json:"attestation_type" gorm:"type:varchar(50)"`
+	Transports      string     `json:"transports" gorm:"type:varchar(100)"` // comma separated
+	AAGUID          []byte     `json:"-" gorm:"type:bytea"`
+	SignCount       uint32     `json:"sign_count" gorm:"not null;default:0"`
+	CloneWarning    bool       `json:"clone_warning" gorm:"default:false"`
+	BackupEligible  bool       `json:"backup_eligible" gorm:"default:false"`
+	BackupState     bool       `json:"backup_state" gorm:"default:false"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"not null"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
@@ -0,0 +1,10 @@
+package validators
+
+import "regexp"
+
+var e164 = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+
+// IsValidPhone checks the number is in E.164 format, e.g. +251911223344
+func IsValidPhone(phone string) bool {
+	return e164.MatchString(phone)
+}
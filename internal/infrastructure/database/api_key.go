@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// API Key Operations
+func (r *Repository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeys lists the user's keys that haven't been revoked
+func (r *Repository) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// TouchAPIKey records when and from where the key was last used
+func (r *Repository) TouchAPIKey(ctx context.Context, id uuid.UUID, ip string) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_used_at": time.Now(),
+			"last_used_ip": ip,
+		}).Error
+}
+
+// RevokeAPIKey revokes one of the user's keys, the record is kept for auditing
+func (r *Repository) RevokeAPIKey(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
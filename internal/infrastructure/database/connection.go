@@ -65,6 +65,10 @@ func InitDatabase(config *config.Config) (*GormDatabase, error) {
 		os.Exit(1)
 	}
 
+	if err = seedRoles(db, config); err != nil {
+		logrus.Fatalf("Failed to seed roles: %v", err)
+	}
+
 	fmt.Println("DB successfully connected:")
 
 	return &GormDatabase{db: db}, nil
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// Identity Operations
+func (r *Repository) CreateIdentity(ctx context.Context, identity *model.Identity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *Repository) GetIdentity(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	var identity model.Identity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *Repository) ListIdentities(ctx context.Context, userID uuid.UUID) ([]model.Identity, error) {
+	var identities []model.Identity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("linked_at ASC").Find(&identities).Error
+	return identities, err
+}
+
+// TouchIdentity records a sign in through the identity and refreshes the email the provider reported
+func (r *Repository) TouchIdentity(ctx context.Context, id uuid.UUID, email string) error {
+	return r.db.WithContext(ctx).Model(&model.Identity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":         email,
+			"last_login_at": time.Now(),
+		}).Error
+}
+
+func (r *Repository) DeleteIdentity(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND id = ?", userID, id).Delete(&model.Identity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
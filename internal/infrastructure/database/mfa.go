@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// Recovery Code Operations
+
+// ReplaceRecoveryCodes drops every existing code of the user and stores the new set
+func (r *Repository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codes []model.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// ConsumeRecoveryCode marks an unused code as used, it reports false when no such code exists
+func (r *Repository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP step unless it isn't newer, it reports false then so a
+// code raced through two requests is only accepted once
+func (r *Repository) AdvanceTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SealTOTPSecret replaces a TOTP secret stored in plaintext with its sealed value, unless it changed meanwhile
+func (r *Repository) SealTOTPSecret(ctx context.Context, userID uuid.UUID, plaintext, sealed string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ? AND totp_secret = ?", userID, plaintext).
+		Update("totp_secret", sealed).Error
+}
+
+func (r *Repository) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *Repository) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
+
+// Audit Log Operations
+func (r *Repository) CreateLog(ctx context.Context, log *model.Log) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
@@ -13,6 +13,21 @@ func AllModels() []interface{} {
 		&model.Log{},
 		&model.Verification{},
 		&model.BlacklistedToken{},
+		&model.RefreshToken{},
+		&model.Session{},
+		&model.RecoveryCode{},
+		&model.WebAuthnCredential{},
+		&model.Identity{},
+		&model.Client{},
+		&model.Consent{},
+		&model.APIKey{},
+		&model.Permission{},
+		&model.Role{},
+		&model.UserRole{},
+		&model.Organization{},
+		&model.Membership{},
+		&model.Tenant{},
+		&model.PasswordHistory{},
 	}
 }
 
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Client Operations
+func (r *Repository) CreateClient(ctx context.Context, client *model.Client) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *Repository) GetClientByClientID(ctx context.Context, clientID string) (*model.Client, error) {
+	var client model.Client
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *Repository) ListClients(ctx context.Context) ([]model.Client, error) {
+	var clients []model.Client
+	err := r.db.WithContext(ctx).Order("created_at ASC").Find(&clients).Error
+	return clients, err
+}
+
+func (r *Repository) DeleteClient(ctx context.Context, clientID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("client_id = ?", clientID).Delete(&model.Client{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("client_id = ?", clientID).Delete(&model.Consent{}).Error
+	})
+}
+
+// Consent Operations
+func (r *Repository) GetConsent(ctx context.Context, userID uuid.UUID, clientID string) (*model.Consent, error) {
+	var consent model.Consent
+	err := r.db.WithContext(ctx).Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent).Error
+	if err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+// SaveConsent records the approved scopes, replacing an earlier consent for the same client
+func (r *Repository) SaveConsent(ctx context.Context, userID uuid.UUID, clientID, scopes string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"scopes": scopes, "updated_at": now}),
+	}).Create(&model.Consent{
+		UserID:    userID,
+		ClientID:  clientID,
+		Scopes:    scopes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}).Error
+}
+
+func (r *Repository) ListConsents(ctx context.Context, userID uuid.UUID) ([]model.Consent, error) {
+	var consents []model.Consent
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&consents).Error
+	return consents, err
+}
+
+func (r *Repository) DeleteConsent(ctx context.Context, userID uuid.UUID, clientID string) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND client_id = ?", userID, clientID).Delete(&model.Consent{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
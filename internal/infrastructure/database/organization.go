@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Organization Operations
+
+// CreateOrganization creates the organization with its first owner
+func (r *Repository) CreateOrganization(ctx context.Context, org *model.Organization, owner uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.Membership{
+			OrganizationID: org.ID,
+			UserID:         owner,
+			Role:           model.OrgRoleOwner,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}).Error
+	})
+}
+
+func (r *Repository) GetOrganizationByID(ctx context.Context, id uuid.UUID) (*model.Organization, error) {
+	var org model.Organization
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&org).Error
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Membership Operations
+func (r *Repository) GetMembership(ctx context.Context, orgID, userID uuid.UUID) (*model.Membership, error) {
+	var membership model.Membership
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ListUserMemberships lists the user's memberships with their organizations
+func (r *Repository) ListUserMemberships(ctx context.Context, userID uuid.UUID) ([]model.Membership, error) {
+	var memberships []model.Membership
+	err := r.db.WithContext(ctx).Preload("Organization").Where("user_id = ?", userID).Order("created_at ASC").Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *Repository) ListOrganizationMembers(ctx context.Context, orgID uuid.UUID) ([]model.Membership, error) {
+	var memberships []model.Membership
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("created_at ASC").Find(&memberships).Error
+	return memberships, err
+}
+
+// AddMembership adds the user, an existing membership keeps its role
+func (r *Repository) AddMembership(ctx context.Context, membership *model.Membership) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(membership).Error
+}
+
+func (r *Repository) UpdateMembershipRole(ctx context.Context, orgID, userID uuid.UUID, role string) error {
+	result := r.db.WithContext(ctx).Model(&model.Membership{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Updates(map[string]interface{}{"role": role, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RemoveMembership removes the user and clears the organization from their sessions
+func (r *Repository) RemoveMembership(ctx context.Context, orgID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("organization_id = ? AND user_id = ?", orgID, userID).Delete(&model.Membership{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&model.Session{}).
+			Where("user_id = ? AND organization_id = ?", userID, orgID).
+			Update("organization_id", nil).Error
+	})
+}
+
+func (r *Repository) CountOrganizationOwners(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Membership{}).
+		Where("organization_id = ? AND role = ?", orgID, model.OrgRoleOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// SetSessionOrganization sets the organization the session's tokens are scoped to, nil for none
+func (r *Repository) SetSessionOrganization(ctx context.Context, sessionID uuid.UUID, orgID *uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.Session{}).Where("id = ?", sessionID).
+		Update("organization_id", orgID).Error
+}
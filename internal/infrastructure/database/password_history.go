@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// RecentPasswords returns the hashes of the user's last n passwords, newest first
+func (r *Repository) RecentPasswords(ctx context.Context, userID uuid.UUID, n int) ([]string, error) {
+	var hashes []string
+	err := r.db.WithContext(ctx).Model(&model.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(n).
+		Pluck("password", &hashes).Error
+	return hashes, err
+}
+
+// AddPasswordHistory records a new password hash and drops all but the last keep ones
+func (r *Repository) AddPasswordHistory(ctx context.Context, userID uuid.UUID, hashedPassword string, keep int) error {
+	db := r.db.WithContext(ctx)
+	entry := &model.PasswordHistory{
+		UserID:    userID,
+		Password:  hashedPassword,
+		CreatedAt: time.Now(),
+	}
+	if err := db.Create(entry).Error; err != nil {
+		return err
+	}
+	return db.Where("user_id = ? AND id NOT IN (?)", userID,
+		db.Model(&model.PasswordHistory{}).Select("id").Where("user_id = ?", userID).Order("created_at DESC").Limit(keep),
+	).Delete(&model.PasswordHistory{}).Error
+}
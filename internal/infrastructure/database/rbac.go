@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Permission Operations
+func (r *Repository) CreatePermission(ctx context.Context, permission *model.Permission) error {
+	return r.db.WithContext(ctx).Create(permission).Error
+}
+
+func (r *Repository) GetPermissionByName(ctx context.Context, name string) (*model.Permission, error) {
+	var permission model.Permission
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&permission).Error
+	if err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+func (r *Repository) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+// DeletePermission removes the permission from every role holding it
+func (r *Repository) DeletePermission(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var permission model.Permission
+		if err := tx.Where("name = ?", name).First(&permission).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM role_permissions WHERE permission_id = ?", permission.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&permission).Error
+	})
+}
+
+// Role Operations
+func (r *Repository) CreateRole(ctx context.Context, role *model.Role) error {
+	return r.db.WithContext(ctx).Create(role).Error
+}
+
+func (r *Repository) GetRoleByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *Repository) ListRoles(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// DeleteRole removes the role with its grants and assignments
+func (r *Repository) DeleteRole(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role model.Role
+		if err := tx.Where("name = ?", name).First(&role).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&model.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+}
+
+func (r *Repository) AddRolePermission(ctx context.Context, role *model.Role, permission *model.Permission) error {
+	return r.db.WithContext(ctx).Model(role).Association("Permissions").Append(permission)
+}
+
+func (r *Repository) RemoveRolePermission(ctx context.Context, role *model.Role, permission *model.Permission) error {
+	return r.db.WithContext(ctx).Model(role).Association("Permissions").Delete(permission)
+}
+
+// User Role Operations
+func (r *Repository) AssignUserRole(ctx context.Context, userID, roleID uuid.UUID) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserRole{
+		UserID:    userID,
+		RoleID:    roleID,
+		CreatedAt: time.Now(),
+	}).Error
+}
+
+func (r *Repository) UnassignUserRole(ctx context.Context, userID, roleID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&model.UserRole{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetUserRoleNames lists the names of the roles assigned to the user
+func (r *Repository) GetUserRoleNames(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	var names []string
+	err := r.db.WithContext(ctx).Model(&model.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name ASC").
+		Pluck("roles.name", &names).Error
+	return names, err
+}
+
+// GetRolePermissionNames resolves the permissions granted by any of the roles in one query
+func (r *Repository) GetRolePermissionNames(ctx context.Context, roles []string) ([]string, error) {
+	var names []string
+	if len(roles) == 0 {
+		return names, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name IN ?", roles).
+		Order("permissions.name ASC").
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
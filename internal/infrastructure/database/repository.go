@@ -59,9 +59,10 @@ func (r *Repository) DeleteUser(ctx context.Context, userID uuid.UUID) error {
 	return r.db.WithContext(ctx).Where("id = ?", userID).Delete(&model.User{}).Error
 }
 
+// UserExists counts soft deleted accounts too, they keep their email until restored
 func (r *Repository) UserExists(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&model.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).Where("email = ?", email).Count(&count).Error
 	if err != nil {
 		return false, err
 	}
@@ -69,16 +70,30 @@ func (r *Repository) UserExists(ctx context.Context, email string) (bool, error)
 }
 
 // Login Operations
-func (r *Repository) IncrementLoginAttempts(ctx context.Context, userID uuid.UUID) error {
-	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).
-		Updates(map[string]interface{}{
-			"incorrect_login_attempts":        gorm.Expr("incorrect_login_attempts + 1"),
-			"last_incorrect_login_attempt_at": time.Now(),
-		}).Error
+
+// IncrementLoginAttempts records a failed login and returns the failures in a row so far
+func (r *Repository) IncrementLoginAttempts(ctx context.Context, userID uuid.UUID) (int, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"incorrect_login_attempts":        gorm.Expr("incorrect_login_attempts + 1"),
+				"last_incorrect_login_attempt_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Select("incorrect_login_attempts").Where("id = ?", userID).First(&user).Error
+	})
+	return user.IncorrectLoginAttempts, err
 }
 
+// ResetLoginAttempts clears the failure count and any lock
 func (r *Repository) ResetLoginAttempts(ctx context.Context, userID uuid.UUID) error {
-	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("incorrect_login_attempts", 0).Error
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"incorrect_login_attempts": 0,
+			"locked_until":             nil,
+		}).Error
 }
 
 func (r *Repository) UpdateLastLogin(ctx context.Context, userID uuid.UUID) error {
@@ -95,6 +110,16 @@ func (r *Repository) BlacklistToken(ctx context.Context, token string) error {
 	return r.db.WithContext(ctx).Create(blacklistedToken).Error
 }
 
+// BlacklistTokenUntil blacklists a token until it would have expired anyway
+func (r *Repository) BlacklistTokenUntil(ctx context.Context, token string, expiresAt time.Time) error {
+	blacklistedToken := &model.BlacklistedToken{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	return r.db.WithContext(ctx).Create(blacklistedToken).Error
+}
+
 func (r *Repository) IsTokenBlacklisted(ctx context.Context, token string) (bool, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
@@ -188,3 +213,71 @@ func (r *Repository) UpdatePassword(ctx context.Context, userID uuid.UUID, hashe
 		"password_changed_at": time.Now(),
 	}).Error
 }
+
+// GetLatestVerification retrieves the most recent pending, unexpired verification of a type for a user
+func (r *Repository) GetLatestVerification(ctx context.Context, userID uuid.UUID, vType model.VerificationType) (*model.Verification, error) {
+	var verification model.Verification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND type = ? AND status = ? AND expires_at > ?", userID, vType, model.VerificationStatusPending, time.Now()).
+		Order("created_at DESC").
+		First(&verification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// IncrementVerificationAttempts records a failed attempt and returns the new count
+func (r *Repository) IncrementVerificationAttempts(ctx context.Context, id uuid.UUID) (int, error) {
+	var verification model.Verification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Verification{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Select("attempts").Where("id = ?", id).First(&verification).Error
+	})
+	return verification.Attempts, err
+}
+
+// ConsumeVerification marks a pending verification as verified. It only succeeds once, concurrent callers get false.
+func (r *Repository) ConsumeVerification(ctx context.Context, id uuid.UUID) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&model.Verification{}).
+		Where("id = ? AND status = ?", id, model.VerificationStatusPending).
+		Updates(map[string]interface{}{
+			"status":      model.VerificationStatusVerified,
+			"updated_at":  now,
+			"verified_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountVerificationsSince counts verifications of a type sent to a user after the given time, whatever their status
+func (r *Repository) CountVerificationsSince(ctx context.Context, userID uuid.UUID, vType model.VerificationType, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.Verification{}).
+		Where("user_id = ? AND type = ? AND sent_at > ?", userID, vType, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ExpirePendingVerifications expires every pending verification of a type for a user
+func (r *Repository) ExpirePendingVerifications(ctx context.Context, userID uuid.UUID, vType model.VerificationType) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Verification{}).
+		Where("user_id = ? AND type = ? AND status = ?", userID, vType, model.VerificationStatusPending).
+		Updates(map[string]interface{}{
+			"status":     model.VerificationStatusExpired,
+			"updated_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"time"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// seedRoles creates the roles named in the config with their default permissions. Roles that already exist keep
+// what admins made of them, except admin roles which always get every permission so nobody can lock them out.
+func seedRoles(db *gorm.DB, cfg *config.Config) error {
+	names := append([]string{}, model.BuiltinPermissions...)
+	for _, granted := range cfg.RolePermissions {
+		names = append(names, granted...)
+	}
+
+	permissions := map[string]model.Permission{}
+	for _, name := range names {
+		if _, ok := permissions[name]; ok {
+			continue
+		}
+		permission := model.Permission{Name: name, CreatedAt: time.Now()}
+		if err := db.Where("name = ?", name).FirstOrCreate(&permission).Error; err != nil {
+			return err
+		}
+		permissions[name] = permission
+	}
+
+	defaults := map[string]map[string]model.Permission{}
+	grant := func(role string, permission model.Permission) {
+		if defaults[role] == nil {
+			defaults[role] = map[string]model.Permission{}
+		}
+		defaults[role][permission.Name] = permission
+	}
+	for role, granted := range cfg.RolePermissions {
+		for _, name := range granted {
+			grant(role, permissions[name])
+		}
+	}
+	for _, role := range cfg.ReadOnlyRoles {
+		for name, permission := range permissions {
+			if model.IsReadPermission(name) {
+				grant(role, permission)
+			}
+		}
+	}
+	admins := map[string]bool{}
+	for _, role := range cfg.AdminRoles {
+		admins[role] = true
+		for _, permission := range permissions {
+			grant(role, permission)
+		}
+	}
+
+	for name, set := range defaults {
+		granted := make([]model.Permission, 0, len(set))
+		for _, permission := range set {
+			granted = append(granted, permission)
+		}
+
+		var role model.Role
+		err := db.Where("name = ?", name).First(&role).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			role = model.Role{Name: name, Permissions: granted, CreatedAt: time.Now()}
+			if err := db.Create(&role).Error; err != nil {
+				return err
+			}
+			logrus.Infoln("Seeded role : ", name)
+		case err != nil:
+			return err
+		case admins[name]:
+			if err := db.Model(&role).Association("Permissions").Append(granted); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
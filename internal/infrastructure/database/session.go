@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval throttles last-seen writes so authenticated traffic doesn't update the row on every request
+const sessionTouchInterval = time.Minute
+
+// Session Operations
+func (r *Repository) CreateSession(ctx context.Context, session *model.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *Repository) GetSessionByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	var session model.Session
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *Repository) GetSessionByFamily(ctx context.Context, familyID uuid.UUID) (*model.Session, error) {
+	var session model.Session
+	err := r.db.WithContext(ctx).Where("family_id = ?", familyID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveSessions returns the non-revoked sessions of a user, most recently used first
+func (r *Repository) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// TouchSession records activity on a session, at most once per sessionTouchInterval
+func (r *Repository) TouchSession(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Model(&model.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, now.Add(-sessionTouchInterval)).
+		Update("last_seen_at", now).Error
+}
+
+// RevokeSession revokes a user's session together with its refresh token family
+func (r *Repository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	revoked, err := r.revokeSessions(ctx, "user_id = ? AND id = ?", userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of a user except the one given
+func (r *Repository) RevokeOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	_, err := r.revokeSessions(ctx, "user_id = ? AND id <> ?", userID, keepSessionID)
+	return err
+}
+
+// RevokeAllSessions revokes every session of a user
+func (r *Repository) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.revokeSessions(ctx, "user_id = ?", userID)
+	return err
+}
+
+// revokeSessions revokes the active sessions matching the condition and their refresh token families
+func (r *Repository) revokeSessions(ctx context.Context, query string, args ...interface{}) (int, error) {
+	var revoked int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sessions []model.Session
+		if err := tx.Where(query, args...).Where("revoked_at IS NULL").Find(&sessions).Error; err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(sessions))
+		families := make([]uuid.UUID, 0, len(sessions))
+		for _, s := range sessions {
+			ids = append(ids, s.ID)
+			families = append(families, s.FamilyID)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.Session{}).Where("id IN ?", ids).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		revoked = len(sessions)
+		return tx.Model(&model.RefreshToken{}).
+			Where("family_id IN ? AND revoked_at IS NULL", families).
+			Update("revoked_at", now).Error
+	})
+	return revoked, err
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// Tenant Operations
+func (r *Repository) CreateTenant(ctx context.Context, tenant *model.Tenant) error {
+	return r.db.WithContext(ctx).Create(tenant).Error
+}
+
+func (r *Repository) GetTenantByID(ctx context.Context, id uuid.UUID) (*model.Tenant, error) {
+	var tenant model.Tenant
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *Repository) GetTenantBySlug(ctx context.Context, slug string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *Repository) GetTenantByHost(ctx context.Context, host string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	err := r.db.WithContext(ctx).Where("host = ?", host).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *Repository) ListTenants(ctx context.Context) ([]model.Tenant, error) {
+	var tenants []model.Tenant
+	err := r.db.WithContext(ctx).Order("slug ASC").Find(&tenants).Error
+	return tenants, err
+}
+
+// SaveTenant writes every field of the tenant, settings included
+func (r *Repository) SaveTenant(ctx context.Context, tenant *model.Tenant) error {
+	return r.db.WithContext(ctx).Save(tenant).Error
+}
+
+func (r *Repository) DeleteTenant(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Tenant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// paginate is a scope selecting the page, the page is expected to be normalized
+func paginate(page model.Pagination) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset((page.Page - 1) * page.PerPage).Limit(page.PerPage)
+	}
+}
+
+// filterUsers is a scope applying the admin listing filter
+func filterUsers(filter model.UserFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Deleted {
+			db = db.Unscoped().Where("users.deleted_at IS NOT NULL")
+		}
+		if filter.Email != "" {
+			// LIKE wildcards in the input are matched literally
+			escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Email)
+			db = db.Where("users.email ILIKE ?", "%"+escaped+"%")
+		}
+		if filter.Role != "" {
+			db = db.Where("users.role = ? OR users.id IN (?)", filter.Role,
+				db.Session(&gorm.Session{NewDB: true}).Table("user_roles").
+					Select("user_roles.user_id").
+					Joins("JOIN roles ON roles.id = user_roles.role_id").
+					Where("roles.name = ?", filter.Role))
+		}
+		if filter.EmailVerified != nil {
+			db = db.Where("users.is_email_verified = ?", *filter.EmailVerified)
+		}
+		if filter.PhoneVerified != nil {
+			db = db.Where("users.is_phone_verified = ?", *filter.PhoneVerified)
+		}
+		if filter.SignUpMethod != "" {
+			db = db.Where("users.sign_up_method = ?", filter.SignUpMethod)
+		}
+		if filter.Status != "" {
+			db = db.Where("users.status = ?", filter.Status)
+		}
+		if filter.CreatedAfter != nil {
+			db = db.Where("users.created_at >= ?", *filter.CreatedAfter)
+		}
+		if filter.CreatedBefore != nil {
+			db = db.Where("users.created_at < ?", *filter.CreatedBefore)
+		}
+		return db
+	}
+}
+
+// ListUsers returns a page of the users matching the filter, newest first, and how many match in total
+func (r *Repository) ListUsers(ctx context.Context, filter model.UserFilter, page model.Pagination) ([]model.User, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&model.User{}).Scopes(filterUsers(filter)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	users := []model.User{}
+	err := r.db.WithContext(ctx).Model(&model.User{}).Scopes(filterUsers(filter), paginate(page)).
+		Order("users.created_at DESC").Order("users.id").
+		Find(&users).Error
+	return users, total, err
+}
+
+// GetUserByIDUnscoped finds the user even when soft deleted
+func (r *Repository) GetUserByIDUnscoped(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	var user model.User
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RestoreUser undoes a soft delete
+func (r *Repository) RestoreUser(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
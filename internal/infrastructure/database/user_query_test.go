@@ -0,0 +1,68 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// listUsersSQL renders the listing query for filter and page without a database
+func listUsersSQL(t *testing.T, filter model.UserFilter, page model.Pagination) string {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		users := []model.User{}
+		return tx.Model(&model.User{}).Scopes(filterUsers(filter), paginate(page)).Find(&users)
+	})
+}
+
+func TestFilterUsers(t *testing.T) {
+	verified := true
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  model.UserFilter
+		want    []string
+		notWant []string
+	}{
+		{"no filter", model.UserFilter{}, []string{`"users"."deleted_at" IS NULL`}, []string{"ILIKE", "user_roles"}},
+		{"email wildcards escaped", model.UserFilter{Email: `50%_off\`}, []string{`users.email ILIKE '%50\%\_off\\%'`}, nil},
+		{"role held or assigned", model.UserFilter{Role: "editor"}, []string{"users.role = 'editor' OR users.id IN (SELECT user_roles.user_id FROM", "roles.name = 'editor'"}, nil},
+		{"verification and method", model.UserFilter{EmailVerified: &verified, SignUpMethod: "google"}, []string{"users.is_email_verified = true", "users.sign_up_method = 'google'"}, []string{"is_phone_verified"}},
+		{"created range", model.UserFilter{CreatedAfter: &after, CreatedBefore: &after}, []string{"users.created_at >= '2024-01-01", "users.created_at < '2024-01-01"}, nil},
+		{"deleted only", model.UserFilter{Deleted: true}, []string{"users.deleted_at IS NOT NULL"}, []string{`"users"."deleted_at" IS NULL`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql := listUsersSQL(t, tt.filter, model.Pagination{Page: 1, PerPage: 20})
+			for _, want := range tt.want {
+				if !strings.Contains(sql, want) {
+					t.Errorf("%s\nmissing %s", sql, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(sql, notWant) {
+					t.Errorf("%s\nshouldn't contain %s", sql, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	if sql := listUsersSQL(t, model.UserFilter{}, model.Pagination{Page: 3, PerPage: 25}); !strings.HasSuffix(sql, "LIMIT 25 OFFSET 50") {
+		t.Errorf("%s\nwant the third page of 25", sql)
+	}
+	if sql := listUsersSQL(t, model.UserFilter{}, model.Pagination{Page: 1, PerPage: 25}); !strings.HasSuffix(sql, "LIMIT 25") {
+		t.Errorf("%s\nwant the first page of 25", sql)
+	}
+}
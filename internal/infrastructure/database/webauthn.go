@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"gorm.io/gorm"
+)
+
+// WebAuthn Credential Operations
+func (r *Repository) CreateWebAuthnCredential(ctx context.Context, credential *model.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+func (r *Repository) ListWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error) {
+	var credentials []model.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *Repository) GetWebAuthnCredentialByCredentialID(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error) {
+	var credential model.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("credential_id = ?", credentialID).First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// UpdateWebAuthnCredentialUsage stores the sign counter and flags reported by the last successful assertion
+func (r *Repository) UpdateWebAuthnCredentialUsage(ctx context.Context, id uuid.UUID, signCount uint32, backupState bool) error {
+	return r.db.WithContext(ctx).Model(&model.WebAuthnCredential{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sign_count":   signCount,
+			"backup_state": backupState,
+			"last_used_at": time.Now(),
+		}).Error
+}
+
+func (r *Repository) FlagWebAuthnCredentialCloned(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.WebAuthnCredential{}).Where("id = ?", id).Update("clone_warning", true).Error
+}
+
+func (r *Repository) DeleteWebAuthnCredential(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND id = ?", userID, id).Delete(&model.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
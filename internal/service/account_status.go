@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// CheckAccountStatus returns why the account can't be used right now, nil when it is active
+func (s *AuthServiceImpl) CheckAccountStatus(user *model.User) error {
+	switch user.EffectiveStatus(time.Now()) {
+	case model.UserStatusSuspended:
+		return ErrAccountSuspended
+	case model.UserStatusBanned:
+		return ErrAccountBanned
+	case model.UserStatusPendingDeletion:
+		return ErrAccountPendingDeletion
+	}
+	return nil
+}
+
+// SetUserStatus changes the account state and tells the user by email. Suspensions need an end in the future,
+// leaving the active state revokes every session so refresh tokens die with it.
+func (s *AuthServiceImpl) SetUserStatus(ctx context.Context, adminID, userID, status, reason string, until *time.Time) (*model.User, error) {
+	if !model.IsUserStatus(status) {
+		return nil, ErrInvalidStatus
+	}
+	if status == model.UserStatusSuspended && (until == nil || !until.After(time.Now())) {
+		return nil, ErrInvalidStatus
+	}
+	if status != model.UserStatusSuspended {
+		until = nil
+	}
+	if adminID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previous := user.EffectiveStatus(now)
+	var reasonPtr *string
+	if reason != "" {
+		reasonPtr = &reason
+	}
+	var actor *uuid.UUID
+	if uid, err := uuid.Parse(adminID); err == nil {
+		actor = &uid
+	}
+	if err := s.repo.UpdateUser(ctx, *user.ID, map[string]interface{}{
+		"status":            status,
+		"status_reason":     reasonPtr,
+		"status_changed_by": actor,
+		"status_changed_at": now,
+		"suspended_until":   until,
+	}); err != nil {
+		return nil, err
+	}
+	user.Status = status
+	user.StatusReason = reasonPtr
+	user.StatusChangedBy = actor
+	user.StatusChangedAt = &now
+	user.SuspendedUntil = until
+
+	if status != model.UserStatusActive {
+		if err := s.repo.RevokeAllSessions(ctx, *user.ID); err != nil {
+			return nil, err
+		}
+	}
+	metadata := map[string]string{"old_status": previous, "new_status": status, "reason": reason}
+	if until != nil {
+		metadata["until"] = until.UTC().Format(time.RFC3339)
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventUserStatusChanged, user.ID, metadata)
+
+	if user.Email != nil && *user.Email != "" {
+		if err := s.emailService.SendAccountStatusEmail(ctx, *user.Email, derefString(user.Name), status, reason, until); err != nil {
+			logrus.Errorln("Failed to send account status email : ", err)
+		}
+	}
+	return user, nil
+}
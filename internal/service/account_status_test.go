@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestCheckAccountStatus(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	later, earlier := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		user model.User
+		want error
+	}{
+		{"active", model.User{Status: model.UserStatusActive}, nil},
+		{"suspended", model.User{Status: model.UserStatusSuspended, SuspendedUntil: &later}, ErrAccountSuspended},
+		{"suspension over", model.User{Status: model.UserStatusSuspended, SuspendedUntil: &earlier}, nil},
+		{"banned", model.User{Status: model.UserStatusBanned}, ErrAccountBanned},
+		{"pending deletion", model.User{Status: model.UserStatusPendingDeletion}, ErrAccountPendingDeletion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.CheckAccountStatus(&tt.user); err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+
+			// Every sign in method issues its tokens through GenerateTokens, which refuses before opening a session
+			if tt.want == nil {
+				return
+			}
+			id := uuid.New()
+			tt.user.ID = &id
+			if _, _, err := s.GenerateTokens(context.Background(), &tt.user); err != tt.want {
+				t.Errorf("GenerateTokens err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetUserStatusValidated(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	ctx := context.Background()
+	adminID := uuid.NewString()
+	later, earlier := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		userID string
+		status string
+		until  *time.Time
+		want   error
+	}{
+		{"unknown status", uuid.NewString(), "deleted", nil, ErrInvalidStatus},
+		{"suspended without an end", uuid.NewString(), model.UserStatusSuspended, nil, ErrInvalidStatus},
+		{"suspended into the past", uuid.NewString(), model.UserStatusSuspended, &earlier, ErrInvalidStatus},
+		{"ban self", adminID, model.UserStatusBanned, nil, ErrCannotModifySelf},
+		{"suspend self", adminID, model.UserStatusSuspended, &later, ErrCannotModifySelf},
+		{"malformed user", "jane", model.UserStatusBanned, nil, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.SetUserStatus(ctx, adminID, tt.userID, tt.status, "spam", tt.until); err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetUserStatusAudited(t *testing.T) {
+	adminID, userID := uuid.New(), uuid.New()
+	until := time.Now().Add(time.Hour)
+	repo, db := newFakeRepo(t, &model.User{ID: &userID, Status: model.UserStatusActive})
+	s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig()}
+
+	if _, err := s.SetUserStatus(context.Background(), adminID.String(), userID.String(), model.UserStatusSuspended, "chargebacks", &until); err != nil {
+		t.Fatal(err)
+	}
+	logs := db.logs()
+	if len(logs) != 1 {
+		t.Fatalf("%d audit entries, want 1", len(logs))
+	}
+	entry := logs[0]
+	if entry.Event != model.LogEventUserStatusChanged || entry.UserId != adminID.String() || entry.TargetUserId != userID.String() {
+		t.Errorf("audit entry = %+v, want the status change by %s on %s", entry, adminID, userID)
+	}
+	want := map[string]string{
+		"old_status": model.UserStatusActive,
+		"new_status": model.UserStatusSuspended,
+		"reason":     "chargebacks",
+		"until":      until.UTC().Format(time.RFC3339),
+	}
+	if !reflect.DeepEqual(entry.Metadata, want) {
+		t.Errorf("metadata = %v, want %v", entry.Metadata, want)
+	}
+}
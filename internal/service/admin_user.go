@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// ListUsers returns a page of the users matching the filter
+func (s *AuthServiceImpl) ListUsers(ctx context.Context, filter model.UserFilter, page model.Pagination) (*model.UserPage, error) {
+	page = page.Normalized()
+	users, total, err := s.repo.ListUsers(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UserPage{
+		Users:   users,
+		Total:   total,
+		Page:    page.Page,
+		PerPage: page.PerPage,
+	}, nil
+}
+
+// GetUser returns the user, soft deleted ones included
+func (s *AuthServiceImpl) GetUser(ctx context.Context, userID string) (*model.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.repo.GetUserByIDUnscoped(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// AdminCreateUser creates an account regardless of sign up being disabled. Unverified addresses get the usual
+// verification email and accounts without a password a reset link, failing to send either doesn't undo the account.
+func (s *AuthServiceImpl) AdminCreateUser(ctx context.Context, adminID string, form model.AdminUserForm) (*model.User, error) {
+	role := form.Role
+	if role == "" {
+		role = "user"
+	}
+	if !roleNamePattern.MatchString(role) {
+		return nil, ErrInvalidName
+	}
+
+	exists, err := s.repo.UserExists(ctx, form.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUserAlreadyExists
+	}
+
+	if form.Password != "" {
+		candidate := &model.User{Name: &form.Name, Email: &form.Email, TenantID: tenantIDFromContext(ctx)}
+		if err := s.checkPassword(ctx, candidate, "password", form.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	password := form.Password
+	if password == "" {
+		// Nobody knows it, the user picks a real one through the reset link
+		if password, err = utils.GenerateRandomToken(32); err != nil {
+			return nil, err
+		}
+	}
+	hashedPassword, err := utils.EncryptPassword(password, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Name:            &form.Name,
+		Email:           &form.Email,
+		Password:        &hashedPassword,
+		Role:            role,
+		SignUpMethod:    enum.AdminSignUp,
+		IsEmailVerified: form.EmailVerified,
+		TenantID:        tenantIDFromContext(ctx),
+	}
+	if form.Phone != "" {
+		user.Phone = &form.Phone
+	}
+	if form.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	if form.Password != "" {
+		s.rememberPassword(ctx, user, hashedPassword)
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventUserCreated, user.ID, map[string]string{"role": role})
+
+	if !form.EmailVerified {
+		if err := s.SendVerificationEmail(ctx, user); err != nil {
+			logrus.Errorln("Failed to send verification email to new user : ", err)
+		}
+	}
+	if form.Password == "" {
+		if err := s.sendPasswordReset(ctx, user); err != nil {
+			logrus.Errorln("Failed to send password reset to new user : ", err)
+		}
+	}
+	return user, nil
+}
+
+// UpdateUserRole changes the user's primary role, admins can't change their own
+func (s *AuthServiceImpl) UpdateUserRole(ctx context.Context, adminID, userID, role string) (*model.User, error) {
+	if !roleNamePattern.MatchString(role) {
+		return nil, ErrInvalidName
+	}
+	if adminID == userID {
+		return nil, ErrCannotModifySelf
+	}
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateUser(ctx, *user.ID, map[string]interface{}{"role": role}); err != nil {
+		return nil, err
+	}
+	previous := user.Role
+	user.Role = role
+	s.auditAdminChange(ctx, adminID, model.LogEventUserRoleChanged, user.ID, map[string]string{"old_role": previous, "new_role": role})
+	return user, nil
+}
+
+// VerifyUser marks the user's email and/or phone verified without a code
+func (s *AuthServiceImpl) VerifyUser(ctx context.Context, adminID, userID string, email, phone bool) (*model.User, error) {
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if (email && derefString(user.Email) == "") || (phone && derefString(user.Phone) == "") {
+		return nil, ErrNoContact
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	verified := map[string]string{}
+	if email && !user.IsEmailVerified {
+		verified["email"] = derefString(user.Email)
+		updates["is_email_verified"] = true
+		updates["email_verified_at"] = now
+		user.IsEmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+	if phone && !user.IsPhoneVerified {
+		verified["phone"] = derefString(user.Phone)
+		updates["is_phone_verified"] = true
+		updates["phone_verified_at"] = now
+		user.IsPhoneVerified = true
+		user.PhoneVerifiedAt = &now
+	}
+	if len(updates) == 0 {
+		return user, nil
+	}
+
+	if err := s.repo.UpdateUser(ctx, *user.ID, updates); err != nil {
+		return nil, err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventUserVerified, user.ID, verified)
+	return user, nil
+}
+
+// SendUserPasswordReset emails the user a password reset link
+func (s *AuthServiceImpl) SendUserPasswordReset(ctx context.Context, adminID, userID string) error {
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if derefString(user.Email) == "" {
+		return ErrNoContact
+	}
+
+	if err := s.sendPasswordReset(ctx, user); err != nil {
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventUserPasswordResetSent, user.ID, nil)
+	return nil
+}
+
+// DeleteUser soft deletes the user and signs them out everywhere, the account can be restored
+func (s *AuthServiceImpl) DeleteUser(ctx context.Context, adminID, userID string) error {
+	if adminID == userID {
+		return ErrCannotModifySelf
+	}
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteUser(ctx, *user.ID); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeAllSessions(ctx, *user.ID); err != nil {
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventUserDeleted, user.ID, nil)
+	return nil
+}
+
+// RestoreUser brings back a soft deleted user, their old sessions stay revoked
+func (s *AuthServiceImpl) RestoreUser(ctx context.Context, adminID, userID string) (*model.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if err := s.repo.RestoreUser(ctx, uid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventUserRestored, &uid, nil)
+	return s.repo.GetUserByID(ctx, uid)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestAdminUserInputValidated(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	ctx := context.Background()
+	adminID := uuid.NewString()
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"create with a bad role", func() error {
+			_, err := s.AdminCreateUser(ctx, adminID, model.AdminUserForm{Name: "Jane", Email: "jane@example.com", Role: "team lead"})
+			return err
+		}, ErrInvalidName},
+		{"view a malformed user", func() error { _, err := s.GetUser(ctx, "jane"); return err }, ErrUserNotFound},
+		{"role with bad characters", func() error { _, err := s.UpdateUserRole(ctx, adminID, uuid.NewString(), "admin/root"); return err }, ErrInvalidName},
+		{"own role", func() error { _, err := s.UpdateUserRole(ctx, adminID, adminID, "user"); return err }, ErrCannotModifySelf},
+		{"role of a malformed user", func() error { _, err := s.UpdateUserRole(ctx, adminID, "jane", "user"); return err }, ErrUserNotFound},
+		{"verify a malformed user", func() error { _, err := s.VerifyUser(ctx, adminID, "jane", true, false); return err }, ErrUserNotFound},
+		{"reset a malformed user", func() error { return s.SendUserPasswordReset(ctx, adminID, "jane") }, ErrUserNotFound},
+		{"delete self", func() error { return s.DeleteUser(ctx, adminID, adminID) }, ErrCannotModifySelf},
+		{"delete a malformed user", func() error { return s.DeleteUser(ctx, adminID, "jane") }, ErrUserNotFound},
+		{"restore a malformed user", func() error { _, err := s.RestoreUser(ctx, adminID, "jane"); return err }, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminUserActionsAuditTarget(t *testing.T) {
+	adminID, userID := uuid.New(), uuid.New()
+	email, phone := "jane@example.com", "+15550100"
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		call     func(s *AuthServiceImpl) error
+		event    string
+		metadata map[string]string
+		deleted  bool
+	}{
+		{"role change", func(s *AuthServiceImpl) error {
+			_, err := s.UpdateUserRole(ctx, adminID.String(), userID.String(), "editor")
+			return err
+		}, model.LogEventUserRoleChanged, map[string]string{"old_role": "user", "new_role": "editor"}, false},
+		{"verification", func(s *AuthServiceImpl) error {
+			_, err := s.VerifyUser(ctx, adminID.String(), userID.String(), true, true)
+			return err
+		}, model.LogEventUserVerified, map[string]string{"email": email, "phone": phone}, false},
+		{"deletion", func(s *AuthServiceImpl) error { return s.DeleteUser(ctx, adminID.String(), userID.String()) }, model.LogEventUserDeleted, nil, false},
+		{"restore", func(s *AuthServiceImpl) error {
+			_, err := s.RestoreUser(ctx, adminID.String(), userID.String())
+			return err
+		}, model.LogEventUserRestored, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &model.User{ID: &userID, Email: &email, Phone: &phone, Role: "user"}
+			if tt.deleted {
+				user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			}
+			repo, db := newFakeRepo(t, user)
+			s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig()}
+			if err := tt.call(s); err != nil {
+				t.Fatal(err)
+			}
+
+			logs := db.logs()
+			if len(logs) != 1 {
+				t.Fatalf("%d audit entries, want 1", len(logs))
+			}
+			entry := logs[0]
+			if entry.Event != tt.event || entry.UserId != adminID.String() || entry.TargetUserId != userID.String() {
+				t.Errorf("audit entry = %+v, want %s by %s on %s", entry, tt.event, adminID, userID)
+			}
+			if !reflect.DeepEqual(entry.Metadata, tt.metadata) {
+				t.Errorf("metadata = %v, want %v", entry.Metadata, tt.metadata)
+			}
+		})
+	}
+}
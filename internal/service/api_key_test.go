@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestNarrowAPIKeyPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		owned   []string
+		want    []string
+	}{
+		{"all still owned", []string{"users:read", "reports:read"}, []string{"users:read", "reports:read", "users:write"}, []string{"users:read", "reports:read"}},
+		{"owner lost one", []string{"users:read", "users:write"}, []string{"users:read"}, []string{"users:read"}},
+		{"owner lost all", []string{"users:write"}, nil, []string{}},
+		{"key without permissions", nil, []string{"users:read"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := narrowAPIKeyPermissions(tt.granted, tt.owned); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("narrowAPIKeyPermissions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKeyRejectsForeignKeys(t *testing.T) {
+	s := &AuthServiceImpl{}
+	for _, key := range []string{"", "not-a-key", "SAK_abc", "Bearer sak_abc"} {
+		if _, err := s.AuthenticateAPIKey(context.Background(), key); err != ErrInvalidToken {
+			t.Errorf("AuthenticateAPIKey(%q) = %v, want %v", key, err, ErrInvalidToken)
+		}
+	}
+}
@@ -2,14 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
 	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/auth/oauth"
 	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
 	"github.com/minilikmila/standard-auth-go/internal/infrastructure/database"
 	"github.com/minilikmila/standard-auth-go/pkg/utils"
 )
@@ -21,6 +25,9 @@ type AuthServiceImpl struct {
 	emailService EmailService
 	smsService   SMSService
 	jwtService   JWTService
+	webAuthn     *webauthn.WebAuthn
+	oauth        map[string]oauth.Provider
+	tenants      *tenantCache
 }
 
 // NewAuthService creates a new instance of AuthService
@@ -31,11 +38,18 @@ func NewAuthService(repo *database.Repository, config *config.Config, emailServi
 		emailService: emailService,
 		smsService:   smsService,
 		jwtService:   jwtService,
+		webAuthn:     newWebAuthn(config),
+		oauth:        newOAuthProviders(config),
+		tenants:      newTenantCache(),
 	}
 }
 
 // CreateUser implements user creation
 func (s *AuthServiceImpl) CreateUser(ctx context.Context, user *model.User) error {
+	if tenantConfig(ctx, s.config).DisableSignup {
+		return ErrSignupDisabled
+	}
+
 	// Check if user already exists
 	exists, err := s.repo.UserExists(ctx, *user.Email)
 	if err != nil {
@@ -45,6 +59,12 @@ func (s *AuthServiceImpl) CreateUser(ctx context.Context, user *model.User) erro
 		return ErrUserAlreadyExists
 	}
 
+	// Bound to the tenant it signs up under, that tenant's policies apply from here on
+	user.TenantID = tenantIDFromContext(ctx)
+	if err := s.checkPassword(ctx, user, "password", derefString(user.Password)); err != nil {
+		return err
+	}
+
 	// Hash password
 	hashedPassword, err := utils.EncryptPassword(*user.Password, 10)
 	if err != nil {
@@ -53,7 +73,11 @@ func (s *AuthServiceImpl) CreateUser(ctx context.Context, user *model.User) erro
 	user.Password = &hashedPassword
 
 	// Create user
-	return s.repo.CreateUser(ctx, user)
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	s.rememberPassword(ctx, user, hashedPassword)
+	return nil
 }
 
 // UserExists checks if a user exists
@@ -61,52 +85,81 @@ func (s *AuthServiceImpl) UserExists(ctx context.Context, email string) (bool, e
 	return s.repo.UserExists(ctx, email)
 }
 
-// Login implements user login
-func (s *AuthServiceImpl) Login(ctx context.Context, email, password string) (*model.User, error) {
+// Login implements user login. When the account has (or must set up) a second factor it stops short of
+// completing the login and returns an MFA pending token to be exchanged through VerifyMFA. Nothing about the
+// account is revealed before the password is checked: unknown emails and locked accounts cost a password
+// comparison too, and the verification state is only looked at once the password is right.
+func (s *AuthServiceImpl) Login(ctx context.Context, email, password string) (*model.User, string, error) {
 	//
 	// Get user by email
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return nil, ErrInvalidCredentials
+		utils.ComparePassword(dummyPasswordHash(), password)
+		return nil, "", ErrInvalidCredentials
+	}
+
+	// The password isn't looked at while locked, guesses must not be confirmed
+	if user.IsLocked(time.Now()) {
+		utils.ComparePassword(dummyPasswordHash(), password)
+		return nil, "", ErrTooManyAttempts
+	}
+
+	// Verify password
+	if user.Password == nil || !utils.ComparePassword(*user.Password, password) {
+		// Increment incorrect login attempts, locking the account past the policy's threshold
+		if err := s.recordFailedLogin(ctx, user); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrInvalidCredentials
 	}
 
+	// Only told to someone who knows the password
 	isEmailVerified, err := s.repo.IsEmailVerified(ctx, *user.ID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	if !user.IsEmailVerified || !isEmailVerified {
-		return nil, ErrEmailNotVerified
+		return nil, "", ErrEmailNotVerified
 	}
 
-	// Verify password
-	if !utils.ComparePassword(*user.Password, password) {
-		// Increment incorrect login attempts
-		if err := s.repo.IncrementLoginAttempts(ctx, *user.ID); err != nil {
-			return nil, err
-		}
-		return nil, ErrInvalidCredentials
+	if err := s.CheckAccountStatus(user); err != nil {
+		return nil, "", err
 	}
 
 	// Reset incorrect login attempts
 	if err := s.repo.ResetLoginAttempts(ctx, *user.ID); err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	// Second step
+	if user.MFAEnabled || s.mfaRequired(ctx, user) {
+		mfaToken, err := s.createMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, "", err
+		}
+		return user, mfaToken, nil
 	}
 
 	// Update last login time
 	if err := s.repo.UpdateLastLogin(ctx, *user.ID); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return user, nil
+	return user, "", nil
 }
 
-// Logout implements user logout
+// Logout implements user logout. The access token is blacklisted and its session revoked, which revokes the
+// session's refresh token family too.
 func (s *AuthServiceImpl) Logout(ctx context.Context, token string) error {
+	if info, err := s.jwtService.InspectToken(token); err == nil && info.SessionID != nil {
+		if err := s.repo.RevokeSession(ctx, info.UserID, *info.SessionID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+
 	// Add token to blacklist and hashed it for security and compromising traits
-	hashedToken := utils.HashToken(token)
-	logrus.Infoln("Hashed token to logout : ", hashedToken)
-	return s.repo.BlacklistToken(ctx, hashedToken)
+	return s.repo.BlacklistToken(ctx, utils.HashToken(token))
 }
 func derefString(s *string) string {
 	if s != nil {
@@ -115,37 +168,83 @@ func derefString(s *string) string {
 	return ""
 }
 
-// GenerateTokens generates access and refresh tokens
+// GenerateTokens opens a new session for the requesting device and issues its first access/refresh pair
 func (s *AuthServiceImpl) GenerateTokens(ctx context.Context, user *model.User) (string, string, error) {
-	// Check if email is verified
-	if !user.IsEmailVerified {
+	// Every sign in method ends here
+	if err := s.CheckAccountStatus(user); err != nil {
+		return "", "", err
+	}
+	// A lockout holds for magic links, codes and social logins too, not only for the password
+	if user.IsLocked(time.Now()) {
+		return "", "", ErrAccountLocked
+	}
+
+	// Check the account has at least one verified contact, phone-only accounts sign in by SMS
+	if !user.IsEmailVerified && !user.IsPhoneVerified {
 		return "", "", ErrEmailNotVerified
 	}
 
-	metadata := map[string]string{
-		"userId":          user.ID.String(),
-		"email":           derefString(user.Email),
-		"phone_number":    derefString(user.Phone),
-		"profile_picture": derefString(user.ProfilePicture),
-		"signup_method":   user.SignUpMethod,
-		"role":            user.Role,
-		"ip":              "",
+	session, err := s.createSession(ctx, user)
+	if err != nil {
+		return "", "", err
+	}
+
+	return s.issueTokens(ctx, user, session, nil)
+}
+
+// issueTokens mints an access/refresh pair for the session and persists the hashed refresh token in its family
+func (s *AuthServiceImpl) issueTokens(ctx context.Context, user *model.User, session *model.Session, parentID *uuid.UUID) (string, string, error) {
+	cfg, err := s.userConfig(ctx, user)
+	if err != nil {
+		return "", "", err
 	}
+
 	// Generate access token
-	accessToken, err := s.jwtService.GenerateToken(user, metadata)
+	accessToken, err := s.generateAccessToken(ctx, user, session)
 	if err != nil {
 		return "", "", err
 	}
 
 	// Generate refresh token
-	refreshToken, err := s.jwtService.GenerateRefreshToken(user)
+	refreshToken, err := s.jwtService.GenerateRefreshToken(user, session.ID, cfg.JWT.RefreshExp)
 	if err != nil {
 		return "", "", err
 	}
 
+	// Only the hash is stored, the raw token never touches the database
+	if err := s.repo.CreateRefreshToken(ctx, &model.RefreshToken{
+		UserID:    *user.ID,
+		FamilyID:  session.FamilyID,
+		ParentID:  parentID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(time.Duration(cfg.JWT.RefreshExp) * time.Minute),
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return "", "", err
+	}
+
 	return accessToken, refreshToken, nil
 }
 
+// generateAccessToken mints an access token for the session, scoped to the organization it is switched to and
+// living as long as the user's own tenant allows, whatever tenant the request names
+func (s *AuthServiceImpl) generateAccessToken(ctx context.Context, user *model.User, session *model.Session) (string, error) {
+	cfg, err := s.userConfig(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	metadata := map[string]string{
+		"userId":          user.ID.String(),
+		"email":           derefString(user.Email),
+		"phone_number":    derefString(user.Phone),
+		"profile_picture": derefString(user.ProfilePicture),
+		"signup_method":   user.SignUpMethod,
+		"role":            user.Role,
+		"ip":              session.IPAddress,
+	}
+	return s.jwtService.GenerateToken(user, session.ID, session.OrganizationID, cfg.JWT.Exp, metadata)
+}
+
 // SendVerificationEmail sends email verification
 func (s *AuthServiceImpl) SendVerificationEmail(ctx context.Context, user *model.User) error {
 	// Generate verification token
@@ -283,6 +382,11 @@ func (s *AuthServiceImpl) ForgotPassword(ctx context.Context, email string) erro
 	if err != nil {
 		return ErrUserNotFound
 	}
+	return s.sendPasswordReset(ctx, user)
+}
+
+// sendPasswordReset emails the user a one hour password reset link
+func (s *AuthServiceImpl) sendPasswordReset(ctx context.Context, user *model.User) error {
 	token, err := s.jwtService.GeneratePasswordResetToken(user)
 	if err != nil {
 		return err
@@ -318,6 +422,14 @@ func (s *AuthServiceImpl) ResetPassword(ctx context.Context, token, newPassword
 		return ErrInvalidToken
 	}
 
+	user, err := s.repo.GetUserByID(ctx, verification.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err := s.checkPassword(ctx, user, "new_password", newPassword); err != nil {
+		return err
+	}
+
 	hashedPassword, err := utils.EncryptPassword(newPassword, 10)
 	if err != nil {
 		return err
@@ -352,7 +464,10 @@ func (s *AuthServiceImpl) ResetPassword(ctx context.Context, token, newPassword
 	if err != nil {
 		return err
 	}
-	return nil
+	s.rememberPassword(ctx, user, hashedPassword)
+
+	// Sign out everywhere, whoever held the old password may still hold a session
+	return s.repo.RevokeAllSessions(ctx, verification.UserID)
 }
 
 // UpdatePassword changes the user's password after verifying the current password
@@ -365,17 +480,27 @@ func (s *AuthServiceImpl) UpdatePassword(ctx context.Context, userID string, cur
 	if err != nil {
 		return ErrUserNotFound
 	}
-	if !utils.ComparePassword(*user.Password, currentPassword) {
+	if user.Password == nil || !utils.ComparePassword(*user.Password, currentPassword) {
 		return ErrInvalidCredentials
 	}
+	if err := s.checkPassword(ctx, user, "new_password", newPassword); err != nil {
+		return err
+	}
 	hashedPassword, err := utils.EncryptPassword(newPassword, 10)
 	if err != nil {
 		return err
 	}
-	return s.repo.UpdatePassword(ctx, uid, hashedPassword)
+	if err := s.repo.UpdatePassword(ctx, uid, hashedPassword); err != nil {
+		return err
+	}
+	s.rememberPassword(ctx, user, hashedPassword)
+
+	// Sign out everywhere, existing sessions were opened with the old password
+	return s.repo.RevokeAllSessions(ctx, uid)
 }
 
-// RefreshToken implements token refresh
+// RefreshToken rotates a refresh token: the presented token is consumed and a new one is issued in the same family.
+// Presenting a token that was already used (or revoked) revokes the whole family.
 func (s *AuthServiceImpl) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
 	// Validate refresh token
 	userID, err := s.jwtService.ValidateRefreshToken(refreshToken)
@@ -383,37 +508,157 @@ func (s *AuthServiceImpl) RefreshToken(ctx context.Context, refreshToken string)
 		return "", "", ErrInvalidToken
 	}
 
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil || stored.UserID != userID {
+		return "", "", ErrInvalidToken
+	}
+
+	if err := checkRefreshToken(stored); err != nil {
+		if err == ErrTokenReused {
+			logrus.Warnln("Refresh token reuse detected, revoking family : ", stored.FamilyID)
+			if err := s.revokeReusedFamily(ctx, stored); err != nil {
+				return "", "", err
+			}
+		}
+		return "", "", err
+	}
+
+	// Consume the token, losing a concurrent race counts as reuse
+	consumed, err := s.repo.MarkRefreshTokenUsed(ctx, stored.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if !consumed {
+		if err := s.revokeReusedFamily(ctx, stored); err != nil {
+			return "", "", err
+		}
+		return "", "", ErrTokenReused
+	}
+
+	session, err := s.repo.GetSessionByFamily(ctx, stored.FamilyID)
+	if err != nil || session.RevokedAt != nil {
+		return "", "", ErrSessionRevoked
+	}
+	if err := s.repo.TouchSession(ctx, session.ID); err != nil {
+		logrus.Errorln("Failed to update session activity : ", err)
+	}
+
 	// Get user
 	user, err := s.repo.GetUserByID(ctx, userID)
 	if err != nil {
 		return "", "", ErrUserNotFound
 	}
+	if err := s.CheckAccountStatus(user); err != nil {
+		return "", "", err
+	}
 
 	// Generate new tokens
-	return s.GenerateTokens(ctx, user)
+	return s.issueTokens(ctx, user, session, &stored.ID)
+}
+
+// revokeReusedFamily revokes every token of a reused refresh token's family and signs out its session, so the
+// access tokens already issued for it stop working too
+func (s *AuthServiceImpl) revokeReusedFamily(ctx context.Context, stored *model.RefreshToken) error {
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+	session, err := s.repo.GetSessionByFamily(ctx, stored.FamilyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Not found once revoked already
+	if err := s.repo.RevokeSession(ctx, session.UserID, session.ID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// checkRefreshToken tells whether a stored refresh token can be exchanged. A token already used or revoked is
+// reuse even once expired, a replayed old token must still take its family down.
+func checkRefreshToken(stored *model.RefreshToken) error {
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		return ErrTokenReused
+	}
+	if !stored.IsActive() {
+		return ErrTokenExpired
+	}
+	return nil
 }
 
 // ValidateToken validates access token
 func (s *AuthServiceImpl) ValidateToken(ctx context.Context, token string) (string, error) {
+	info, err := s.ValidateAccessToken(ctx, token)
+	if err != nil {
+		return "Invalid token", err
+	}
+	if info.UserID == uuid.Nil {
+		return "Invalid token", ErrInvalidToken
+	}
+	return info.UserID.String(), nil
+}
+
+// JWKS returns the public keys resource servers verify our tokens with
+func (s *AuthServiceImpl) JWKS() model.JWKSet {
+	return s.jwtService.JWKS()
+}
 
+// ValidateAccessToken validates a first-party access token against the blacklist and its session, or a client
+// credentials token whose client is still registered. Tokens OIDC clients got on behalf of a user are refused,
+// they are only good at the endpoints made for them.
+func (s *AuthServiceImpl) ValidateAccessToken(ctx context.Context, token string) (*model.TokenInfo, error) {
+	info, err := s.inspectAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if info.ClientID != "" {
+		if info.UserID != uuid.Nil {
+			return nil, ErrInvalidToken
+		}
+		client, err := s.repo.GetClientByClientID(ctx, info.ClientID)
+		if err != nil || !client.AllowsGrant(model.GrantClientCredentials) {
+			return nil, ErrInvalidToken
+		}
+	}
+	return info, nil
+}
+
+// inspectAccessToken validates any access token against the blacklist and its session
+func (s *AuthServiceImpl) inspectAccessToken(ctx context.Context, token string) (*model.TokenInfo, error) {
 	// compare hashed value
 	hashedToken := utils.HashToken(token)
 	// Check if token is blacklisted
 	isBlacklisted, err := s.repo.IsTokenBlacklisted(ctx, hashedToken)
 	if err != nil {
-		return "Invalid token", err
+		return nil, err
 	}
 	if isBlacklisted {
-		return "", ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
 	// Validate token
-	userID, err := s.jwtService.ValidateToken(token)
+	info, err := s.jwtService.InspectToken(token)
 	if err != nil {
-		return "Invalid token", err
+		return nil, err
+	}
+	if info.TokenType != enum.AccessToken {
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens tied to a session die with it
+	if info.SessionID != nil {
+		session, err := s.repo.GetSessionByID(ctx, *info.SessionID)
+		if err != nil || session.RevokedAt != nil {
+			return nil, ErrSessionRevoked
+		}
+		if err := s.repo.TouchSession(ctx, session.ID); err != nil {
+			logrus.Errorln("Failed to update session activity : ", err)
+		}
 	}
 
-	return userID.String(), nil
+	return info, nil
 }
 
 // UpdateProfile implements profile update
@@ -422,6 +667,10 @@ func (s *AuthServiceImpl) UpdateProfile(ctx context.Context, userID string, upda
 	if err != nil {
 		return err
 	}
+	// Second factor state only changes through the enrollment endpoints
+	for _, column := range []string{"mfa_enabled", "mfa_enabled_at", "totp_secret", "totp_last_step"} {
+		delete(updates, column)
+	}
 	return s.repo.UpdateUser(ctx, id, updates)
 }
 
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		token model.RefreshToken
+		want  error
+	}{
+		{"fresh", model.RefreshToken{ExpiresAt: future}, nil},
+		{"expired", model.RefreshToken{ExpiresAt: past}, ErrTokenExpired},
+		{"already used", model.RefreshToken{ExpiresAt: future, UsedAt: &past}, ErrTokenReused},
+		{"revoked", model.RefreshToken{ExpiresAt: future, RevokedAt: &past}, ErrTokenReused},
+		{"used and expired", model.RefreshToken{ExpiresAt: past, UsedAt: &past}, ErrTokenReused},
+		{"revoked and expired", model.RefreshToken{ExpiresAt: past, RevokedAt: &past}, ErrTokenReused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkRefreshToken(&tt.token); got != tt.want {
+				t.Errorf("checkRefreshToken = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"context"
 	"html/template"
 	"net/smtp"
+	"net/url"
+	"time"
 
 	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
 	"github.com/sirupsen/logrus"
 )
 
@@ -55,31 +58,114 @@ func (s *EmailServiceImpl) sendEmailFromTemplate(ctx context.Context, to, subjec
 	return nil
 }
 
+// branding returns the company name and app URL emails carry, the request's tenant may have its own
+func (s *EmailServiceImpl) branding(ctx context.Context) (string, string) {
+	cfg := tenantConfig(ctx, s.config)
+	return cfg.CompanyName, cfg.AppURL
+}
+
 func (s *EmailServiceImpl) SendVerificationEmail(ctx context.Context, email, token, receiverName string) error {
+	company, appURL := s.branding(ctx)
 	if receiverName == "" {
 		receiverName = "User"
 	}
 	data := map[string]interface{}{
 		"Name":            receiverName, // Replace with actual name if available
-		"VerificationURL": s.config.AppURL + "/verify?token=" + token,
-		"Company":         s.config.CompanyName,
+		"VerificationURL": appURL + "/verify?token=" + token,
+		"Company":         company,
 	}
 	return s.sendEmailFromTemplate(ctx, email, "Verify your email address", "templates/email_verification.html", data)
 }
 
 func (s *EmailServiceImpl) SendPasswordResetEmail(ctx context.Context, email, token string) error {
+	company, appURL := s.branding(ctx)
 	data := map[string]interface{}{
 		"Name":     "User", // Replace with actual name if available
-		"ResetURL": s.config.AppURL + "/reset-password?token=" + token,
-		"Company":  s.config.CompanyName,
+		"ResetURL": appURL + "/reset-password?token=" + token,
+		"Company":  company,
 	}
 	return s.sendEmailFromTemplate(ctx, email, "Reset your password", "templates/password_reset.html", data)
 }
 
 func (s *EmailServiceImpl) SendWelcomeEmail(ctx context.Context, email string, name string) error {
+	company, _ := s.branding(ctx)
 	data := map[string]interface{}{
 		"Name":    name,
-		"Company": s.config.CompanyName,
+		"Company": company,
+	}
+	return s.sendEmailFromTemplate(ctx, email, "Welcome to "+company, "templates/welcome.html", data)
+}
+
+func (s *EmailServiceImpl) SendMagicLinkEmail(ctx context.Context, email, token, receiverName string) error {
+	company, appURL := s.branding(ctx)
+	if receiverName == "" {
+		receiverName = "User"
+	}
+	data := map[string]interface{}{
+		"Name":      receiverName,
+		"SignInURL": appURL + "/magic-link?token=" + url.QueryEscape(token),
+		"ExpiresIn": int(s.config.MagicLinkExp),
+		"Company":   company,
+	}
+	return s.sendEmailFromTemplate(ctx, email, "Your sign in link", "templates/magic_link.html", data)
+}
+
+// SendOrganizationInviteEmail sends an invitation to join an organization
+func (s *EmailServiceImpl) SendOrganizationInviteEmail(ctx context.Context, email, token, organization, inviter, role string) error {
+	company, appURL := s.branding(ctx)
+	if inviter == "" {
+		inviter = "A member"
+	}
+	data := map[string]interface{}{
+		"Inviter":      inviter,
+		"Organization": organization,
+		"Role":         role,
+		"AcceptURL":    appURL + "/accept-invite?token=" + url.QueryEscape(token),
+		"ExpiresIn":    int(s.config.OrgInviteExpiry),
+		"Company":      company,
+	}
+	return s.sendEmailFromTemplate(ctx, email, "You are invited to join "+organization, "templates/org_invitation.html", data)
+}
+
+// accountStatusTitles are the subjects of account status notifications
+var accountStatusTitles = map[string]string{
+	model.UserStatusActive:          "Your account was reactivated",
+	model.UserStatusSuspended:       "Your account was suspended",
+	model.UserStatusBanned:          "Your account was banned",
+	model.UserStatusPendingDeletion: "Your account is scheduled for deletion",
+}
+
+// SendAccountStatusEmail tells the user an admin changed their account state
+func (s *EmailServiceImpl) SendAccountStatusEmail(ctx context.Context, email, receiverName, status, reason string, until *time.Time) error {
+	company, _ := s.branding(ctx)
+	if receiverName == "" {
+		receiverName = "User"
+	}
+	data := map[string]interface{}{
+		"Name":    receiverName,
+		"Title":   accountStatusTitles[status],
+		"Active":  status == model.UserStatusActive,
+		"Reason":  reason,
+		"Until":   "",
+		"Company": company,
+	}
+	if until != nil {
+		data["Until"] = until.UTC().Format("January 2, 2006 15:04 MST")
+	}
+	return s.sendEmailFromTemplate(ctx, email, accountStatusTitles[status], "templates/account_status.html", data)
+}
+
+// SendUnlockEmail tells the user their login got locked and links to lifting the lock
+func (s *EmailServiceImpl) SendUnlockEmail(ctx context.Context, email, token, receiverName string, until time.Time) error {
+	company, appURL := s.branding(ctx)
+	if receiverName == "" {
+		receiverName = "User"
+	}
+	data := map[string]interface{}{
+		"Name":      receiverName,
+		"UnlockURL": appURL + "/unlock?token=" + url.QueryEscape(token),
+		"Until":     until.UTC().Format("January 2, 2006 15:04 MST"),
+		"Company":   company,
 	}
-	return s.sendEmailFromTemplate(ctx, email, "Welcome to "+s.config.CompanyName, "templates/welcome.html", data)
+	return s.sendEmailFromTemplate(ctx, email, "Your account was locked", "templates/unlock_account.html", data)
 }
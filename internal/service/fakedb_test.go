@@ -0,0 +1,588 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/infrastructure/database"
+)
+
+var errNoDatabase = errors.New("no database in tests")
+
+// fakeDB stands in for Postgres: it keeps rows per model and answers the repository's queries by evaluating their
+// WHERE conditions, so writes guarded by a condition only affect the rows still matching it. Conditions it can't
+// evaluate fail the test rather than match everything.
+type fakeDB struct {
+	t       *testing.T
+	mu      sync.Mutex
+	tables  map[reflect.Type][]reflect.Value
+	schemas map[string]*schema.Schema // by table, of the tables queried so far
+	cache   sync.Map
+	created []interface{}
+	updated []map[string]interface{}
+}
+
+// newFakeRepo returns a repository over a fakeDB holding rows, pointers to models
+func newFakeRepo(t *testing.T, rows ...interface{}) (*database.Repository, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{t: t, tables: map[reflect.Type][]reflect.Value{}, schemas: map[string]*schema.Schema{}}
+	for _, row := range rows {
+		f.insert(reflect.ValueOf(row))
+	}
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: fakeConnPool{}}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	callbacks := []error{
+		db.Callback().Query().Replace("gorm:query", f.query),
+		db.Callback().Create().Replace("gorm:create", f.create),
+		db.Callback().Update().Replace("gorm:update", f.update),
+		db.Callback().Delete().Replace("gorm:delete", f.delete),
+	}
+	for _, err := range callbacks {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return database.NewRepository(db), f
+}
+
+// insert stores a pointer to a model, giving it an id if it has none yet as the column default would
+func (f *fakeDB) insert(row reflect.Value) {
+	if id := row.Elem().FieldByName("ID"); id.IsValid() {
+		switch current := id.Interface().(type) {
+		case uuid.UUID:
+			if current == uuid.Nil {
+				id.Set(reflect.ValueOf(uuid.New()))
+			}
+		case *uuid.UUID:
+			if current == nil {
+				generated := uuid.New()
+				id.Set(reflect.ValueOf(&generated))
+			}
+		}
+	}
+	f.tables[row.Elem().Type()] = append(f.tables[row.Elem().Type()], row)
+}
+
+// fakeRow is a row of the statement's model joined with the rows of the tables it joins, by table name
+type fakeRow map[string]reflect.Value
+
+var joinPattern = regexp.MustCompile(`^(?:INNER )?JOIN (\w+) ON (\w+)\.(\w+) = (\w+)\.(\w+)$`)
+
+// matching returns the rows of the statement's model meeting its conditions, in the statement's order
+func (f *fakeDB) matching(tx *gorm.DB) []reflect.Value {
+	rows := []reflect.Value{}
+	for _, row := range f.joined(tx) {
+		rows = append(rows, row[tx.Statement.Schema.Table])
+	}
+	return rows
+}
+
+// joined returns the statement's rows with the rows they join, meeting its conditions and in its order
+func (f *fakeDB) joined(tx *gorm.DB) []fakeRow {
+	stmt := tx.Statement
+	f.schemas[stmt.Schema.Table] = stmt.Schema
+	deletedAt := softDeleteField(stmt.Schema)
+
+	rows := []fakeRow{}
+	for _, row := range f.tables[stmt.Schema.ModelType] {
+		if deletedAt == nil || stmt.Unscoped || f.value(stmt, deletedAt, row) == nil {
+			rows = append(rows, fakeRow{stmt.Schema.Table: row})
+		}
+	}
+	for _, join := range stmt.Joins {
+		m := joinPattern.FindStringSubmatch(join.Name)
+		if m == nil {
+			f.t.Fatalf("fake database: can't join %q", join.Name)
+		}
+		joinedRows := f.table(stmt, m[1])
+		expanded := []fakeRow{}
+		for _, row := range rows {
+			for _, other := range joinedRows {
+				candidate := fakeRow{m[1]: other}
+				for table, value := range row {
+					candidate[table] = value
+				}
+				if equal(f.column(stmt, candidate, m[2]+"."+m[3]), f.column(stmt, candidate, m[4]+"."+m[5])) {
+					expanded = append(expanded, candidate)
+				}
+			}
+		}
+		rows = expanded
+	}
+
+	var conditions []clause.Expression
+	if where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where); ok {
+		conditions = where.Exprs
+	}
+	matched := []fakeRow{}
+	for _, row := range rows {
+		if f.matches(stmt, row, conditions) {
+			matched = append(matched, row)
+		}
+	}
+
+	if orderBy, ok := stmt.Clauses["ORDER BY"].Expression.(clause.OrderBy); ok {
+		for i := len(orderBy.Columns) - 1; i >= 0; i-- {
+			column, desc := orderBy.Columns[i].Column.Name, orderBy.Columns[i].Desc
+			if fields := strings.Fields(column); len(fields) == 2 {
+				column, desc = fields[0], strings.EqualFold(fields[1], "DESC")
+			}
+			sort.SliceStable(matched, func(a, b int) bool {
+				order, _ := compare(f.column(stmt, matched[a], column), f.column(stmt, matched[b], column))
+				if desc {
+					return order > 0
+				}
+				return order < 0
+			})
+		}
+	}
+	return matched
+}
+
+// table returns the rows stored under a table name, registering the schema of their model
+func (f *fakeDB) table(stmt *gorm.Statement, name string) []reflect.Value {
+	for modelType, rows := range f.tables {
+		s, err := schema.Parse(reflect.New(modelType).Interface(), &f.cache, stmt.DB.NamingStrategy)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		if s.Table == name {
+			f.schemas[name] = s
+			return rows
+		}
+	}
+	return nil
+}
+
+func softDeleteField(s *schema.Schema) *schema.Field {
+	if field := s.LookUpField("deleted_at"); field != nil && field.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+		return field
+	}
+	return nil
+}
+
+// field looks up a column of the statement's model
+func (f *fakeDB) field(stmt *gorm.Statement, column string) *schema.Field {
+	if column == clause.PrimaryKey {
+		return stmt.Schema.PrioritizedPrimaryField
+	}
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		column = column[i+1:]
+	}
+	field := stmt.Schema.LookUpField(strings.Trim(column, `"`))
+	if field == nil {
+		f.t.Fatalf("fake database: no column %q on %s", column, stmt.Schema.Name)
+	}
+	return field
+}
+
+// column reads a column of a joined row, qualified by its table or else of the statement's model
+func (f *fakeDB) column(stmt *gorm.Statement, row fakeRow, column string) interface{} {
+	table := stmt.Schema.Table
+	if i := strings.LastIndex(column, "."); i >= 0 && column != clause.PrimaryKey {
+		table, column = strings.Trim(column[:i], `"`), column[i+1:]
+	}
+	if table == stmt.Schema.Table {
+		return f.value(stmt, f.field(stmt, column), row[table])
+	}
+	s, ok := f.schemas[table]
+	if !ok || !row[table].IsValid() {
+		f.t.Fatalf("fake database: table %s isn't joined", table)
+	}
+	field := s.LookUpField(strings.Trim(column, `"`))
+	if field == nil {
+		f.t.Fatalf("fake database: no column %q on %s", column, table)
+	}
+	return f.value(stmt, field, row[table])
+}
+
+// value reads a column the way it would compare in SQL: nil for NULL, driver values for valuers
+func (f *fakeDB) value(stmt *gorm.Statement, field *schema.Field, row reflect.Value) interface{} {
+	v, _ := field.ValueOf(stmt.Context, row.Elem())
+	return normalize(v)
+}
+
+func normalize(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	if valuer, ok := rv.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+		return normalize(value)
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return rv.Interface()
+}
+
+// compare orders two normalized values, reporting false when they can't be compared
+func compare(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return sign(float64(a - b)), true
+		case float64:
+			return sign(float64(a) - b), true
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return sign(a - float64(b)), true
+		case float64:
+			return sign(a - b), true
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok && a == b {
+			return 0, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+func sign(d float64) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+func equal(a, b interface{}) bool {
+	order, ok := compare(a, b)
+	return ok && order == 0
+}
+
+// in reports whether v equals one of values, a slice
+func in(v interface{}, values interface{}) bool {
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return equal(v, normalize(values))
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if equal(v, normalize(rv.Index(i).Interface())) {
+			return true
+		}
+	}
+	return false
+}
+
+var conditionPattern = regexp.MustCompile(`^([\w."]+)\s+(=|!=|<>|<=|>=|<|>|IS NULL|IS NOT NULL|IN|NOT IN)\s*(\(?\?\)?)?$`)
+
+func (f *fakeDB) matches(stmt *gorm.Statement, row fakeRow, conditions []clause.Expression) bool {
+	for _, condition := range conditions {
+		switch c := condition.(type) {
+		case clause.Expr:
+			vars := c.Vars
+			for _, part := range strings.Split(c.SQL, " AND ") {
+				m := conditionPattern.FindStringSubmatch(strings.TrimSpace(part))
+				if m == nil {
+					f.t.Fatalf("fake database: can't evaluate condition %q", c.SQL)
+				}
+				v := f.column(stmt, row, m[1])
+				var arg interface{}
+				if m[3] != "" {
+					arg, vars = vars[0], vars[1:]
+				}
+				if !holds(v, m[2], arg) {
+					return false
+				}
+			}
+		case clause.Eq:
+			if !holds(f.column(stmt, row, columnName(c.Column)), "=", c.Value) {
+				return false
+			}
+		case clause.Neq:
+			if !holds(f.column(stmt, row, columnName(c.Column)), "!=", c.Value) {
+				return false
+			}
+		case clause.IN:
+			if !in(f.column(stmt, row, columnName(c.Column)), c.Values) {
+				return false
+			}
+		default:
+			f.t.Fatalf("fake database: can't evaluate condition %#v", condition)
+		}
+	}
+	return true
+}
+
+func columnName(column interface{}) string {
+	if c, ok := column.(clause.Column); ok {
+		return c.Name
+	}
+	return fmt.Sprint(column)
+}
+
+func holds(v interface{}, op string, arg interface{}) bool {
+	if op == "=" && normalize(arg) == nil {
+		op = "IS NULL"
+	}
+	switch op {
+	case "IS NULL":
+		return v == nil
+	case "IS NOT NULL":
+		return v != nil
+	case "IN":
+		return in(v, arg)
+	case "NOT IN":
+		return v != nil && !in(v, arg)
+	}
+	order, ok := compare(v, normalize(arg))
+	if !ok {
+		return false
+	}
+	switch op {
+	case "=":
+		return order == 0
+	case "!=", "<>":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	}
+	return order >= 0
+}
+
+func (f *fakeDB) query(tx *gorm.DB) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := f.matching(tx)
+	dest := reflect.ValueOf(tx.Statement.Dest).Elem()
+	switch {
+	case dest.Kind() == reflect.Int64:
+		dest.SetInt(int64(len(rows)))
+		tx.RowsAffected = 1
+	case dest.Kind() == reflect.Struct && dest.Type() == tx.Statement.Schema.ModelType:
+		if len(rows) == 0 {
+			if tx.Statement.RaiseErrorOnNotFound {
+				tx.AddError(gorm.ErrRecordNotFound)
+			}
+			return
+		}
+		dest.Set(rows[0].Elem())
+		tx.RowsAffected = 1
+	case dest.Kind() == reflect.Slice && dest.Type().Elem() == tx.Statement.Schema.ModelType:
+		for _, row := range rows {
+			dest.Set(reflect.Append(dest, row.Elem()))
+		}
+		tx.RowsAffected = int64(len(rows))
+	case dest.Kind() == reflect.Slice && dest.Type().Elem() == reflect.PointerTo(tx.Statement.Schema.ModelType):
+		for _, row := range rows {
+			copied := reflect.New(row.Elem().Type())
+			copied.Elem().Set(row.Elem())
+			dest.Set(reflect.Append(dest, copied))
+		}
+		tx.RowsAffected = int64(len(rows))
+	case dest.Kind() == reflect.Slice:
+		// Pluck of a single column
+		column, distinct := "", tx.Statement.Distinct
+		if selected, ok := tx.Statement.Clauses["SELECT"].Expression.(clause.Select); ok && len(selected.Columns) == 1 {
+			column, distinct = selected.Columns[0].Name, selected.Distinct
+		} else if len(tx.Statement.Selects) == 1 {
+			column = tx.Statement.Selects[0]
+		} else {
+			f.t.Fatalf("fake database: can't pluck into %s", dest.Type())
+		}
+		seen := map[interface{}]bool{}
+		for _, row := range f.joined(tx) {
+			v := f.column(tx.Statement, row, column)
+			if distinct && seen[v] {
+				continue
+			}
+			seen[v] = true
+			dest.Set(reflect.Append(dest, reflect.ValueOf(v).Convert(dest.Type().Elem())))
+		}
+		tx.RowsAffected = int64(dest.Len())
+	default:
+		f.t.Fatalf("fake database: can't query %s into %s", tx.Statement.Schema.Name, dest.Type())
+	}
+}
+
+func (f *fakeDB) create(tx *gorm.DB) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dest := reflect.ValueOf(tx.Statement.Dest)
+	if dest.Elem().Kind() == reflect.Slice {
+		for i := 0; i < dest.Elem().Len(); i++ {
+			f.insert(dest.Elem().Index(i).Addr())
+		}
+		tx.RowsAffected = int64(dest.Elem().Len())
+		return
+	}
+	f.insert(dest)
+	f.created = append(f.created, tx.Statement.Dest)
+	tx.RowsAffected = 1
+}
+
+var incrementPattern = regexp.MustCompile(`^(\w+)\s*\+\s*(\d+)$`)
+
+// update sets columns on the matching rows, recording the columns set by map; updates by struct save the row
+func (f *fakeDB) update(tx *gorm.DB) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stmt := tx.Statement
+
+	updates, ok := stmt.Dest.(map[string]interface{})
+	if !ok {
+		saved := reflect.ValueOf(stmt.Dest)
+		id, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, saved.Elem())
+		for _, row := range f.tables[stmt.Schema.ModelType] {
+			if equal(f.value(stmt, stmt.Schema.PrioritizedPrimaryField, row), normalize(id)) {
+				row.Elem().Set(saved.Elem())
+				tx.RowsAffected = 1
+			}
+		}
+		return
+	}
+	f.updated = append(f.updated, updates)
+
+	rows := f.matching(tx)
+	for _, row := range rows {
+		for column, value := range updates {
+			field := f.field(stmt, column)
+			if expr, ok := value.(clause.Expr); ok {
+				m := incrementPattern.FindStringSubmatch(expr.SQL)
+				if m == nil {
+					f.t.Fatalf("fake database: can't evaluate %q", expr.SQL)
+				}
+				current, _ := f.value(stmt, f.field(stmt, m[1]), row).(int64)
+				by, _ := strconv.ParseInt(m[2], 10, 64)
+				value = current + by
+			}
+			if err := field.Set(stmt.Context, row.Elem(), value); err != nil {
+				f.t.Fatalf("fake database: setting %s: %v", column, err)
+			}
+		}
+	}
+	tx.RowsAffected = int64(len(rows))
+}
+
+// delete removes the matching rows, or marks them deleted for models deleted softly
+func (f *fakeDB) delete(tx *gorm.DB) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stmt := tx.Statement
+	rows := f.matching(tx)
+	if deletedAt := softDeleteField(stmt.Schema); deletedAt != nil && !stmt.Unscoped {
+		for _, row := range rows {
+			if err := deletedAt.Set(stmt.Context, row.Elem(), time.Now()); err != nil {
+				f.t.Fatal(err)
+			}
+		}
+	} else {
+		kept := []reflect.Value{}
+		for _, row := range f.tables[stmt.Schema.ModelType] {
+			deleted := false
+			for _, match := range rows {
+				deleted = deleted || match == row
+			}
+			if !deleted {
+				kept = append(kept, row)
+			}
+		}
+		f.tables[stmt.Schema.ModelType] = kept
+	}
+	tx.RowsAffected = int64(len(rows))
+}
+
+// logs returns the audit entries written so far
+func (f *fakeDB) logs() []model.Log {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	logs := []model.Log{}
+	for _, created := range f.created {
+		if log, ok := created.(*model.Log); ok {
+			logs = append(logs, *log)
+		}
+	}
+	return logs
+}
+
+// fakeConnPool only hands out transactions, the callbacks above never reach it
+type fakeConnPool struct{}
+
+func (fakeConnPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errNoDatabase
+}
+
+func (fakeConnPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errNoDatabase
+}
+
+func (fakeConnPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errNoDatabase
+}
+
+func (fakeConnPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (fakeConnPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &fakeTx{}, nil
+}
+
+type fakeTx struct{ fakeConnPool }
+
+func (*fakeTx) Commit() error   { return nil }
+func (*fakeTx) Rollback() error { return nil }
@@ -3,22 +3,89 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 
+	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/google/uuid"
+	jwt_ "github.com/minilikmila/standard-auth-go/internal/auth/jwt"
 	"github.com/minilikmila/standard-auth-go/internal/domain/model"
 )
 
 // Common errors
 var (
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserAlreadyExists  = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrInvalidCode        = errors.New("invalid verification code")
-	ErrTokenExpired       = errors.New("token expired")
-	ErrTooManyAttempts    = errors.New("too many login attempts")
-	ErrEmailNotVerified   = errors.New("email not verified")
-	ErrPhoneNotVerified   = errors.New("phone not verified")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrUserAlreadyExists      = errors.New("user already exists")
+	ErrInvalidCredentials     = errors.New("invalid credentials")
+	ErrInvalidToken           = errors.New("invalid token")
+	ErrInvalidCode            = errors.New("invalid verification code")
+	ErrTokenExpired           = errors.New("token expired")
+	ErrTooManyAttempts        = errors.New("too many login attempts")
+	ErrEmailNotVerified       = errors.New("email not verified")
+	ErrPhoneNotVerified       = errors.New("phone not verified")
+	ErrTokenReused            = errors.New("refresh token reuse detected")
+	ErrSessionRevoked         = errors.New("session revoked")
+	ErrSessionNotFound        = errors.New("session not found")
+	ErrMFAAlreadyEnabled      = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled          = errors.New("two-factor authentication not enabled")
+	ErrMFARequired            = errors.New("two-factor authentication is required")
+	ErrWebAuthnDisabled       = errors.New("webauthn is disabled")
+	ErrCredentialNotFound     = errors.New("credential not found")
+	ErrEmailDisabled          = errors.New("email support is disabled")
+	ErrPhoneDisabled          = errors.New("phone support is disabled")
+	ErrProviderDisabled       = errors.New("oauth provider is not enabled")
+	ErrOAuthExchange          = errors.New("oauth code exchange failed")
+	ErrSignupDisabled         = errors.New("sign up is disabled")
+	ErrAccountExists          = errors.New("an account with this email already exists, sign in and link the provider")
+	ErrIdentityInUse          = errors.New("identity is linked to another account")
+	ErrIdentityNotFound       = errors.New("identity not found")
+	ErrLastLoginMethod        = errors.New("can't remove the last login method")
+	ErrOIDCDisabled           = errors.New("oidc provider is disabled")
+	ErrInvalidClient          = errors.New("unknown client or bad client credentials")
+	ErrInvalidRedirectURI     = errors.New("redirect uri is not registered for the client")
+	ErrInvalidRequest         = errors.New("invalid authorization request")
+	ErrInvalidScope           = errors.New("requested scope is not allowed")
+	ErrUnsupportedResponse    = errors.New("unsupported response type")
+	ErrInvalidGrant           = errors.New("invalid or expired authorization grant")
+	ErrUnsupportedGrant       = errors.New("unsupported grant type")
+	ErrUnauthorizedClient     = errors.New("client is not allowed to use this grant type")
+	ErrAPIKeyNotFound         = errors.New("api key not found")
+	ErrPermissionDenied       = errors.New("permission denied")
+	ErrInvalidExpiry          = errors.New("expiry must be in the future")
+	ErrConsentRequired        = errors.New("user consent required")
+	ErrConsentNotFound        = errors.New("consent not found")
+	ErrClientNotFound         = errors.New("client not found")
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleExists             = errors.New("role already exists")
+	ErrPermissionNotFound     = errors.New("permission not found")
+	ErrPermissionExists       = errors.New("permission already exists")
+	ErrInvalidName            = errors.New("invalid name")
+	ErrProtectedRole          = errors.New("admin roles from the config can't be deleted or lose permissions")
+	ErrOrganizationNotFound   = errors.New("organization not found")
+	ErrNotMember              = errors.New("not a member of the organization")
+	ErrAlreadyMember          = errors.New("already a member of the organization")
+	ErrInvalidOrgRole         = errors.New("role must be owner, admin or member")
+	ErrLastOwner              = errors.New("an organization needs at least one owner")
+	ErrInvalidInvitation      = errors.New("invitation is invalid or expired")
+	ErrInvitationMismatch     = errors.New("invitation was sent to another email address")
+	ErrTenantNotFound         = errors.New("tenant not found")
+	ErrTenantExists           = errors.New("a tenant with this slug or host already exists")
+	ErrCannotModifySelf       = errors.New("admins can't change the role of or delete their own account")
+	ErrNoContact              = errors.New("user has no email address or phone number for this")
+	ErrAccountSuspended       = errors.New("account is suspended")
+	ErrAccountBanned          = errors.New("account is banned")
+	ErrAccountPendingDeletion = errors.New("account is pending deletion")
+	ErrAccountLocked          = errors.New("account is locked after too many failed logins, try again later")
+	ErrInvalidStatus          = errors.New("status must be active, suspended with a future until, banned or pending_deletion")
+	ErrInvalidTenantSettings  = errors.New("tenant settings must be positive, keep token lifetimes within the global ones and only name enabled providers")
+
+	// Key rotation errors come from the key store
+	ErrKeyRotationDisabled = jwt_.ErrKeyRotationDisabled
+	ErrNoNextKey           = jwt_.ErrNoNextKey
+	ErrNextKeyExists       = jwt_.ErrNextKeyExists
+	ErrKeyNotFound         = jwt_.ErrKeyNotFound
+	ErrActiveKey           = jwt_.ErrActiveKey
+	ErrAuthenticatorCloned = errors.New("authenticator sign counter regressed, possible clone")
 )
 
 // AuthService defines the interface for authentication operations
@@ -26,7 +93,8 @@ type AuthService interface {
 	// User Management
 	CreateUser(ctx context.Context, user *model.User) error
 	UserExists(ctx context.Context, email string) (bool, error)
-	Login(ctx context.Context, email, password string) (*model.User, error)
+	// Login returns a non-empty MFA pending token instead of completing when a second factor is needed
+	Login(ctx context.Context, email, password string) (*model.User, string, error)
 	Logout(ctx context.Context, token string) error
 	GenerateTokens(ctx context.Context, user *model.User) (string, string, error)
 
@@ -46,6 +114,147 @@ type AuthService interface {
 	// Token Management
 	RefreshToken(ctx context.Context, refreshToken string) (string, string, error)
 	ValidateToken(ctx context.Context, token string) (string, error)
+	ValidateAccessToken(ctx context.Context, token string) (*model.TokenInfo, error)
+	// JWKS returns the public keys resource servers verify our tokens with
+	JWKS() model.JWKSet
+
+	// API keys
+	CreateAPIKey(ctx context.Context, userID, name string, permissions []string, expiresAt *time.Time) (*model.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+	// AuthenticateAPIKey resolves an API key to the request context of its owner, limited to the key's permissions
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.UserCtxData, error)
+
+	// Roles and permissions
+	// GetUserAccess resolves every role of the user and the permissions they grant
+	GetUserAccess(ctx context.Context, user *model.User) ([]string, []string, error)
+	ListRoles(ctx context.Context) ([]model.Role, error)
+	CreateRole(ctx context.Context, adminID, name, description string, permissions []string) (*model.Role, error)
+	DeleteRole(ctx context.Context, adminID, name string) error
+	GrantRolePermission(ctx context.Context, adminID, role, permission string) error
+	RevokeRolePermission(ctx context.Context, adminID, role, permission string) error
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	CreatePermission(ctx context.Context, adminID, name, description string) (*model.Permission, error)
+	DeletePermission(ctx context.Context, adminID, name string) error
+	ListUserRoles(ctx context.Context, userID string) ([]string, error)
+	AssignRole(ctx context.Context, adminID, userID, role string) error
+	UnassignRole(ctx context.Context, adminID, userID, role string) error
+
+	// Organizations
+	CreateOrganization(ctx context.Context, userID, name string) (*model.Organization, error)
+	ListMemberships(ctx context.Context, userID string) ([]model.Membership, error)
+	GetMembership(ctx context.Context, orgID, userID string) (*model.Membership, error)
+	ListMembers(ctx context.Context, orgID string) ([]model.Membership, error)
+	// The actorRole is the caller's role in the organization, only owners can hand out or take away ownership
+	InviteMember(ctx context.Context, actorID, actorRole, orgID, email, role string) error
+	AcceptInvitation(ctx context.Context, userID, token string) (*model.Membership, error)
+	UpdateMemberRole(ctx context.Context, actorID, actorRole, orgID, userID, role string) error
+	RemoveMember(ctx context.Context, actorID, actorRole, orgID, userID string) error
+	// SwitchOrganization scopes the session to the organization, or to none when orgID is empty, and returns an
+	// access token carrying it
+	SwitchOrganization(ctx context.Context, userID, sessionID, orgID string) (string, error)
+
+	// Admin user management
+	ListUsers(ctx context.Context, filter model.UserFilter, page model.Pagination) (*model.UserPage, error)
+	// GetUser also finds soft deleted users
+	GetUser(ctx context.Context, userID string) (*model.User, error)
+	AdminCreateUser(ctx context.Context, adminID string, form model.AdminUserForm) (*model.User, error)
+	UpdateUserRole(ctx context.Context, adminID, userID, role string) (*model.User, error)
+	VerifyUser(ctx context.Context, adminID, userID string, email, phone bool) (*model.User, error)
+	SendUserPasswordReset(ctx context.Context, adminID, userID string) error
+	DeleteUser(ctx context.Context, adminID, userID string) error
+	RestoreUser(ctx context.Context, adminID, userID string) (*model.User, error)
+	// CheckAccountStatus returns why the account can't be used, nil when it is active
+	CheckAccountStatus(user *model.User) error
+	// SetUserStatus changes the account state, until only applies to suspensions. Leaving the active state signs
+	// the user out everywhere.
+	SetUserStatus(ctx context.Context, adminID, userID, status, reason string, until *time.Time) (*model.User, error)
+	// UnlockAccount lifts a login lock through the emailed unlock link
+	UnlockAccount(ctx context.Context, token string) error
+	// UnlockUser lifts a user's login lock and clears their failed attempts
+	UnlockUser(ctx context.Context, adminID, userID string) (*model.User, error)
+
+	// Tenants
+	// ResolveTenant returns the tenant named by slug, or the one serving host. Nil means the global configuration.
+	ResolveTenant(ctx context.Context, slug, host string) (*model.Tenant, error)
+	ListTenants(ctx context.Context) ([]model.Tenant, error)
+	GetTenant(ctx context.Context, tenantID string) (*model.Tenant, error)
+	CreateTenant(ctx context.Context, adminID string, tenant *model.Tenant) error
+	UpdateTenant(ctx context.Context, adminID, tenantID string, update *model.Tenant) (*model.Tenant, error)
+	DeleteTenant(ctx context.Context, adminID, tenantID string) error
+
+	// Signing key rotation
+	ListSigningKeys(ctx context.Context) ([]model.SigningKey, error)
+	CreateSigningKey(ctx context.Context, adminID string) (*model.SigningKey, error)
+	PromoteSigningKey(ctx context.Context, adminID string) (*model.SigningKey, error)
+	RetireSigningKey(ctx context.Context, adminID, kid string) error
+
+	// Session Management
+	ListSessions(ctx context.Context, userID string) ([]model.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error
+
+	// Two-factor Authentication
+	BeginTOTPEnrollment(ctx context.Context, userID string) (string, string, error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID, code string) error
+	ResolveMFAToken(ctx context.Context, mfaToken string) (*model.User, error)
+	CompleteMFAEnrollment(ctx context.Context, mfaToken, code string) (*model.User, []string, error)
+	VerifyMFA(ctx context.Context, mfaToken, code string) (*model.User, error)
+	VerifyMFARecoveryCode(ctx context.Context, mfaToken, recoveryCode string) (*model.User, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error)
+	CountRecoveryCodes(ctx context.Context, userID string) (int64, error)
+
+	// WebAuthn / Passkeys
+	BeginWebAuthnRegistration(ctx context.Context, userID string) (*protocol.CredentialCreation, error)
+	FinishWebAuthnRegistration(ctx context.Context, userID, name string, body io.Reader) (*model.WebAuthnCredential, error)
+	BeginWebAuthnLogin(ctx context.Context, email string) (*protocol.CredentialAssertion, error)
+	FinishWebAuthnLogin(ctx context.Context, body io.Reader) (*model.User, string, error)
+	ListWebAuthnCredentials(ctx context.Context, userID string) ([]model.WebAuthnCredential, error)
+	DeleteWebAuthnCredential(ctx context.Context, userID, credentialID string) error
+
+	// Magic Link
+	SendMagicLink(ctx context.Context, email, nonce string) error
+	VerifyMagicLink(ctx context.Context, token, nonce string) (*model.User, string, error)
+
+	// Phone OTP Login
+	SendLoginOTP(ctx context.Context, phone string) error
+	// VerifyLoginOTP returns a non-empty MFA pending token instead of completing when a second factor is needed
+	VerifyLoginOTP(ctx context.Context, phone, code string) (*model.User, string, error)
+
+	// OAuth
+	// OAuthProviders lists the names of the providers enabled for the request's tenant
+	OAuthProviders(ctx context.Context) []string
+	// BeginOAuth returns the provider consent URL and the state the caller must bind to the browser
+	BeginOAuth(ctx context.Context, provider string) (string, string, error)
+	// CompleteOAuth signs in or, for a state started by BeginOAuthLink, links. A sign in needing a second factor
+	// carries an MFA pending token instead of completing.
+	CompleteOAuth(ctx context.Context, provider, state, code string) (*model.OAuthResult, error)
+	BeginOAuthLink(ctx context.Context, userID, provider string) (string, string, error)
+	ListIdentities(ctx context.Context, userID string) ([]model.Identity, error)
+	UnlinkIdentity(ctx context.Context, userID, identityID string) error
+
+	// OpenID Connect Provider
+	OpenIDConfiguration() (map[string]interface{}, error)
+	// ValidateAuthorizeRequest returns ErrInvalidClient or ErrInvalidRedirectURI when the error can't be sent back
+	// to the client, any other error belongs on the redirect URI
+	ValidateAuthorizeRequest(ctx context.Context, req *model.AuthorizeRequest) (*model.Client, error)
+	// Authorize returns the redirect carrying a fresh code, or ErrConsentRequired
+	Authorize(ctx context.Context, userID, sessionID string, req *model.AuthorizeRequest) (string, error)
+	GrantConsent(ctx context.Context, userID, sessionID string, req *model.AuthorizeRequest, approve bool) (string, error)
+	ExchangeAuthorizationCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string) (*model.TokenResponse, error)
+	IssueClientCredentialsToken(ctx context.Context, clientID, clientSecret, scope string) (*model.TokenResponse, error)
+	// AuthenticateClientToken resolves a client credentials token to the request context of its client, limited to
+	// what the client's owner can still do
+	AuthenticateClientToken(ctx context.Context, info *model.TokenInfo) (*model.UserCtxData, error)
+	IntrospectToken(ctx context.Context, clientID, clientSecret, token string) (*model.IntrospectionResponse, error)
+	RevokeToken(ctx context.Context, clientID, clientSecret, token string) error
+	UserInfo(ctx context.Context, accessToken string) (*model.UserInfo, error)
+	CreateClient(ctx context.Context, caller *model.UserCtxData, client *model.Client, confidential bool) (string, error)
+	ListClients(ctx context.Context) ([]model.Client, error)
+	DeleteClient(ctx context.Context, clientID string) error
+	ListConsents(ctx context.Context, userID string) ([]model.Consent, error)
+	RevokeConsent(ctx context.Context, userID, clientID string) error
 
 	// Profile Management
 	UpdateProfile(ctx context.Context, userID string, updates map[string]interface{}) error
@@ -80,7 +289,7 @@ type Database interface {
 	VerifyPhone(ctx context.Context, id uuid.UUID) error
 
 	// Login operations
-	IncrementLoginAttempts(ctx context.Context, id uuid.UUID) error
+	IncrementLoginAttempts(ctx context.Context, id uuid.UUID) (int, error)
 	ResetLoginAttempts(ctx context.Context, id uuid.UUID) error
 	UpdateLastLogin(ctx context.Context, id uuid.UUID) error
 
@@ -91,14 +300,26 @@ type Database interface {
 
 // JWTService interface for JWT operations
 type JWTService interface {
-	GenerateToken(user *model.User, metadata interface{}) (string, error)
-	GenerateRefreshToken(user *model.User) (string, error)
+	// expiry is in minutes, zero uses the configured one
+	GenerateToken(user *model.User, sessionID uuid.UUID, orgID *uuid.UUID, expiry time.Duration, metadata interface{}) (string, error)
+	GenerateRefreshToken(user *model.User, sessionID uuid.UUID, expiry time.Duration) (string, error)
 	GeneratePasswordResetToken(user *model.User) (string, error)
 	GenerateEmailVerificationToken(user *model.User) (string, error)
+	GenerateMFAToken(user *model.User) (string, error)
+	GenerateClientAccessToken(user *model.User, sessionID uuid.UUID, clientID, scope string) (string, error)
+	GenerateClientCredentialsToken(clientID, scope string) (string, error)
+	GenerateIDToken(user *model.User, clientID, nonce string, authTime time.Time, scopes []string) (string, error)
 	ValidateToken(token string) (uuid.UUID, error)
 	ValidateRefreshToken(token string) (uuid.UUID, error)
 	ValidatePasswordResetToken(token string) (uuid.UUID, error)
 	ValidateEmailVerificationToken(token string) (uuid.UUID, error)
+	ValidateMFAToken(token string) (uuid.UUID, error)
+	InspectToken(token string) (*model.TokenInfo, error)
+	JWKS() model.JWKSet
+	SigningKeys() ([]model.SigningKey, error)
+	GenerateSigningKey() (*model.SigningKey, error)
+	PromoteSigningKey() (*model.SigningKey, error)
+	RetireSigningKey(kid string) error
 	InvalidateToken(ctx context.Context, token string) error
 }
 
@@ -107,10 +328,15 @@ type EmailService interface {
 	SendVerificationEmail(ctx context.Context, email, token, receiverName string) error
 	SendPasswordResetEmail(ctx context.Context, email, token string) error
 	SendWelcomeEmail(ctx context.Context, email string, name string) error
+	SendMagicLinkEmail(ctx context.Context, email, token, receiverName string) error
+	SendOrganizationInviteEmail(ctx context.Context, email, token, organization, inviter, role string) error
+	SendAccountStatusEmail(ctx context.Context, email, receiverName, status, reason string, until *time.Time) error
+	SendUnlockEmail(ctx context.Context, email, token, receiverName string, until time.Time) error
 }
 
 // SMSService defines the interface for SMS operations
 type SMSService interface {
 	SendVerificationSMS(ctx context.Context, phone, code string) error
 	SendPasswordResetSMS(ctx context.Context, phone string, code string) error
+	SendLoginCodeSMS(ctx context.Context, phone, code string) error
 }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// IntrospectToken tells a confidential client whether an access or refresh token is still good (RFC 7662).
+// The token type is read from the token itself, so token_type_hint isn't needed.
+func (s *AuthServiceImpl) IntrospectToken(ctx context.Context, clientID, clientSecret, token string) (*model.IntrospectionResponse, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() {
+		return nil, ErrUnauthorizedClient
+	}
+
+	info, err := s.activeToken(ctx, token)
+	if err != nil {
+		return &model.IntrospectionResponse{Active: false}, nil
+	}
+
+	response := &model.IntrospectionResponse{
+		Active:    true,
+		Scope:     info.Scope,
+		ClientID:  info.ClientID,
+		TokenType: "access_token",
+		Exp:       info.ExpiresAt.Unix(),
+		Iat:       info.IssuedAt.Unix(),
+		Iss:       s.config.JWT.Iss,
+	}
+	if info.TokenType == enum.RefreshToken {
+		response.TokenType = "refresh_token"
+	}
+	if info.SessionID != nil {
+		response.SessionID = info.SessionID.String()
+	}
+
+	if info.UserID == uuid.Nil {
+		response.Sub = info.ClientID
+		return response, nil
+	}
+	user, err := s.repo.GetUserByID(ctx, info.UserID)
+	if err != nil {
+		return &model.IntrospectionResponse{Active: false}, nil
+	}
+	response.Sub = user.ID.String()
+	response.Username = derefString(user.Email)
+	if response.Username == "" {
+		response.Username = derefString(user.Phone)
+	}
+	return response, nil
+}
+
+// activeToken validates an access token like the API does, or a refresh token against its stored rotation state
+func (s *AuthServiceImpl) activeToken(ctx context.Context, token string) (*model.TokenInfo, error) {
+	info, err := s.jwtService.InspectToken(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	switch info.TokenType {
+	case enum.AccessToken:
+		info, err = s.inspectAccessToken(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if info.UserID == uuid.Nil {
+			client, err := s.repo.GetClientByClientID(ctx, info.ClientID)
+			if err != nil || !client.AllowsGrant(model.GrantClientCredentials) {
+				return nil, ErrInvalidToken
+			}
+		}
+		return info, nil
+	case enum.RefreshToken:
+		stored, err := s.repo.GetRefreshTokenByHash(ctx, utils.HashToken(token))
+		if err != nil || stored.UserID != info.UserID || !stored.IsActive() {
+			return nil, ErrInvalidToken
+		}
+		session, err := s.repo.GetSessionByFamily(ctx, stored.FamilyID)
+		if err != nil || session.RevokedAt != nil {
+			return nil, ErrSessionRevoked
+		}
+		return info, nil
+	default:
+		return nil, ErrInvalidToken
+	}
+}
+
+// RevokeToken revokes an access or refresh token (RFC 7009). Revoking a refresh token ends its session, which
+// takes the session's access tokens with it. Unknown or expired tokens are ignored, there is nothing to revoke.
+// Confidential clients can revoke first-party tokens, any client can revoke the tokens issued to it.
+func (s *AuthServiceImpl) RevokeToken(ctx context.Context, clientID, clientSecret, token string) error {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.jwtService.InspectToken(token)
+	if err != nil {
+		return nil
+	}
+	if info.ClientID != client.ClientID && (info.ClientID != "" || client.IsPublic()) {
+		return ErrUnauthorizedClient
+	}
+
+	switch info.TokenType {
+	case enum.AccessToken:
+		hashedToken := utils.HashToken(token)
+		blacklisted, err := s.repo.IsTokenBlacklisted(ctx, hashedToken)
+		if err != nil || blacklisted {
+			return err
+		}
+		return s.repo.BlacklistTokenUntil(ctx, hashedToken, info.ExpiresAt)
+	case enum.RefreshToken:
+		stored, err := s.repo.GetRefreshTokenByHash(ctx, utils.HashToken(token))
+		if err != nil || stored.UserID != info.UserID {
+			return nil
+		}
+		session, err := s.repo.GetSessionByFamily(ctx, stored.FamilyID)
+		if err != nil {
+			return s.repo.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+		}
+		if err := s.repo.RevokeSession(ctx, session.UserID, session.ID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		logrus.Infof("Client %s revoked session %s", client.ClientID, session.ID)
+		return nil
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	policy := config.LockoutPolicy{Attempts: 3, For: 60, MaxFor: 1440}
+
+	tests := []struct {
+		name     string
+		policy   config.LockoutPolicy
+		failures int
+		want     time.Duration
+	}{
+		{"below threshold", policy, 2, 0},
+		{"at threshold", policy, 3, time.Hour},
+		{"doubles after threshold", policy, 4, 2 * time.Hour},
+		{"keeps doubling", policy, 6, 8 * time.Hour},
+		{"capped", policy, 8, 24 * time.Hour},
+		{"stays capped", policy, 50, 24 * time.Hour},
+		{"no cap", config.LockoutPolicy{Attempts: 1, For: 1}, 5, 16 * time.Minute},
+		{"attempts off", config.LockoutPolicy{For: 60, MaxFor: 1440}, 100, 0},
+		{"window off", config.LockoutPolicy{Attempts: 3, MaxFor: 1440}, 100, 0},
+		{"cap below window", config.LockoutPolicy{Attempts: 1, For: 60, MaxFor: 30}, 1, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockoutDuration(tt.policy, tt.failures); got != tt.want {
+				t.Errorf("lockoutDuration(%+v, %d) = %v, want %v", tt.policy, tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTokensRefusesLockedAccount(t *testing.T) {
+	// Magic links, login codes, passkeys and social logins all get their tokens here
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	id := uuid.New()
+	locked := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		user *model.User
+		want error
+	}{
+		{"locked", &model.User{ID: &id, IsEmailVerified: true, LockedUntil: &locked}, ErrAccountLocked},
+		{"locked and banned", &model.User{ID: &id, IsEmailVerified: true, LockedUntil: &locked, Status: model.UserStatusBanned}, ErrAccountBanned},
+		{"lock expired, unverified", &model.User{ID: &id, LockedUntil: &expired}, ErrEmailNotVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := s.GenerateTokens(context.Background(), tt.user); err != tt.want {
+				t.Errorf("GenerateTokens = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnlockUserAudited(t *testing.T) {
+	adminID, userID := uuid.New(), uuid.New()
+	locked := time.Now().Add(time.Hour)
+	repo, db := newFakeRepo(t, &model.User{ID: &userID, IncorrectLoginAttempts: 5, LockedUntil: &locked})
+	s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig()}
+
+	user, err := s.UnlockUser(context.Background(), adminID.String(), userID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.LockedUntil != nil || user.IncorrectLoginAttempts != 0 {
+		t.Errorf("user still locked: %v after %d attempts", user.LockedUntil, user.IncorrectLoginAttempts)
+	}
+	logs := db.logs()
+	if len(logs) != 1 {
+		t.Fatalf("%d audit entries, want 1", len(logs))
+	}
+	entry := logs[0]
+	if entry.Event != model.LogEventAccountUnlocked || entry.UserId != userID.String() || entry.Metadata["unlocked_by"] != adminID.String() {
+		t.Errorf("audit entry = %+v, want the unlock of %s by %s", entry, userID, adminID)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// magicLinkTokenBytes is the entropy of a sign-in link token
+const magicLinkTokenBytes = 32
+
+// SendMagicLink emails a single-use sign-in link bound to the browser holding nonce.
+// Unknown addresses are silently ignored so the endpoint can't be used to probe for accounts.
+func (s *AuthServiceImpl) SendMagicLink(ctx context.Context, email, nonce string) error {
+	if s.config.DisableEmail {
+		return ErrEmailDisabled
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		logrus.Infoln("Magic link requested for unknown email")
+		return nil
+	}
+
+	token, err := utils.GenerateRandomToken(magicLinkTokenBytes)
+	if err != nil {
+		return err
+	}
+
+	hashedToken := utils.HashToken(token)
+	hashedNonce := utils.HashToken(nonce)
+	verification := &model.Verification{
+		UserID:    *user.ID,
+		Type:      model.VerificationTypeMagicLink,
+		Token:     &hashedToken,
+		Data:      &hashedNonce,
+		Status:    model.VerificationStatusPending,
+		SentAt:    time.Now(),
+		ExpiresAt: time.Now().Add(time.Duration(s.config.MagicLinkExp) * time.Minute),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.CreateVerification(ctx, verification); err != nil {
+		return err
+	}
+
+	return s.emailService.SendMagicLinkEmail(ctx, *user.Email, token, derefString(user.Name))
+}
+
+// VerifyMagicLink consumes a sign-in link. The nonce must match the one set on the requesting browser.
+// Like Login it returns an MFA pending token instead of finishing when the account needs a second factor.
+func (s *AuthServiceImpl) VerifyMagicLink(ctx context.Context, token, nonce string) (*model.User, string, error) {
+	if token == "" || nonce == "" {
+		return nil, "", ErrInvalidToken
+	}
+
+	verification, err := s.repo.GetVerificationByToken(ctx, utils.HashToken(token), model.VerificationTypeMagicLink)
+	if err != nil {
+		return nil, "", ErrInvalidToken
+	}
+
+	if verification.Data == nil || subtle.ConstantTimeCompare([]byte(*verification.Data), []byte(utils.HashToken(nonce))) != 1 {
+		return nil, "", ErrInvalidToken
+	}
+
+	// Single use, a concurrent request with the same link loses here
+	consumed, err := s.repo.ConsumeVerification(ctx, verification.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !consumed {
+		return nil, "", ErrInvalidToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, verification.UserID)
+	if err != nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	// Following the link proves ownership of the address
+	if !user.IsEmailVerified {
+		now := time.Now()
+		if err := s.repo.UpdateUser(ctx, *user.ID, map[string]interface{}{
+			"is_email_verified": true,
+			"email_verified_at": now,
+		}); err != nil {
+			return nil, "", err
+		}
+		user.IsEmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+
+	if user.MFAEnabled || s.mfaRequired(ctx, user) {
+		mfaToken, err := s.createMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, "", err
+		}
+		return user, mfaToken, nil
+	}
+
+	if err := s.repo.UpdateLastLogin(ctx, *user.ID); err != nil {
+		return nil, "", err
+	}
+
+	return user, "", nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+func TestVerifyMagicLinkNeedsTokenAndNonce(t *testing.T) {
+	// Rejected before the link is looked up, so a forwarded link can't be spent from another browser
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	for _, tt := range []struct{ token, nonce string }{
+		{"", "nonce"},
+		{"token", ""},
+		{"", ""},
+	} {
+		if _, _, err := s.VerifyMagicLink(context.Background(), tt.token, tt.nonce); err != ErrInvalidToken {
+			t.Errorf("VerifyMagicLink(%q, %q) = %v, want %v", tt.token, tt.nonce, err, ErrInvalidToken)
+		}
+	}
+}
+
+func TestSendMagicLinkEmailDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DisableEmail = true
+	s := &AuthServiceImpl{config: cfg}
+	if err := s.SendMagicLink(context.Background(), "jane@example.com", "nonce"); err != ErrEmailDisabled {
+		t.Errorf("SendMagicLink = %v, want %v", err, ErrEmailDisabled)
+	}
+}
+
+func TestVerifyMagicLink(t *testing.T) {
+	userID := uuid.New()
+	email := "jane@example.com"
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		nonces    []string // presented in turn, the last one's result is checked
+		want      error
+	}{
+		{"valid", time.Minute, []string{"nonce"}, nil},
+		{"wrong nonce", time.Minute, []string{"other-nonce"}, ErrInvalidToken},
+		{"wrong nonce doesn't spend the link", time.Minute, []string{"other-nonce", "nonce"}, nil},
+		{"second use", time.Minute, []string{"nonce", "nonce"}, ErrInvalidToken},
+		{"expired", -time.Minute, []string{"nonce"}, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, nonce := utils.HashToken("link-token"), utils.HashToken("nonce")
+			repo, _ := newFakeRepo(t,
+				&model.User{ID: &userID, Email: &email, IsEmailVerified: true},
+				&model.Verification{UserID: userID, Type: model.VerificationTypeMagicLink, Token: &token, Data: &nonce,
+					Status: model.VerificationStatusPending, ExpiresAt: time.Now().Add(tt.expiresIn)},
+			)
+			s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig()}
+
+			var (
+				user *model.User
+				err  error
+			)
+			for _, presented := range tt.nonces {
+				user, _, err = s.VerifyMagicLink(ctx, "link-token", presented)
+			}
+			if err != tt.want {
+				t.Fatalf("VerifyMagicLink = %v, want %v", err, tt.want)
+			}
+			if err == nil && *user.ID != userID {
+				t.Errorf("signed in %s, want %s", user.ID, userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+const (
+	// maxMFAAttempts is the number of wrong codes accepted per challenge or enrollment before it is burned
+	maxMFAAttempts = 5
+	// recoveryCodeCount is the size of a recovery code set
+	recoveryCodeCount = 10
+)
+
+// mfaRequired reports whether the configuration forces the user onto a second factor, any of their roles counts
+func (s *AuthServiceImpl) mfaRequired(ctx context.Context, user *model.User) bool {
+	if s.config.MFA.Required {
+		return true
+	}
+	if len(s.config.MFA.RequiredRoles) == 0 {
+		return false
+	}
+	roles, _, err := s.GetUserAccess(ctx, user)
+	if err != nil {
+		// Can't tell, ask for the second factor rather than skip it
+		logrus.Errorln("Failed to resolve user roles : ", err)
+		return true
+	}
+	for _, required := range s.config.MFA.RequiredRoles {
+		for _, role := range roles {
+			if strings.EqualFold(required, role) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mfaIssuer names the account in authenticator apps, a tenant's own company name wins over the global issuer
+func (s *AuthServiceImpl) mfaIssuer(ctx context.Context) string {
+	if tenant := tenantFromContext(ctx); tenant != nil && tenant.Settings.CompanyName != nil {
+		return *tenant.Settings.CompanyName
+	}
+	if s.config.MFA.Issuer != "" {
+		return s.config.MFA.Issuer
+	}
+	return s.config.CompanyName
+}
+
+// createMFAChallenge issues a single-use MFA pending token, stored hashed like every other one-time token
+func (s *AuthServiceImpl) createMFAChallenge(ctx context.Context, user *model.User) (string, error) {
+	token, err := s.jwtService.GenerateMFAToken(user)
+	if err != nil {
+		return "", err
+	}
+
+	hashedToken := utils.HashToken(token)
+	verification := &model.Verification{
+		UserID:    *user.ID,
+		Type:      model.VerificationType2FA,
+		Token:     &hashedToken,
+		Status:    model.VerificationStatusPending,
+		SentAt:    time.Now(),
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.CreateVerification(ctx, verification); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// mfaChallenge resolves a pending token to its challenge record and user without consuming it
+func (s *AuthServiceImpl) mfaChallenge(ctx context.Context, mfaToken string) (*model.Verification, *model.User, error) {
+	userID, err := s.jwtService.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	verification, err := s.repo.GetVerificationByToken(ctx, utils.HashToken(mfaToken), model.VerificationType2FA)
+	if err != nil || verification.UserID != userID {
+		return nil, nil, ErrInvalidToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+	return verification, user, nil
+}
+
+// failMFAAttempt counts a wrong code against the verification and burns it once the limit is reached
+func (s *AuthServiceImpl) failMFAAttempt(ctx context.Context, verification *model.Verification) error {
+	return s.failCodeAttempt(ctx, verification, maxMFAAttempts)
+}
+
+// failCodeAttempt counts a wrong code against the verification and burns it after maxAttempts
+func (s *AuthServiceImpl) failCodeAttempt(ctx context.Context, verification *model.Verification, maxAttempts int) error {
+	attempts, err := s.repo.IncrementVerificationAttempts(ctx, verification.ID)
+	if err != nil {
+		return err
+	}
+	if attempts >= maxAttempts {
+		if err := s.repo.UpdateVerificationStatus(ctx, verification.ID, model.VerificationStatusFailed); err != nil {
+			return err
+		}
+		return ErrTooManyAttempts
+	}
+	return ErrInvalidCode
+}
+
+// BeginTOTPEnrollment generates a new TOTP secret for the user and returns it with its otpauth:// URI.
+// The secret only becomes active once ConfirmTOTPEnrollment receives a valid code for it.
+func (s *AuthServiceImpl) BeginTOTPEnrollment(ctx context.Context, userID string) (string, string, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return "", "", ErrUserNotFound
+	}
+	user, err := s.repo.GetUserByID(ctx, uid)
+	if err != nil {
+		return "", "", ErrUserNotFound
+	}
+	if user.MFAEnabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := utils.EncryptSecret(secret, s.config.MFA.EncryptionKey, uid.String())
+	if err != nil {
+		return "", "", err
+	}
+
+	verification := &model.Verification{
+		UserID:    uid,
+		Type:      model.VerificationTypeTOTPEnroll,
+		Token:     &sealed,
+		Status:    model.VerificationStatusPending,
+		SentAt:    time.Now(),
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.CreateVerification(ctx, verification); err != nil {
+		return "", "", err
+	}
+
+	account := derefString(user.Email)
+	if account == "" {
+		account = derefString(user.Phone)
+	}
+	return secret, utils.TOTPURI(s.mfaIssuer(ctx), account, secret), nil
+}
+
+// ConfirmTOTPEnrollment activates the pending TOTP secret once the user proves their authenticator produces codes for it,
+// and returns a fresh set of recovery codes
+func (s *AuthServiceImpl) ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.repo.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	verification, err := s.repo.GetLatestVerification(ctx, uid, model.VerificationTypeTOTPEnroll)
+	if err != nil || verification.Token == nil {
+		return nil, ErrInvalidToken
+	}
+
+	secret, _, err := utils.DecryptSecret(*verification.Token, s.config.MFA.EncryptionKey, uid.String())
+	if err != nil {
+		return nil, err
+	}
+	step, ok := utils.ValidateTOTPCode(secret, code, time.Now())
+	if !ok {
+		return nil, s.failMFAAttempt(ctx, verification)
+	}
+	// Sealed again, enrollments started before secrets were encrypted hold them in plaintext
+	sealed, err := utils.EncryptSecret(secret, s.config.MFA.EncryptionKey, uid.String())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Verification{}).
+			Where("id = ?", verification.ID).
+			Updates(map[string]interface{}{
+				"status":      model.VerificationStatusVerified,
+				"updated_at":  time.Now(),
+				"verified_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).
+			Where("id = ?", uid).
+			Updates(map[string]interface{}{
+				"mfa_enabled":    true,
+				"mfa_enabled_at": time.Now(),
+				"totp_secret":    sealed,
+				"totp_last_step": step,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.generateRecoveryCodes(ctx, uid)
+}
+
+// DisableTOTP turns the second factor off after checking a current code, unless configuration requires it
+func (s *AuthServiceImpl) DisableTOTP(ctx context.Context, userID, code string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	user, err := s.repo.GetUserByID(ctx, uid)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.MFAEnabled || user.TOTPSecret == nil {
+		return ErrMFANotEnabled
+	}
+	if s.mfaRequired(ctx, user) {
+		return ErrMFARequired
+	}
+
+	secret, err := s.totpSecret(ctx, user)
+	if err != nil {
+		return err
+	}
+	step, ok := utils.ValidateTOTPCode(secret, code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return ErrInvalidCode
+	}
+	advanced, err := s.repo.AdvanceTOTPStep(ctx, uid, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidCode
+	}
+
+	if err := s.repo.UpdateUser(ctx, uid, map[string]interface{}{
+		"mfa_enabled":    false,
+		"mfa_enabled_at": nil,
+		"totp_secret":    nil,
+		"totp_last_step": 0,
+	}); err != nil {
+		return err
+	}
+	return s.repo.DeleteRecoveryCodes(ctx, uid)
+}
+
+// totpSecret opens the user's TOTP secret. One stored before secrets were encrypted is sealed on the way, failing
+// to doesn't block the login.
+func (s *AuthServiceImpl) totpSecret(ctx context.Context, user *model.User) (string, error) {
+	secret, plaintext, err := utils.DecryptSecret(*user.TOTPSecret, s.config.MFA.EncryptionKey, user.ID.String())
+	if err != nil {
+		return "", err
+	}
+	if plaintext {
+		sealed, err := utils.EncryptSecret(secret, s.config.MFA.EncryptionKey, user.ID.String())
+		if err == nil {
+			err = s.repo.SealTOTPSecret(ctx, *user.ID, secret, sealed)
+		}
+		if err != nil {
+			logrus.Errorln("Failed to encrypt TOTP secret : ", err)
+		}
+	}
+	return secret, nil
+}
+
+// ResolveMFAToken returns the user behind a pending MFA token, used to let users that must
+// enroll do so before they hold a session
+func (s *AuthServiceImpl) ResolveMFAToken(ctx context.Context, mfaToken string) (*model.User, error) {
+	_, user, err := s.mfaChallenge(ctx, mfaToken)
+	return user, err
+}
+
+// CompleteMFAEnrollment confirms a TOTP enrollment started with a pending MFA token and completes the login
+func (s *AuthServiceImpl) CompleteMFAEnrollment(ctx context.Context, mfaToken, code string) (*model.User, []string, error) {
+	verification, user, err := s.mfaChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recoveryCodes, err := s.ConfirmTOTPEnrollment(ctx, user.ID.String(), code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err = s.completeMFAChallenge(ctx, verification, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, recoveryCodes, nil
+}
+
+// VerifyMFA exchanges a pending MFA token and a TOTP code for the logged in user
+func (s *AuthServiceImpl) VerifyMFA(ctx context.Context, mfaToken, code string) (*model.User, error) {
+	verification, user, err := s.mfaChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled || user.TOTPSecret == nil {
+		return nil, ErrMFANotEnabled
+	}
+
+	secret, err := s.totpSecret(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := utils.ValidateTOTPCode(secret, code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return nil, s.failMFAAttempt(ctx, verification)
+	}
+
+	// Conditional on the stored step, the read above may be stale when the same code arrives twice at once
+	advanced, err := s.repo.AdvanceTOTPStep(ctx, *user.ID, step)
+	if err != nil {
+		return nil, err
+	}
+	if !advanced {
+		return nil, s.failMFAAttempt(ctx, verification)
+	}
+
+	return s.completeMFAChallenge(ctx, verification, user)
+}
+
+// VerifyMFARecoveryCode exchanges a pending MFA token and a one-time recovery code for the logged in user
+func (s *AuthServiceImpl) VerifyMFARecoveryCode(ctx context.Context, mfaToken, recoveryCode string) (*model.User, error) {
+	verification, user, err := s.mfaChallenge(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	consumed, err := s.repo.ConsumeRecoveryCode(ctx, *user.ID, utils.HashRecoveryCode(recoveryCode))
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, s.failMFAAttempt(ctx, verification)
+	}
+
+	s.audit(ctx, *user.ID, model.LogEventRecoveryCodeUsed)
+
+	return s.completeMFAChallenge(ctx, verification, user)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, invalidating the previous set
+func (s *AuthServiceImpl) RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.repo.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	codes, err := s.generateRecoveryCodes(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, uid, model.LogEventRecoveryCodesRegenerate)
+	return codes, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left
+func (s *AuthServiceImpl) CountRecoveryCodes(ctx context.Context, userID string) (int64, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return 0, ErrUserNotFound
+	}
+	return s.repo.CountUnusedRecoveryCodes(ctx, uid)
+}
+
+// generateRecoveryCodes creates and stores (hashed) a new set of recovery codes, the plain codes are only ever returned here
+func (s *AuthServiceImpl) generateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]model.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, model.RecoveryCode{
+			UserID:    userID,
+			CodeHash:  utils.HashRecoveryCode(code),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// audit records a security event for the user, failures are logged and never block the flow
+func (s *AuthServiceImpl) audit(ctx context.Context, userID uuid.UUID, event string) {
+	s.writeAudit(ctx, model.NewLog(userID.String(), event, deviceFromContext(ctx).ClientIp))
+}
+
+func (s *AuthServiceImpl) writeAudit(ctx context.Context, entry model.Log) {
+	if err := s.repo.CreateLog(ctx, &entry); err != nil {
+		logrus.Errorln("Failed to write audit log : ", err)
+	}
+}
+
+// completeMFAChallenge consumes the pending token and records the login. Only one completion per token wins,
+// concurrent ones with a valid recovery code or enrollment get ErrInvalidToken.
+func (s *AuthServiceImpl) completeMFAChallenge(ctx context.Context, verification *model.Verification, user *model.User) (*model.User, error) {
+	consumed, err := s.repo.ConsumeVerification(ctx, verification.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidToken
+	}
+
+	if err := s.repo.UpdateLastLogin(ctx, *user.ID); err != nil {
+		logrus.Errorln("Failed to update last login : ", err)
+	}
+
+	// Reload so callers see the state the enrollment may have just changed
+	return s.repo.GetUserByID(ctx, *user.ID)
+}
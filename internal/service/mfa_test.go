@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// mfaJWT accepts any pending MFA token as the user's
+type mfaJWT struct {
+	JWTService
+	userID uuid.UUID
+}
+
+func (j *mfaJWT) ValidateMFAToken(string) (uuid.UUID, error) {
+	return j.userID, nil
+}
+
+func mfaConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.MFA.EncryptionKey = "a-long-random-secret"
+	return cfg
+}
+
+func currentTOTPCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := utils.GenerateTOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// storedTOTPSecret returns the totp_secret column last written, opened with the configured key
+func storedTOTPSecret(t *testing.T, db *fakeDB, cfg *config.Config, userID uuid.UUID) string {
+	t.Helper()
+	for i := len(db.updated) - 1; i >= 0; i-- {
+		if stored, ok := db.updated[i]["totp_secret"].(string); ok {
+			secret, plaintext, err := utils.DecryptSecret(stored, cfg.MFA.EncryptionKey, userID.String())
+			if err != nil || plaintext {
+				t.Fatalf("stored TOTP secret %q isn't sealed for the user: %v", stored, err)
+			}
+			return secret
+		}
+	}
+	t.Fatal("no TOTP secret stored")
+	return ""
+}
+
+func TestTOTPEnrollmentSealsSecret(t *testing.T) {
+	cfg := mfaConfig()
+	userID := uuid.New()
+	email := "jane@example.com"
+	user := &model.User{ID: &userID, Email: &email}
+	ctx := context.Background()
+
+	repo, db := newFakeRepo(t, user)
+	s := &AuthServiceImpl{repo: repo, config: cfg}
+	secret, _, err := s.BeginTOTPEnrollment(ctx, userID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enrollment *model.Verification
+	for _, created := range db.created {
+		if verification, ok := created.(*model.Verification); ok {
+			enrollment = verification
+		}
+	}
+	if enrollment == nil || enrollment.Token == nil || strings.Contains(*enrollment.Token, secret) {
+		t.Fatalf("enrollment stored %v, want the secret sealed", enrollment)
+	}
+
+	repo, db = newFakeRepo(t, user, enrollment)
+	s.repo = repo
+	if _, err := s.ConfirmTOTPEnrollment(ctx, userID.String(), currentTOTPCode(t, secret)); err != nil {
+		t.Fatal(err)
+	}
+	if got := storedTOTPSecret(t, db, cfg, userID); got != secret {
+		t.Errorf("stored TOTP secret opens to %q, want the enrolled %q", got, secret)
+	}
+}
+
+func TestVerifyMFASealsPlaintextSecret(t *testing.T) {
+	cfg := mfaConfig()
+	userID := uuid.New()
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := utils.EncryptSecret(secret, cfg.MFA.EncryptionKey, userID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSealed, err := utils.EncryptSecret(secret, cfg.MFA.EncryptionKey, uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		stored string
+		sealed bool // whether the stored value gets sealed
+		ok     bool
+	}{
+		{"sealed", sealed, false, true},
+		{"stored before encryption", secret, true, true},
+		{"sealed for another user", otherSealed, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored, token := tt.stored, utils.HashToken("mfa-token")
+			repo, db := newFakeRepo(t,
+				&model.User{ID: &userID, MFAEnabled: true, TOTPSecret: &stored},
+				&model.Verification{ID: uuid.New(), UserID: userID, Type: model.VerificationType2FA, Token: &token,
+					Status: model.VerificationStatusPending, ExpiresAt: time.Now().Add(time.Minute)},
+			)
+			s := &AuthServiceImpl{repo: repo, config: cfg, jwtService: &mfaJWT{userID: userID}}
+
+			_, err := s.VerifyMFA(context.Background(), "mfa-token", currentTOTPCode(t, secret))
+			if (err == nil) != tt.ok {
+				t.Fatalf("VerifyMFA = %v, want success %v", err, tt.ok)
+			}
+			resealed := false
+			for _, updates := range db.updated {
+				if _, ok := updates["totp_secret"]; ok {
+					resealed = true
+				}
+			}
+			if resealed != tt.sealed {
+				t.Fatalf("secret sealed = %v, want %v", resealed, tt.sealed)
+			}
+			if tt.sealed {
+				if got := storedTOTPSecret(t, db, cfg, userID); got != secret {
+					t.Errorf("sealed secret opens to %q, want %q", got, secret)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyMFACodeAcceptedOnce(t *testing.T) {
+	cfg := mfaConfig()
+	userID := uuid.New()
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := utils.EncryptSecret(secret, cfg.MFA.EncryptionKey, userID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge := func(token string) *model.Verification {
+		hash := utils.HashToken(token)
+		return &model.Verification{ID: uuid.New(), UserID: userID, Type: model.VerificationType2FA, Token: &hash,
+			Status: model.VerificationStatusPending, ExpiresAt: time.Now().Add(time.Minute)}
+	}
+	first, second := challenge("mfa-token"), challenge("other-token")
+	repo, _ := newFakeRepo(t, &model.User{ID: &userID, MFAEnabled: true, TOTPSecret: &sealed}, first, second)
+	s := &AuthServiceImpl{repo: repo, config: cfg, jwtService: &mfaJWT{userID: userID}}
+	ctx := context.Background()
+
+	code := currentTOTPCode(t, secret)
+	if _, err := s.VerifyMFA(ctx, "mfa-token", code); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyMFA(ctx, "mfa-token", code); err != ErrInvalidToken {
+		t.Errorf("replayed challenge = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := s.VerifyMFA(ctx, "other-token", code); err != ErrInvalidCode {
+		t.Errorf("replayed code = %v, want %v", err, ErrInvalidCode)
+	}
+
+	// A request that read the state before the first one wrote it loses the conditional writes
+	user, err := repo.GetUserByID(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if advanced, err := repo.AdvanceTOTPStep(ctx, userID, user.TOTPLastStep); err != nil || advanced {
+		t.Errorf("AdvanceTOTPStep to a used step = %v, %v, want false", advanced, err)
+	}
+	if consumed, err := repo.ConsumeVerification(ctx, first.ID); err != nil || consumed {
+		t.Errorf("ConsumeVerification of a used challenge = %v, %v, want false", consumed, err)
+	}
+	if consumed, err := repo.ConsumeVerification(ctx, second.ID); err != nil || !consumed {
+		t.Errorf("ConsumeVerification of a pending challenge = %v, %v, want true", consumed, err)
+	}
+}
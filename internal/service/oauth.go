@@ -0,0 +1,372 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/auth/oauth"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// oauthStateTimeout bounds the round trip through the provider's consent page
+const oauthStateTimeout = 10 * time.Minute
+
+// oauthState is what a pending authorization remembers between start and callback
+type oauthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+// newOAuthProviders builds every enabled built-in and custom provider. A custom provider that can't be
+// discovered is logged and left out rather than failing startup.
+func newOAuthProviders(cfg *config.Config) map[string]oauth.Provider {
+	callbackURL := func(name string) string {
+		return cfg.InstanceUrl + "/api/v1/auth/oauth/" + name + "/callback"
+	}
+
+	providers := map[string]oauth.Provider{}
+	for name, social := range map[string]config.SocialAuthConfig{
+		enum.GoogleLogin:   cfg.Google,
+		enum.GithubLogin:   cfg.Github,
+		enum.LinkedinLogin: cfg.Linkedin,
+		enum.FacebookLogin: cfg.Facebook,
+		enum.TwitterLogin:  cfg.Twitter,
+		enum.SlackLogin:    cfg.Slack,
+		enum.DiscordLogin:  cfg.Discord,
+	} {
+		if !social.Enabled {
+			continue
+		}
+		provider, err := oauth.NewBuiltin(name, social, callbackURL(name))
+		if err != nil {
+			logrus.Errorf("Skipping oauth provider %s: %v", name, err)
+			continue
+		}
+		providers[name] = provider
+	}
+
+	for _, custom := range cfg.CustomOIDC {
+		if !custom.Enabled {
+			continue
+		}
+		if oauth.IsBuiltin(custom.Name) {
+			logrus.Errorf("Skipping custom oidc provider %s: name is reserved", custom.Name)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		provider, err := oauth.DiscoverOIDCProvider(ctx, custom.Name, custom.SocialAuthConfig, callbackURL(custom.Name))
+		cancel()
+		if err != nil {
+			logrus.Errorf("Skipping custom oidc provider %s: %v", custom.Name, err)
+			continue
+		}
+		providers[custom.Name] = provider
+	}
+	return providers
+}
+
+// OAuthProviders lists the providers enabled for the request's tenant in a stable order
+func (s *AuthServiceImpl) OAuthProviders(ctx context.Context) []string {
+	names := make([]string, 0, len(s.oauth))
+	for name := range s.oauth {
+		if s.providerEnabled(ctx, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOAuth stores a fresh state, PKCE verifier and nonce and returns the consent URL
+func (s *AuthServiceImpl) BeginOAuth(ctx context.Context, provider string) (string, string, error) {
+	return s.beginOAuth(ctx, uuid.Nil, provider)
+}
+
+// BeginOAuthLink starts a provider round trip that links the resulting identity to the signed in user
+func (s *AuthServiceImpl) BeginOAuthLink(ctx context.Context, userID, provider string) (string, string, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return "", "", ErrUserNotFound
+	}
+	return s.beginOAuth(ctx, uid, provider)
+}
+
+// beginOAuth records the pending authorization, the state's user is set only when linking
+func (s *AuthServiceImpl) beginOAuth(ctx context.Context, userID uuid.UUID, provider string) (string, string, error) {
+	oauthProvider, ok := s.oauth[provider]
+	if !ok || !s.providerEnabled(ctx, provider) {
+		return "", "", ErrProviderDisabled
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	pending := oauthState{
+		Provider:     provider,
+		CodeVerifier: oauth.GenerateVerifier(),
+		Nonce:        nonce,
+	}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return "", "", err
+	}
+	serialized := string(data)
+	hashedState := utils.HashToken(state)
+
+	verification := &model.Verification{
+		UserID:    userID,
+		Type:      model.VerificationTypeOAuthState,
+		Token:     &hashedState,
+		Data:      &serialized,
+		Status:    model.VerificationStatusPending,
+		SentAt:    time.Now(),
+		ExpiresAt: time.Now().Add(oauthStateTimeout),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.CreateVerification(ctx, verification); err != nil {
+		return "", "", err
+	}
+
+	return oauthProvider.AuthCodeURL(state, pending.CodeVerifier, pending.Nonce), state, nil
+}
+
+// CompleteOAuth consumes the state and redeems the code. A state started by BeginOAuthLink links the identity
+// to its user, otherwise the matching user is signed in and the account created on first login.
+func (s *AuthServiceImpl) CompleteOAuth(ctx context.Context, provider, state, code string) (*model.OAuthResult, error) {
+	oauthProvider, ok := s.oauth[provider]
+	if !ok || !s.providerEnabled(ctx, provider) {
+		return nil, ErrProviderDisabled
+	}
+
+	verification, err := s.repo.GetVerificationByToken(ctx, utils.HashToken(state), model.VerificationTypeOAuthState)
+	if err != nil || verification.Data == nil {
+		return nil, ErrInvalidToken
+	}
+	consumed, err := s.repo.ConsumeVerification(ctx, verification.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidToken
+	}
+
+	var pending oauthState
+	if err := json.Unmarshal([]byte(*verification.Data), &pending); err != nil {
+		return nil, err
+	}
+	if pending.Provider != provider {
+		return nil, ErrInvalidToken
+	}
+
+	profile, err := oauthProvider.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		logrus.Errorf("OAuth exchange with %s failed: %v", provider, err)
+		return nil, ErrOAuthExchange
+	}
+
+	if verification.UserID != uuid.Nil {
+		user, err := s.linkIdentity(ctx, verification.UserID, profile)
+		if err != nil {
+			return nil, err
+		}
+		return &model.OAuthResult{User: user, Linked: true}, nil
+	}
+
+	user, err := s.oauthUser(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabled || s.mfaRequired(ctx, user) {
+		mfaToken, err := s.createMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return &model.OAuthResult{User: user, MFAToken: mfaToken}, nil
+	}
+
+	if err := s.repo.UpdateLastLogin(ctx, *user.ID); err != nil {
+		return nil, err
+	}
+
+	return &model.OAuthResult{User: user}, nil
+}
+
+// oauthUser resolves the provider profile to a local account: a linked identity wins, then an existing account
+// with the same email (only under the auto-link policy), otherwise a new account is created. Only a
+// provider-verified email may create or join an account, otherwise anyone could claim an address they don't own.
+// The same goes for the local account: one that never verified the email may have been registered by someone
+// else ahead of its owner.
+func (s *AuthServiceImpl) oauthUser(ctx context.Context, profile *oauth.Profile) (*model.User, error) {
+	identity, err := s.repo.GetIdentity(ctx, profile.Provider, profile.Subject)
+	if err == nil {
+		if err := s.repo.TouchIdentity(ctx, identity.ID, profile.Email); err != nil {
+			logrus.Errorln("Failed to update identity : ", err)
+		}
+		user, err := s.repo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		return user, nil
+	}
+
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, profile.Email)
+	if err == nil {
+		// Accounts created by this provider before identities were recorded are linked on their next sign in
+		if !s.config.AutoLinkVerifiedEmail && user.SignUpMethod != profile.Provider {
+			return nil, ErrAccountExists
+		}
+		if !user.IsEmailVerified {
+			return nil, ErrAccountExists
+		}
+		if _, err := s.linkIdentity(ctx, *user.ID, profile); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if tenantConfig(ctx, s.config).DisableSignup {
+		return nil, ErrSignupDisabled
+	}
+
+	now := time.Now()
+	name := truncate(profile.Name, 25)
+	user = &model.User{
+		Name:            &name,
+		Email:           &profile.Email,
+		SignUpMethod:    profile.Provider,
+		Role:            "user", // Default role
+		IsEmailVerified: true,
+		EmailVerifiedAt: &now,
+		TenantID:        tenantIDFromContext(ctx),
+	}
+	if profile.Picture != "" {
+		user.ProfilePicture = &profile.Picture
+	}
+	err = s.repo.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(newIdentity(*user.ID, profile)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// linkIdentity attaches the provider account to the user, it can't already belong to someone else
+func (s *AuthServiceImpl) linkIdentity(ctx context.Context, userID uuid.UUID, profile *oauth.Profile) (*model.User, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	existing, err := s.repo.GetIdentity(ctx, profile.Provider, profile.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityInUse
+		}
+		return user, nil
+	}
+
+	if err := s.repo.CreateIdentity(ctx, newIdentity(userID, profile)); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, userID, model.LogEventIdentityLinked)
+	return user, nil
+}
+
+func newIdentity(userID uuid.UUID, profile *oauth.Profile) *model.Identity {
+	identity := &model.Identity{
+		UserID:   userID,
+		Provider: profile.Provider,
+		Subject:  profile.Subject,
+		LinkedAt: time.Now(),
+	}
+	if profile.Email != "" {
+		identity.Email = &profile.Email
+	}
+	return identity
+}
+
+// ListIdentities lists the external identities linked to the user
+func (s *AuthServiceImpl) ListIdentities(ctx context.Context, userID string) ([]model.Identity, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListIdentities(ctx, uid)
+}
+
+// UnlinkIdentity removes a linked identity unless it is the user's last way to sign in
+func (s *AuthServiceImpl) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	id, err := uuid.Parse(identityID)
+	if err != nil {
+		return ErrIdentityNotFound
+	}
+
+	methods, err := s.loginMethods(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if methods <= 1 {
+		return ErrLastLoginMethod
+	}
+
+	if err := s.repo.DeleteIdentity(ctx, uid, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrIdentityNotFound
+		}
+		return err
+	}
+	s.audit(ctx, uid, model.LogEventIdentityUnlinked)
+	return nil
+}
+
+// loginMethods counts the ways the user can sign in: a password, each passkey and each linked identity
+func (s *AuthServiceImpl) loginMethods(ctx context.Context, userID uuid.UUID) (int, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, ErrUserNotFound
+	}
+	identities, err := s.repo.ListIdentities(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	credentials, err := s.repo.ListWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	methods := len(identities) + len(credentials)
+	if user.Password != nil {
+		methods++
+	}
+	return methods, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/auth/oauth"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model/enum"
+)
+
+func TestNewOAuthProviders(t *testing.T) {
+	unreachable := httptest.NewServer(nil)
+	unreachable.Close()
+
+	enabled := config.SocialAuthConfig{Enabled: true, ClientID: "client-id", SecretID: "secret"}
+	cfg := config.DefaultConfig()
+	cfg.Github = enabled
+	cfg.Slack = enabled
+	cfg.Discord = config.SocialAuthConfig{ClientID: "client-id"}
+	cfg.CustomOIDC = []config.CustomOIDCConfig{
+		{Name: "corp", SocialAuthConfig: config.SocialAuthConfig{Enabled: true, ClientID: "client-id",
+			Issuer: "https://idp.corp.example.com", AuthURL: "https://idp.corp.example.com/authorize",
+			TokenURL: "https://idp.corp.example.com/token", JWKSURL: "https://idp.corp.example.com/jwks"}},
+		{Name: enum.GoogleLogin, SocialAuthConfig: config.SocialAuthConfig{Enabled: true, ClientID: "client-id", Issuer: "https://idp.corp.example.com"}},
+		{Name: "partner", SocialAuthConfig: config.SocialAuthConfig{Enabled: true, ClientID: "client-id", Issuer: unreachable.URL}},
+		{Name: "retired", SocialAuthConfig: config.SocialAuthConfig{ClientID: "client-id", Issuer: unreachable.URL}},
+	}
+
+	s := &AuthServiceImpl{config: cfg, oauth: newOAuthProviders(cfg)}
+	// Disabled providers, custom ones shadowing a built-in name and ones that can't be discovered are left out
+	want := []string{"corp", enum.GithubLogin, enum.SlackLogin}
+	if got := s.OAuthProviders(context.Background()); !reflect.DeepEqual(got, want) {
+		t.Errorf("OAuthProviders = %v, want %v", got, want)
+	}
+}
+
+func TestOAuthProvidersOfTenant(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig(), oauth: map[string]oauth.Provider{
+		enum.GithubLogin: nil,
+		enum.GoogleLogin: nil,
+		"corp":           nil,
+	}}
+
+	tests := []struct {
+		name    string
+		tenant  *model.Tenant
+		want    []string
+		allowed string
+		refused string
+	}{
+		{"no tenant", nil, []string{"corp", enum.GithubLogin, enum.GoogleLogin}, "corp", "facebook"},
+		{"tenant keeping every provider", &model.Tenant{}, []string{"corp", enum.GithubLogin, enum.GoogleLogin}, "corp", "facebook"},
+		{"tenant narrowing providers", &model.Tenant{Settings: model.TenantSettings{EnabledProviders: []string{enum.GoogleLogin, "facebook"}}},
+			[]string{enum.GoogleLogin}, enum.GoogleLogin, "facebook"},
+		{"tenant disabling providers", &model.Tenant{Settings: model.TenantSettings{EnabledProviders: []string{}}}, []string{}, "", enum.GoogleLogin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.tenant != nil {
+				ctx = context.WithValue(ctx, "tenant", tt.tenant)
+			}
+			if got := s.OAuthProviders(ctx); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OAuthProviders = %v, want %v", got, tt.want)
+			}
+			if tt.allowed != "" && !s.providerEnabled(ctx, tt.allowed) {
+				t.Errorf("%s refused", tt.allowed)
+			}
+			if _, _, err := s.BeginOAuth(ctx, tt.refused); err != ErrProviderDisabled {
+				t.Errorf("BeginOAuth(%s) = %v, want %v", tt.refused, err, ErrProviderDisabled)
+			}
+			if _, err := s.CompleteOAuth(ctx, tt.refused, "state", "code"); err != ErrProviderDisabled {
+				t.Errorf("CompleteOAuth(%s) = %v, want %v", tt.refused, err, ErrProviderDisabled)
+			}
+		})
+	}
+}
+
+func TestIdentityIDsValidated(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig(), oauth: map[string]oauth.Provider{enum.GithubLogin: nil}}
+	ctx := context.Background()
+
+	if _, _, err := s.BeginOAuthLink(ctx, "not-a-uuid", enum.GithubLogin); err != ErrUserNotFound {
+		t.Errorf("BeginOAuthLink with a malformed user ID = %v, want %v", err, ErrUserNotFound)
+	}
+	if _, _, err := s.BeginOAuthLink(ctx, uuid.NewString(), "facebook"); err != ErrProviderDisabled {
+		t.Errorf("BeginOAuthLink to a disabled provider = %v, want %v", err, ErrProviderDisabled)
+	}
+	if _, err := s.ListIdentities(ctx, "not-a-uuid"); err != ErrUserNotFound {
+		t.Errorf("ListIdentities with a malformed user ID = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := s.UnlinkIdentity(ctx, "not-a-uuid", uuid.NewString()); err != ErrUserNotFound {
+		t.Errorf("UnlinkIdentity with a malformed user ID = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := s.UnlinkIdentity(ctx, uuid.NewString(), "not-a-uuid"); err != ErrIdentityNotFound {
+		t.Errorf("UnlinkIdentity with a malformed identity ID = %v, want %v", err, ErrIdentityNotFound)
+	}
+}
+
+func TestNewIdentity(t *testing.T) {
+	userID := uuid.New()
+
+	identity := newIdentity(userID, &oauth.Profile{Provider: enum.GithubLogin, Subject: "42", Email: "jane@example.com"})
+	if identity.UserID != userID || identity.Provider != enum.GithubLogin || identity.Subject != "42" || identity.LinkedAt.IsZero() {
+		t.Errorf("identity = %+v", identity)
+	}
+	if identity.Email == nil || *identity.Email != "jane@example.com" {
+		t.Errorf("identity email = %v, want the profile's", identity.Email)
+	}
+
+	if identity := newIdentity(userID, &oauth.Profile{Provider: enum.TwitterLogin, Subject: "3"}); identity.Email != nil {
+		t.Errorf("identity email = %q, want none when the provider shares none", *identity.Email)
+	}
+}
+
+func TestOAuthUserAutoLinkNeedsVerifiedAccount(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AutoLinkVerifiedEmail = true
+	profile := &oauth.Profile{Provider: enum.GoogleLogin, Subject: "42", Email: "jane@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name         string
+		signUpMethod string
+		verified     bool
+		want         error
+	}{
+		{"verified account", enum.RegularSignUp, true, nil},
+		{"unverified account", enum.RegularSignUp, false, ErrAccountExists},
+		{"unverified account of the provider", enum.GoogleLogin, false, ErrAccountExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			repo, db := newFakeRepo(t, &model.User{ID: &userID, Email: &profile.Email, SignUpMethod: tt.signUpMethod, IsEmailVerified: tt.verified})
+			s := &AuthServiceImpl{repo: repo, config: cfg}
+
+			user, err := s.oauthUser(context.Background(), profile)
+			if err != tt.want {
+				t.Fatalf("oauthUser = %v, want %v", err, tt.want)
+			}
+			linked := false
+			for _, created := range db.created {
+				if identity, ok := created.(*model.Identity); ok && identity.UserID == userID {
+					linked = true
+				}
+			}
+			if linked != (tt.want == nil) {
+				t.Errorf("identity linked = %v, want %v", linked, tt.want == nil)
+			}
+			if tt.want == nil && *user.ID != userID {
+				t.Errorf("signed in as %s, want the linked account %s", user.ID, userID)
+			}
+		})
+	}
+}
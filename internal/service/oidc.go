@@ -0,0 +1,528 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// supportedScopes are the OpenID Connect scopes clients can be granted
+var supportedScopes = []string{"openid", "profile", "email", "phone"}
+
+// scopePattern is the RFC 6749 scope-token syntax, custom API scopes like "reports:read" must match it
+var scopePattern = regexp.MustCompile(`^[\x21\x23-\x5B\x5D-\x7E]+$`)
+
+// OpenIDConfiguration returns the discovery document
+func (s *AuthServiceImpl) OpenIDConfiguration() (map[string]interface{}, error) {
+	if !s.config.OIDC.Enabled {
+		return nil, ErrOIDCDisabled
+	}
+	issuer := s.config.OIDC.Issuer
+	return map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/oauth/authorize",
+		"token_endpoint":                        issuer + "/oauth/token",
+		"introspection_endpoint":                issuer + "/oauth/introspect",
+		"revocation_endpoint":                   issuer + "/oauth/revoke",
+		"userinfo_endpoint":                     issuer + "/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{model.GrantAuthorizationCode, model.GrantClientCredentials},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{s.config.JWT.Alg},
+		"scopes_supported":                      supportedScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported": []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "azp",
+			"name", "picture", "email", "email_verified", "phone_number", "phone_number_verified",
+		},
+	}, nil
+}
+
+// ValidateAuthorizeRequest checks the client and redirect URI first, nothing can be redirected before they are
+// trusted, then the rest of the request. PKCE with S256 is required from every client.
+func (s *AuthServiceImpl) ValidateAuthorizeRequest(ctx context.Context, req *model.AuthorizeRequest) (*model.Client, error) {
+	if !s.config.OIDC.Enabled {
+		return nil, ErrOIDCDisabled
+	}
+
+	client, err := s.repo.GetClientByClientID(ctx, req.ClientID)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return nil, ErrInvalidRedirectURI
+	}
+	if !client.AllowsGrant(model.GrantAuthorizationCode) {
+		return client, ErrUnauthorizedClient
+	}
+
+	if req.ResponseType != "code" {
+		return client, ErrUnsupportedResponse
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return client, ErrInvalidRequest
+	}
+	scopes := strings.Fields(req.Scope)
+	if !model.ScopesCover(scopes, []string{"openid"}) || !client.AllowsScopes(scopes) {
+		return client, ErrInvalidScope
+	}
+	return client, nil
+}
+
+// Authorize issues a code straight away when the user already consented to every requested scope
+func (s *AuthServiceImpl) Authorize(ctx context.Context, userID, sessionID string, req *model.AuthorizeRequest) (string, error) {
+	if _, err := s.ValidateAuthorizeRequest(ctx, req); err != nil {
+		return "", err
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+
+	if strings.Contains(req.Prompt, "consent") {
+		return "", ErrConsentRequired
+	}
+	consent, err := s.repo.GetConsent(ctx, uid, req.ClientID)
+	if err != nil || !model.ScopesCover(strings.Fields(consent.Scopes), strings.Fields(req.Scope)) {
+		return "", ErrConsentRequired
+	}
+
+	return s.issueAuthorizationCode(ctx, uid, sessionID, req)
+}
+
+// GrantConsent records the user's answer for the client and returns where to send the browser next
+func (s *AuthServiceImpl) GrantConsent(ctx context.Context, userID, sessionID string, req *model.AuthorizeRequest, approve bool) (string, error) {
+	if _, err := s.ValidateAuthorizeRequest(ctx, req); err != nil {
+		return "", err
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+
+	if !approve {
+		return AuthorizeErrorRedirect(req, "access_denied"), nil
+	}
+
+	if err := s.repo.SaveConsent(ctx, uid, req.ClientID, strings.Join(strings.Fields(req.Scope), " ")); err != nil {
+		return "", err
+	}
+	return s.issueAuthorizationCode(ctx, uid, sessionID, req)
+}
+
+// issueAuthorizationCode stores a single-use code, hashed like every other one-time token, and returns the
+// redirect carrying it
+func (s *AuthServiceImpl) issueAuthorizationCode(ctx context.Context, userID uuid.UUID, sessionID string, req *model.AuthorizeRequest) (string, error) {
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return "", ErrSessionNotFound
+	}
+	session, err := s.repo.GetSessionByID(ctx, sid)
+	if err != nil || session.RevokedAt != nil || session.UserID != userID {
+		return "", ErrSessionRevoked
+	}
+
+	code, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(model.AuthorizationCode{
+		ClientID:      req.ClientID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		SessionID:     session.ID.String(),
+		AuthTime:      session.CreatedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	serialized := string(data)
+	hashedCode := utils.HashToken(code)
+
+	verification := &model.Verification{
+		UserID:    userID,
+		Type:      model.VerificationTypeOIDCCode,
+		Token:     &hashedCode,
+		Data:      &serialized,
+		Status:    model.VerificationStatusPending,
+		SentAt:    time.Now(),
+		ExpiresAt: time.Now().Add(s.config.OIDC.CodeExpiry * time.Second),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.CreateVerification(ctx, verification); err != nil {
+		return "", err
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return withQuery(req.RedirectURI, params), nil
+}
+
+// ExchangeAuthorizationCode redeems a code for an access token and, for the openid scope, an ID token
+func (s *AuthServiceImpl) ExchangeAuthorizationCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string) (*model.TokenResponse, error) {
+	if !s.config.OIDC.Enabled {
+		return nil, ErrOIDCDisabled
+	}
+
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsGrant(model.GrantAuthorizationCode) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	verification, err := s.repo.GetVerificationByToken(ctx, utils.HashToken(code), model.VerificationTypeOIDCCode)
+	if err != nil || verification.Data == nil {
+		return nil, ErrInvalidGrant
+	}
+	consumed, err := s.repo.ConsumeVerification(ctx, verification.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidGrant
+	}
+
+	var grant model.AuthorizationCode
+	if err := json.Unmarshal([]byte(*verification.Data), &grant); err != nil {
+		return nil, err
+	}
+	if grant.ClientID != client.ClientID || grant.RedirectURI != redirectURI || !verifyPKCE(grant.CodeChallenge, codeVerifier) {
+		return nil, ErrInvalidGrant
+	}
+
+	sessionID, err := uuid.Parse(grant.SessionID)
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil || session.RevokedAt != nil {
+		return nil, ErrInvalidGrant
+	}
+	user, err := s.repo.GetUserByID(ctx, verification.UserID)
+	if err != nil || s.CheckAccountStatus(user) != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	accessToken, err := s.jwtService.GenerateClientAccessToken(user, session.ID, client.ClientID, grant.Scope)
+	if err != nil {
+		return nil, err
+	}
+	response := &model.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.config.JWT.Exp * 60),
+		Scope:       grant.Scope,
+	}
+
+	scopes := strings.Fields(grant.Scope)
+	if model.ScopesCover(scopes, []string{"openid"}) {
+		response.IDToken, err = s.jwtService.GenerateIDToken(user, client.ClientID, grant.Nonce, grant.AuthTime, scopes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// IssueClientCredentialsToken issues a token to a confidential client acting on its own behalf. The requested
+// scope has to be a subset of the client's, an empty one grants all of them. Only the scopes the client's owner
+// still holds are granted, and a client whose owner is gone or no longer active gets no token.
+func (s *AuthServiceImpl) IssueClientCredentialsToken(ctx context.Context, clientID, clientSecret, scope string) (*model.TokenResponse, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if client.IsPublic() || !client.AllowsGrant(model.GrantClientCredentials) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		scopes = strings.Fields(client.Scopes)
+	}
+	if !client.AllowsScopes(scopes) {
+		return nil, ErrInvalidScope
+	}
+	owned, err := s.clientOwnerPermissions(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	scopes = narrowClientScopes(scopes, owned)
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	granted := strings.Join(scopes, " ")
+
+	accessToken, err := s.jwtService.GenerateClientCredentialsToken(client.ClientID, granted)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.config.JWT.Exp * 60),
+		Scope:       granted,
+	}, nil
+}
+
+// AuthenticateClientToken resolves a client credentials token to the request context of its client. Like an API
+// key's, the token's scopes are narrowed to what the client's owner can still do whenever it is used.
+func (s *AuthServiceImpl) AuthenticateClientToken(ctx context.Context, info *model.TokenInfo) (*model.UserCtxData, error) {
+	client, err := s.repo.GetClientByClientID(ctx, info.ClientID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	owned, err := s.clientOwnerPermissions(ctx, client)
+	if err != nil {
+		if err == ErrUnauthorizedClient {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	return &model.UserCtxData{
+		ClientID:    client.ClientID,
+		Permissions: narrowClientScopes(strings.Fields(info.Scope), owned),
+	}, nil
+}
+
+// clientOwnerPermissions returns what the user who created the client can do now, a client acting on its own
+// behalf never does more. Clients without an owner, or whose owner is gone or no longer active, can't act.
+func (s *AuthServiceImpl) clientOwnerPermissions(ctx context.Context, client *model.Client) ([]string, error) {
+	if client.OwnerID == nil {
+		return nil, ErrUnauthorizedClient
+	}
+	owner, err := s.repo.GetUserByID(ctx, *client.OwnerID)
+	if err != nil || s.CheckAccountStatus(owner) != nil {
+		return nil, ErrUnauthorizedClient
+	}
+	_, owned, err := s.GetUserAccess(ctx, owner)
+	return owned, err
+}
+
+// narrowClientScopes keeps the OpenID Connect scopes and the permissions the owner still has
+func narrowClientScopes(scopes, owned []string) []string {
+	narrowed := []string{}
+	for _, scope := range scopes {
+		if model.ScopesCover(supportedScopes, []string{scope}) || model.ScopesCover(owned, []string{scope}) {
+			narrowed = append(narrowed, scope)
+		}
+	}
+	return narrowed
+}
+
+// authenticateClient checks the secret of confidential clients, public clients send none
+func (s *AuthServiceImpl) authenticateClient(ctx context.Context, clientID, clientSecret string) (*model.Client, error) {
+	client, err := s.repo.GetClientByClientID(ctx, clientID)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	if client.IsPublic() {
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(*client.SecretHash), []byte(utils.HashToken(clientSecret))) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// UserInfo returns the claims the access token's scopes release
+func (s *AuthServiceImpl) UserInfo(ctx context.Context, accessToken string) (*model.UserInfo, error) {
+	info, err := s.inspectAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	scopes := strings.Fields(info.Scope)
+	if info.ClientID == "" || !model.ScopesCover(scopes, []string{"openid"}) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, info.UserID)
+	if err != nil || s.CheckAccountStatus(user) != nil {
+		return nil, ErrInvalidToken
+	}
+	return &model.UserInfo{
+		Subject:    user.ID.String(),
+		UserClaims: model.NewUserClaims(user, scopes),
+	}, nil
+}
+
+// CreateClient registers a client and returns its secret, which is only ever shown here. Public clients get none.
+// Authorization code clients need redirect URIs and OpenID scopes, client credentials clients need a secret and
+// get whatever API scopes they are registered with, as long as the caller holds them.
+func (s *AuthServiceImpl) CreateClient(ctx context.Context, caller *model.UserCtxData, client *model.Client, confidential bool) (string, error) {
+	grants := strings.Fields(client.GrantTypes)
+	if len(grants) == 0 {
+		grants = []string{model.GrantAuthorizationCode}
+	}
+	if client.Name == "" || !model.ScopesCover([]string{model.GrantAuthorizationCode, model.GrantClientCredentials}, grants) {
+		return "", ErrInvalidRequest
+	}
+	authorizationCode := model.ScopesCover(grants, []string{model.GrantAuthorizationCode})
+	clientCredentials := model.ScopesCover(grants, []string{model.GrantClientCredentials})
+
+	redirectURIs := strings.Fields(client.RedirectURIs)
+	if authorizationCode && len(redirectURIs) == 0 {
+		return "", ErrInvalidRequest
+	}
+	for _, uri := range redirectURIs {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Fragment != "" {
+			return "", ErrInvalidRedirectURI
+		}
+	}
+	// A client acting on its own behalf has nothing but its secret to prove who it is
+	if clientCredentials && !confidential {
+		return "", ErrInvalidRequest
+	}
+
+	scopes := strings.Fields(client.Scopes)
+	if len(scopes) == 0 {
+		if clientCredentials {
+			return "", ErrInvalidScope
+		}
+		scopes = []string{"openid", "profile", "email"}
+	}
+	for _, scope := range scopes {
+		if !scopePattern.MatchString(scope) {
+			return "", ErrInvalidScope
+		}
+	}
+	// Client credentials tokens act with their scopes as permissions, a caller can't hand out more than they hold
+	if !model.ScopesCover(caller.Permissions, apiScopes(scopes)) {
+		return "", ErrPermissionDenied
+	}
+
+	clientID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	client.ClientID = clientID
+	client.RedirectURIs = strings.Join(redirectURIs, " ")
+	client.Scopes = strings.Join(scopes, " ")
+	client.GrantTypes = strings.Join(grants, " ")
+	client.CreatedAt = time.Now()
+	client.UpdatedAt = time.Now()
+	if owner, err := uuid.Parse(caller.ID); err == nil {
+		client.OwnerID = &owner
+	}
+
+	secret := ""
+	if confidential {
+		secret, err = utils.GenerateRandomToken(32)
+		if err != nil {
+			return "", err
+		}
+		hashedSecret := utils.HashToken(secret)
+		client.SecretHash = &hashedSecret
+	}
+
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// apiScopes drops the OpenID Connect scopes, they only release the signed-in user's own claims
+func apiScopes(scopes []string) []string {
+	api := []string{}
+	for _, scope := range scopes {
+		if !model.ScopesCover(supportedScopes, []string{scope}) {
+			api = append(api, scope)
+		}
+	}
+	return api
+}
+
+func (s *AuthServiceImpl) ListClients(ctx context.Context) ([]model.Client, error) {
+	return s.repo.ListClients(ctx)
+}
+
+// DeleteClient removes a client and the consents given to it
+func (s *AuthServiceImpl) DeleteClient(ctx context.Context, clientID string) error {
+	if err := s.repo.DeleteClient(ctx, clientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrClientNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListConsents lists the clients the user approved
+func (s *AuthServiceImpl) ListConsents(ctx context.Context, userID string) ([]model.Consent, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListConsents(ctx, uid)
+}
+
+// RevokeConsent withdraws the user's approval, the client has to ask again on its next authorization
+func (s *AuthServiceImpl) RevokeConsent(ctx context.Context, userID, clientID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.repo.DeleteConsent(ctx, uid, clientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrConsentNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AuthorizeErrorRedirect returns the redirect reporting an RFC 6749 error code to the client
+func AuthorizeErrorRedirect(req *model.AuthorizeRequest, code string) string {
+	params := url.Values{"error": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return withQuery(req.RedirectURI, params)
+}
+
+// verifyPKCE checks the verifier against an S256 challenge
+func verifyPKCE(challenge, verifier string) bool {
+	if challenge == "" || verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// withQuery appends params to uri, keeping any query it already has
+func withQuery(uri string, params url.Values) string {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := parsed.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
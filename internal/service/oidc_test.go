@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	tests := []struct {
+		name      string
+		challenge string
+		verifier  string
+		ok        bool
+	}{
+		{"matching verifier", challenge, verifier, true},
+		{"wrong verifier", challenge, verifier + "x", false},
+		{"plain method", verifier, verifier, false},
+		{"padded challenge", challenge + "=", verifier, false},
+		{"missing verifier", challenge, "", false},
+		{"missing challenge", "", verifier, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPKCE(tt.challenge, tt.verifier); got != tt.ok {
+				t.Errorf("verifyPKCE(%q, %q) = %v, want %v", tt.challenge, tt.verifier, got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestWithQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		uri    string
+		params map[string][]string
+		want   string
+	}{
+		{"no query", "https://app.example.com/cb", map[string][]string{"code": {"abc"}}, "https://app.example.com/cb?code=abc"},
+		{"keeps query", "https://app.example.com/cb?tab=1", map[string][]string{"code": {"abc"}}, "https://app.example.com/cb?code=abc&tab=1"},
+		{"replaces param", "https://app.example.com/cb?code=old", map[string][]string{"code": {"new"}}, "https://app.example.com/cb?code=new"},
+		{"escapes", "https://app.example.com/cb", map[string][]string{"state": {"a b&c"}}, "https://app.example.com/cb?state=a+b%26c"},
+		{"unparsable", "://bad", map[string][]string{"code": {"abc"}}, "://bad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withQuery(tt.uri, tt.params); got != tt.want {
+				t.Errorf("withQuery = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeErrorRedirect(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *model.AuthorizeRequest
+		want string
+	}{
+		{"with state", &model.AuthorizeRequest{RedirectURI: "https://app.example.com/cb", State: "xyz"}, "https://app.example.com/cb?error=access_denied&state=xyz"},
+		{"without state", &model.AuthorizeRequest{RedirectURI: "https://app.example.com/cb"}, "https://app.example.com/cb?error=access_denied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AuthorizeErrorRedirect(tt.req, "access_denied"); got != tt.want {
+				t.Errorf("AuthorizeErrorRedirect = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExchangeAuthorizationCodeDisabled(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	if _, err := s.ExchangeAuthorizationCode(context.Background(), "client", "", "code", "https://app.example.com/cb", "verifier"); err != ErrOIDCDisabled {
+		t.Errorf("ExchangeAuthorizationCode with the provider off = %v, want %v", err, ErrOIDCDisabled)
+	}
+}
+
+func TestCreateClientScopeEscalation(t *testing.T) {
+	caller := &model.UserCtxData{ID: "8f14e45f-ceea-467f-a0e6-0a8b7a1e2c3d", Permissions: []string{"clients:write", "reports:read"}}
+
+	tests := []struct {
+		name   string
+		client *model.Client
+		want   error
+	}{
+		{"scope the caller lacks", &model.Client{Name: "etl", Scopes: "reports:read users:delete", GrantTypes: model.GrantClientCredentials}, ErrPermissionDenied},
+		{"admin scope on a code client", &model.Client{Name: "app", RedirectURIs: "https://app.example.com/cb", Scopes: "openid users:delete"}, ErrPermissionDenied},
+		{"malformed scope", &model.Client{Name: "etl", Scopes: `reports"read`, GrantTypes: model.GrantClientCredentials}, ErrInvalidScope},
+		{"no scopes for client credentials", &model.Client{Name: "etl", GrantTypes: model.GrantClientCredentials}, ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &AuthServiceImpl{config: config.DefaultConfig()}
+			if _, err := s.CreateClient(context.Background(), caller, tt.client, true); err != tt.want {
+				t.Errorf("CreateClient = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIScopes(t *testing.T) {
+	got := apiScopes([]string{"openid", "reports:read", "email", "profile", "phone", "users:delete"})
+	if len(got) != 2 || got[0] != "reports:read" || got[1] != "users:delete" {
+		t.Errorf("apiScopes = %v, want only the API scopes", got)
+	}
+	if got := apiScopes([]string{"openid", "email"}); len(got) != 0 {
+		t.Errorf("apiScopes = %v, OpenID scopes need no permission", got)
+	}
+}
+
+// rolePermission is a row of the role_permissions join table
+type rolePermission struct {
+	RoleID       uuid.UUID
+	PermissionID uuid.UUID
+}
+
+func (rolePermission) TableName() string {
+	return "role_permissions"
+}
+
+// scopeJWT mints client credentials tokens that are their scope
+type scopeJWT struct {
+	JWTService
+}
+
+func (scopeJWT) GenerateClientCredentialsToken(_, scope string) (string, error) {
+	return scope, nil
+}
+
+func TestClientCredentialsNarrowedToOwner(t *testing.T) {
+	ownerID, roleID, permissionID := uuid.New(), uuid.New(), uuid.New()
+	secret := utils.HashToken("secret")
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		owner     *model.User
+		ownerID   *uuid.UUID
+		scope     string
+		want      string
+		wantErr   error
+		wantToken error // authenticating a token the client got before
+	}{
+		{"owner lost a scope", &model.User{ID: &ownerID, Role: "etl-owner"}, &ownerID, "", "reports:read", nil, nil},
+		{"only scopes the owner lost", &model.User{ID: &ownerID, Role: "etl-owner"}, &ownerID, "users:write", "", ErrInvalidScope, nil},
+		{"owner suspended", &model.User{ID: &ownerID, Role: "etl-owner", Status: model.UserStatusSuspended}, &ownerID, "", "", ErrUnauthorizedClient, ErrInvalidToken},
+		{"owner deleted", nil, &ownerID, "", "", ErrUnauthorizedClient, ErrInvalidToken},
+		{"no owner", nil, nil, "", "", ErrUnauthorizedClient, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := []interface{}{
+				&model.Client{ClientID: "etl", SecretHash: &secret, Scopes: "reports:read users:write", GrantTypes: model.GrantClientCredentials, OwnerID: tt.ownerID},
+				&model.Role{ID: roleID, Name: "etl-owner"},
+				&model.Permission{ID: permissionID, Name: "reports:read"},
+				&rolePermission{RoleID: roleID, PermissionID: permissionID},
+			}
+			if tt.owner != nil {
+				rows = append(rows, tt.owner)
+			}
+			repo, _ := newFakeRepo(t, rows...)
+			s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig(), jwtService: scopeJWT{}}
+
+			token, err := s.IssueClientCredentialsToken(ctx, "etl", "secret", tt.scope)
+			if err != tt.wantErr {
+				t.Fatalf("IssueClientCredentialsToken = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && token.Scope != tt.want {
+				t.Errorf("granted %q, want %q", token.Scope, tt.want)
+			}
+
+			access, err := s.AuthenticateClientToken(ctx, &model.TokenInfo{ClientID: "etl", Scope: "reports:read users:write"})
+			if err != tt.wantToken {
+				t.Fatalf("AuthenticateClientToken = %v, want %v", err, tt.wantToken)
+			}
+			if err == nil && !reflect.DeepEqual(access.Permissions, []string{"reports:read"}) {
+				t.Errorf("client token permissions = %v, want the owner's reports:read only", access.Permissions)
+			}
+		})
+	}
+}
+
+func TestNarrowClientScopes(t *testing.T) {
+	got := narrowClientScopes([]string{"openid", "reports:read", "users:delete"}, []string{"reports:read"})
+	if !reflect.DeepEqual(got, []string{"openid", "reports:read"}) {
+		t.Errorf("narrowClientScopes = %v, want the OpenID scope and the one the owner holds", got)
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// orgInviteTokenBytes is the entropy of an invitation link token
+const orgInviteTokenBytes = 32
+
+// CreateOrganization creates an organization owned by the user
+func (s *AuthServiceImpl) CreateOrganization(ctx context.Context, userID, name string) (*model.Organization, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, ErrInvalidName
+	}
+
+	org := &model.Organization{
+		Name:      name,
+		CreatedBy: uid,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.CreateOrganization(ctx, org, uid); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, uid, model.LogEventOrgCreated)
+	return org, nil
+}
+
+// ListMemberships lists the organizations the user belongs to with their role in each
+func (s *AuthServiceImpl) ListMemberships(ctx context.Context, userID string) ([]model.Membership, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListUserMemberships(ctx, uid)
+}
+
+// GetMembership returns the user's membership, ErrNotMember when there is none
+func (s *AuthServiceImpl) GetMembership(ctx context.Context, orgID, userID string) (*model.Membership, error) {
+	oid, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, ErrNotMember
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrNotMember
+	}
+	membership, err := s.repo.GetMembership(ctx, oid, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotMember
+		}
+		return nil, err
+	}
+	return membership, nil
+}
+
+// ListMembers lists the members of an organization
+func (s *AuthServiceImpl) ListMembers(ctx context.Context, orgID string) ([]model.Membership, error) {
+	org, err := s.organizationByID(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListOrganizationMembers(ctx, org.ID)
+}
+
+// InviteMember emails a single-use invitation. The invitee accepts it signed in with the invited address, so an
+// account can be created in between.
+func (s *AuthServiceImpl) InviteMember(ctx context.Context, actorID, actorRole, orgID, email, role string) error {
+	if s.config.DisableEmail {
+		return ErrEmailDisabled
+	}
+	if !model.IsOrgRole(role) {
+		return ErrInvalidOrgRole
+	}
+	if role == model.OrgRoleOwner && actorRole != model.OrgRoleOwner {
+		return ErrPermissionDenied
+	}
+	inviterID, err := uuid.Parse(actorID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	org, err := s.organizationByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if invitee, err := s.repo.GetUserByEmail(ctx, email); err == nil {
+		if _, err := s.repo.GetMembership(ctx, org.ID, *invitee.ID); err == nil {
+			return ErrAlreadyMember
+		}
+	}
+
+	token, err := utils.GenerateRandomToken(orgInviteTokenBytes)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(model.OrgInvitation{OrganizationID: org.ID, Email: email, Role: role})
+	if err != nil {
+		return err
+	}
+
+	hashedToken := utils.HashToken(token)
+	invitation := string(data)
+	verification := &model.Verification{
+		UserID:    inviterID,
+		Type:      model.VerificationTypeOrgInvite,
+		Token:     &hashedToken,
+		Data:      &invitation,
+		Status:    model.VerificationStatusPending,
+		SentAt:    time.Now(),
+		ExpiresAt: time.Now().Add(time.Duration(s.config.OrgInviteExpiry) * time.Hour),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.repo.CreateVerification(ctx, verification); err != nil {
+		return err
+	}
+
+	inviterName := ""
+	if inviter, err := s.repo.GetUserByID(ctx, inviterID); err == nil {
+		inviterName = derefString(inviter.Name)
+	}
+	if err := s.emailService.SendOrganizationInviteEmail(ctx, email, token, org.Name, inviterName, role); err != nil {
+		return err
+	}
+	s.audit(ctx, inviterID, model.LogEventOrgMemberInvited)
+	return nil
+}
+
+// AcceptInvitation adds the signed in user to the organization they were invited to
+func (s *AuthServiceImpl) AcceptInvitation(ctx context.Context, userID, token string) (*model.Membership, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.repo.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	verification, err := s.repo.GetVerificationByToken(ctx, utils.HashToken(token), model.VerificationTypeOrgInvite)
+	if err != nil || verification.Data == nil {
+		return nil, ErrInvalidInvitation
+	}
+	var invitation model.OrgInvitation
+	if err := json.Unmarshal([]byte(*verification.Data), &invitation); err != nil {
+		return nil, ErrInvalidInvitation
+	}
+	// Only the owner of the invited address may use the link, a forwarded email isn't enough
+	if !user.IsEmailVerified || !strings.EqualFold(derefString(user.Email), invitation.Email) {
+		return nil, ErrInvitationMismatch
+	}
+	if _, err := s.repo.GetOrganizationByID(ctx, invitation.OrganizationID); err != nil {
+		return nil, ErrInvalidInvitation
+	}
+
+	consumed, err := s.repo.ConsumeVerification(ctx, verification.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidInvitation
+	}
+
+	membership := &model.Membership{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         uid,
+		Role:           invitation.Role,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.repo.AddMembership(ctx, membership); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, uid, model.LogEventOrgMemberJoined)
+	return s.repo.GetMembership(ctx, invitation.OrganizationID, uid)
+}
+
+// UpdateMemberRole changes a member's role in the organization
+func (s *AuthServiceImpl) UpdateMemberRole(ctx context.Context, actorID, actorRole, orgID, userID, role string) error {
+	if !model.IsOrgRole(role) {
+		return ErrInvalidOrgRole
+	}
+	membership, err := s.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwnership(ctx, actorRole, membership, role); err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateMembershipRole(ctx, membership.OrganizationID, membership.UserID, role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotMember
+		}
+		return err
+	}
+	s.auditAdmin(ctx, actorID, model.LogEventOrgMemberRoleChanged)
+	return nil
+}
+
+// RemoveMember removes a member, their sessions switched to the organization fall back to none
+func (s *AuthServiceImpl) RemoveMember(ctx context.Context, actorID, actorRole, orgID, userID string) error {
+	membership, err := s.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkOwnership(ctx, actorRole, membership, ""); err != nil {
+		return err
+	}
+
+	if err := s.repo.RemoveMembership(ctx, membership.OrganizationID, membership.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotMember
+		}
+		return err
+	}
+	s.auditAdmin(ctx, actorID, model.LogEventOrgMemberRemoved)
+	return nil
+}
+
+// checkOwnership guards changes to owners: only owners can make or unmake one and the last one has to stay
+func (s *AuthServiceImpl) checkOwnership(ctx context.Context, actorRole string, membership *model.Membership, newRole string) error {
+	if membership.Role != model.OrgRoleOwner && newRole != model.OrgRoleOwner {
+		return nil
+	}
+	if actorRole != model.OrgRoleOwner {
+		return ErrPermissionDenied
+	}
+	if membership.Role == model.OrgRoleOwner && newRole != model.OrgRoleOwner {
+		owners, err := s.repo.CountOrganizationOwners(ctx, membership.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if owners <= 1 {
+			return ErrLastOwner
+		}
+	}
+	return nil
+}
+
+// SwitchOrganization scopes the session to one of the user's organizations and exchanges the caller's access
+// token for one carrying the org_id claim. Refreshed tokens keep the organization.
+func (s *AuthServiceImpl) SwitchOrganization(ctx context.Context, userID, sessionID, orgID string) (string, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	user, err := s.repo.GetUserByID(ctx, uid)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	session, err := s.repo.GetSessionByID(ctx, sid)
+	if err != nil || session.UserID != uid || session.RevokedAt != nil {
+		return "", ErrSessionRevoked
+	}
+
+	var org *uuid.UUID
+	if orgID != "" {
+		membership, err := s.GetMembership(ctx, orgID, userID)
+		if err != nil {
+			return "", err
+		}
+		org = &membership.OrganizationID
+	}
+
+	if err := s.repo.SetSessionOrganization(ctx, session.ID, org); err != nil {
+		return "", err
+	}
+	session.OrganizationID = org
+
+	accessToken, err := s.generateAccessToken(ctx, user, session)
+	if err != nil {
+		return "", err
+	}
+	logrus.WithField("session", session.ID).Infoln("Switched organization : ", orgID)
+	s.audit(ctx, uid, model.LogEventOrgSwitched)
+	return accessToken, nil
+}
+
+func (s *AuthServiceImpl) organizationByID(ctx context.Context, orgID string) (*model.Organization, error) {
+	id, err := uuid.Parse(orgID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	org, err := s.repo.GetOrganizationByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return org, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestOrganizationInputValidated(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DisableEmail = false
+	s := &AuthServiceImpl{config: cfg}
+	ctx := context.Background()
+	userID, orgID := uuid.NewString(), uuid.NewString()
+
+	for _, name := range []string{"", "   ", strings.Repeat("a", 101)} {
+		if _, err := s.CreateOrganization(ctx, userID, name); err != ErrInvalidName {
+			t.Errorf("CreateOrganization(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"create for a malformed user", func() error { _, err := s.CreateOrganization(ctx, "not-a-uuid", "Acme"); return err }, ErrUserNotFound},
+		{"list for a malformed user", func() error { _, err := s.ListMemberships(ctx, "not-a-uuid"); return err }, ErrUserNotFound},
+		{"membership of a malformed organization", func() error { _, err := s.GetMembership(ctx, "acme", userID); return err }, ErrNotMember},
+		{"membership of a malformed user", func() error { _, err := s.GetMembership(ctx, orgID, "jane"); return err }, ErrNotMember},
+		{"invite to an unknown role", func() error {
+			return s.InviteMember(ctx, userID, model.OrgRoleOwner, orgID, "jane@example.com", "guest")
+		}, ErrInvalidOrgRole},
+		{"admin inviting an owner", func() error {
+			return s.InviteMember(ctx, userID, model.OrgRoleAdmin, orgID, "jane@example.com", model.OrgRoleOwner)
+		}, ErrPermissionDenied},
+		{"invite by a malformed user", func() error {
+			return s.InviteMember(ctx, "jane", model.OrgRoleOwner, orgID, "jane@example.com", model.OrgRoleMember)
+		}, ErrUserNotFound},
+		{"accept for a malformed user", func() error { _, err := s.AcceptInvitation(ctx, "jane", "token"); return err }, ErrUserNotFound},
+		{"change to an unknown role", func() error { return s.UpdateMemberRole(ctx, userID, model.OrgRoleOwner, orgID, userID, "guest") }, ErrInvalidOrgRole},
+		{"remove from a malformed organization", func() error { return s.RemoveMember(ctx, userID, model.OrgRoleOwner, "acme", userID) }, ErrNotMember},
+		{"switch for a malformed user", func() error { _, err := s.SwitchOrganization(ctx, "jane", uuid.NewString(), orgID); return err }, ErrUserNotFound},
+		{"switch a malformed session", func() error { _, err := s.SwitchOrganization(ctx, userID, "current", orgID); return err }, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	cfg.DisableEmail = true
+	if err := s.InviteMember(ctx, userID, model.OrgRoleOwner, orgID, "jane@example.com", model.OrgRoleMember); err != ErrEmailDisabled {
+		t.Errorf("InviteMember without email = %v, want %v", err, ErrEmailDisabled)
+	}
+}
+
+func TestCheckOwnership(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	member := &model.Membership{Role: model.OrgRoleMember}
+	owner := &model.Membership{Role: model.OrgRoleOwner}
+
+	tests := []struct {
+		name       string
+		actorRole  string
+		membership *model.Membership
+		newRole    string
+		want       error
+	}{
+		{"admin promotes a member to admin", model.OrgRoleAdmin, member, model.OrgRoleAdmin, nil},
+		{"admin removes a member", model.OrgRoleAdmin, member, "", nil},
+		{"admin promotes a member to owner", model.OrgRoleAdmin, member, model.OrgRoleOwner, ErrPermissionDenied},
+		{"admin demotes an owner", model.OrgRoleAdmin, owner, model.OrgRoleMember, ErrPermissionDenied},
+		{"admin removes an owner", model.OrgRoleAdmin, owner, "", ErrPermissionDenied},
+		{"owner promotes a member to owner", model.OrgRoleOwner, member, model.OrgRoleOwner, nil},
+		{"owner keeps an owner", model.OrgRoleOwner, owner, model.OrgRoleOwner, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.checkOwnership(context.Background(), tt.actorRole, tt.membership, tt.newRole); err != tt.want {
+				t.Errorf("checkOwnership = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// PasswordPolicyError lists every rule a password breaks
+type PasswordPolicyError struct {
+	Violations []model.PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the password policy"
+}
+
+// personalInfoMinLength keeps short name words like "al" from rejecting half the dictionary
+const personalInfoMinLength = 3
+
+// checkPassword enforces the password policy of the user's tenant on a password about to be set for them, field
+// names the request field the violations are reported against. Breaking any rule returns a *PasswordPolicyError.
+func (s *AuthServiceImpl) checkPassword(ctx context.Context, user *model.User, field, password string) error {
+	cfg, err := s.userConfig(ctx, user)
+	if err != nil {
+		return err
+	}
+	policy := cfg.PasswordPolicy
+	var violations []model.PasswordViolation
+	violate := func(rule, message string) {
+		violations = append(violations, model.PasswordViolation{Field: field, Rule: rule, Message: message})
+	}
+
+	if length := utf8.RuneCountInString(password); length < policy.MinLength || length == 0 {
+		violate("min_length", fmt.Sprintf("must be at least %d characters", max(policy.MinLength, 1)))
+	}
+	if policy.MaxLength > 0 && len(password) > policy.MaxLength {
+		violate("max_length", fmt.Sprintf("must be at most %d bytes", policy.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if policy.RequireUpper && !upper {
+		violate("require_upper", "must contain an upper case letter")
+	}
+	if policy.RequireLower && !lower {
+		violate("require_lower", "must contain a lower case letter")
+	}
+	if policy.RequireDigit && !digit {
+		violate("require_digit", "must contain a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		violate("require_symbol", "must contain a symbol")
+	}
+
+	if policy.DisallowPersonalInfo && user != nil && containsPersonalInfo(user, password) {
+		violate("personal_info", "must not contain your email or name")
+	}
+
+	if policy.History > 0 && user != nil && user.ID != nil {
+		reused, err := s.passwordReused(ctx, user, password, policy.History)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violate("history", fmt.Sprintf("must not be one of your last %d passwords", policy.History))
+		}
+	}
+
+	if policy.BreachedDir != "" {
+		breached, err := passwordBreached(policy.BreachedDir, password)
+		if err != nil {
+			// The corpus is an extra safeguard, a broken one shouldn't stop everyone from setting passwords
+			logrus.Errorln("Failed to check breached passwords : ", err)
+		} else if breached {
+			violate("breached", "appears in a known data breach, choose another")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo reports whether the password contains the email's local part or a word of the name
+func containsPersonalInfo(user *model.User, password string) bool {
+	password = strings.ToLower(password)
+	var parts []string
+	if email := derefString(user.Email); email != "" {
+		parts = append(parts, strings.SplitN(email, "@", 2)[0])
+	}
+	parts = append(parts, strings.FieldsFunc(derefString(user.Name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})...)
+
+	for _, part := range parts {
+		part = strings.ToLower(part)
+		if utf8.RuneCountInString(part) >= personalInfoMinLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// passwordReused compares the password with the current one and the last n in the user's history
+func (s *AuthServiceImpl) passwordReused(ctx context.Context, user *model.User, password string, n int) (bool, error) {
+	if user.Password != nil && utils.ComparePassword(*user.Password, password) {
+		return true, nil
+	}
+	hashes, err := s.repo.RecentPasswords(ctx, *user.ID, n)
+	if err != nil {
+		return false, err
+	}
+	for _, hash := range hashes {
+		if utils.ComparePassword(hash, password) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rememberPassword adds a newly set password hash to the user's history, keeping as many as the policy checks
+func (s *AuthServiceImpl) rememberPassword(ctx context.Context, user *model.User, hashedPassword string) {
+	cfg, err := s.userConfig(ctx, user)
+	if err != nil {
+		logrus.Errorln("Failed to record password history : ", err)
+		return
+	}
+	keep := cfg.PasswordPolicy.History
+	if keep <= 0 {
+		return
+	}
+	if err := s.repo.AddPasswordHistory(ctx, *user.ID, hashedPassword, keep); err != nil {
+		logrus.Errorln("Failed to record password history : ", err)
+	}
+}
+
+// passwordBreached looks the password up in the k-anonymity corpus, only the file of its SHA-1 prefix is read.
+// A missing file means no breached password has that prefix.
+func passwordBreached(dir, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(dir, prefix))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestCheckPassword(t *testing.T) {
+	user := &model.User{Email: strPtr("jane.doe@example.com"), Name: strPtr("Jane Doe")}
+
+	tests := []struct {
+		name   string
+		policy config.PasswordPolicy
+		user   *model.User
+		pass   string
+		rules  []string
+	}{
+		{"accepted", config.PasswordPolicy{MinLength: 8, MaxLength: 72}, user, "correct horse", nil},
+		{"empty", config.PasswordPolicy{}, user, "", []string{"min_length"}},
+		{"too short", config.PasswordPolicy{MinLength: 8}, user, "short", []string{"min_length"}},
+		{"length counts runes", config.PasswordPolicy{MinLength: 4}, user, "ñäöü", nil},
+		{"too long", config.PasswordPolicy{MaxLength: 4}, user, "ñäöü", []string{"max_length"}},
+		{
+			"character classes",
+			config.PasswordPolicy{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true},
+			user, "lowercase",
+			[]string{"require_upper", "require_digit", "require_symbol"},
+		},
+		{
+			"all classes present",
+			config.PasswordPolicy{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true},
+			user, "Tr0ub4dor&3", nil,
+		},
+		{"email local part", config.PasswordPolicy{DisallowPersonalInfo: true}, user, "my-JANE.DOE-pass", []string{"personal_info"}},
+		{"name word", config.PasswordPolicy{DisallowPersonalInfo: true}, user, "doe12345", []string{"personal_info"}},
+		{"personal info allowed", config.PasswordPolicy{}, user, "doe12345", nil},
+		{"no user", config.PasswordPolicy{DisallowPersonalInfo: true}, nil, "doe12345", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.PasswordPolicy = tt.policy
+			s := &AuthServiceImpl{config: cfg}
+
+			err := s.checkPassword(context.Background(), tt.user, "password", tt.pass)
+			var rules []string
+			if err != nil {
+				var policyErr *PasswordPolicyError
+				if !errors.As(err, &policyErr) {
+					t.Fatalf("checkPassword returned %v, want a *PasswordPolicyError", err)
+				}
+				for _, violation := range policyErr.Violations {
+					if violation.Field != "password" {
+						t.Errorf("violation %q reported against %q", violation.Rule, violation.Field)
+					}
+					rules = append(rules, violation.Rule)
+				}
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("violations = %v, want %v", rules, tt.rules)
+			}
+		})
+	}
+}
+
+func TestContainsPersonalInfo(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  *model.User
+		pass  string
+		found bool
+	}{
+		{"email local part", &model.User{Email: strPtr("alice@example.com")}, "xxALICExx", true},
+		{"email domain ignored", &model.User{Email: strPtr("alice@example.com")}, "example1", false},
+		{"name word", &model.User{Name: strPtr("Bob Marley")}, "marley!!", true},
+		{"short name word ignored", &model.User{Name: strPtr("Al Li")}, "al-li-al-li", false},
+		{"nothing to match", &model.User{}, "anything", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsPersonalInfo(tt.user, tt.pass); got != tt.found {
+				t.Errorf("containsPersonalInfo(%q) = %v, want %v", tt.pass, got, tt.found)
+			}
+		})
+	}
+}
+
+func TestPasswordBreached(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	dir := t.TempDir()
+	corpus := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "5BAA6"), []byte(corpus), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "5BAA6"), []byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		password string
+		breached bool
+		wantErr  bool
+	}{
+		{"listed", dir, "password", true, false},
+		{"prefix file without the suffix", other, "password", false, false},
+		{"no prefix file", dir, "correct horse battery staple", false, false},
+		{"unreadable corpus", filepath.Join(dir, "5BAA6"), "password", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breached, err := passwordBreached(tt.dir, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("passwordBreached error = %v, want error %v", err, tt.wantErr)
+			}
+			if breached != tt.breached {
+				t.Errorf("passwordBreached(%q) = %v, want %v", tt.password, breached, tt.breached)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+// SendLoginOTP texts a one-time sign-in code to the phone number. Each number is held to a resend cooldown
+// and an hourly cap, and requesting a new code expires the previous one. Unknown numbers are silently ignored,
+// and so are requests over the limits: answering them differently would tell which numbers are registered.
+// Requests are throttled per number, registered or not, by the route's rate limit.
+func (s *AuthServiceImpl) SendLoginOTP(ctx context.Context, phone string) error {
+	if s.config.DisablePhone || !s.config.SMSOTP.Enabled {
+		return ErrPhoneDisabled
+	}
+
+	user, err := s.repo.GetUserByPhone(ctx, phone)
+	if err != nil {
+		logrus.Infoln("Login code requested for unknown phone number")
+		return nil
+	}
+
+	now := time.Now()
+	recent, err := s.repo.CountVerificationsSince(ctx, *user.ID, model.VerificationTypePhoneLogin, now.Add(-s.config.SMSOTP.ResendCooldown*time.Second))
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		logrus.Infoln("Login code requested again within the cooldown")
+		return nil
+	}
+
+	sent, err := s.repo.CountVerificationsSince(ctx, *user.ID, model.VerificationTypePhoneLogin, now.Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if s.config.SMSOTP.MaxSendsPerHour > 0 && sent >= int64(s.config.SMSOTP.MaxSendsPerHour) {
+		logrus.Infoln("Login code requested past the hourly cap")
+		return nil
+	}
+
+	if err := s.repo.ExpirePendingVerifications(ctx, *user.ID, model.VerificationTypePhoneLogin); err != nil {
+		return err
+	}
+
+	code := utils.GenerateVerificationCode()
+	hashedCode := utils.HashCode(code, s.config.SMSOTP.HashKey)
+	verification := &model.Verification{
+		UserID:    *user.ID,
+		Type:      model.VerificationTypePhoneLogin,
+		Data:      &hashedCode,
+		Status:    model.VerificationStatusPending,
+		SentAt:    now,
+		ExpiresAt: now.Add(s.config.SMSOTP.Expiry * time.Minute),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.CreateVerification(ctx, verification); err != nil {
+		return err
+	}
+
+	return s.smsService.SendLoginCodeSMS(ctx, phone, code)
+}
+
+// VerifyLoginOTP checks a login code against the latest one sent to the number. Wrong codes count against
+// the code and burn it once MaxAttempts is reached. A successful login also marks the phone as verified.
+func (s *AuthServiceImpl) VerifyLoginOTP(ctx context.Context, phone, code string) (*model.User, string, error) {
+	if s.config.DisablePhone || !s.config.SMSOTP.Enabled {
+		return nil, "", ErrPhoneDisabled
+	}
+
+	user, err := s.repo.GetUserByPhone(ctx, phone)
+	if err != nil {
+		return nil, "", ErrInvalidCode
+	}
+
+	verification, err := s.repo.GetLatestVerification(ctx, *user.ID, model.VerificationTypePhoneLogin)
+	if err != nil {
+		return nil, "", ErrInvalidCode
+	}
+
+	if verification.Data == nil || subtle.ConstantTimeCompare([]byte(*verification.Data), []byte(utils.HashCode(code, s.config.SMSOTP.HashKey))) != 1 {
+		return nil, "", s.failCodeAttempt(ctx, verification, s.config.SMSOTP.MaxAttempts)
+	}
+
+	consumed, err := s.repo.ConsumeVerification(ctx, verification.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !consumed {
+		return nil, "", ErrInvalidCode
+	}
+
+	if !user.IsPhoneVerified {
+		now := time.Now()
+		if err := s.repo.UpdateUser(ctx, *user.ID, map[string]interface{}{
+			"is_phone_verified": true,
+			"phone_verified_at": now,
+		}); err != nil {
+			return nil, "", err
+		}
+		user.IsPhoneVerified = true
+		user.PhoneVerifiedAt = &now
+	}
+
+	if user.MFAEnabled || s.mfaRequired(ctx, user) {
+		mfaToken, err := s.createMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, "", err
+		}
+		return user, mfaToken, nil
+	}
+
+	if err := s.repo.UpdateLastLogin(ctx, *user.ID); err != nil {
+		return nil, "", err
+	}
+
+	return user, "", nil
+}
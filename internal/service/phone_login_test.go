@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestPhoneLoginDisabled(t *testing.T) {
+	tests := []struct {
+		name         string
+		disablePhone bool
+		smsLogin     bool
+	}{
+		{"phone support off", true, true},
+		{"sms login off", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.DisablePhone = tt.disablePhone
+			cfg.SMSOTP.Enabled = tt.smsLogin
+			s := &AuthServiceImpl{config: cfg}
+
+			if err := s.SendLoginOTP(context.Background(), "+15550100"); err != ErrPhoneDisabled {
+				t.Errorf("SendLoginOTP = %v, want %v", err, ErrPhoneDisabled)
+			}
+			if _, _, err := s.VerifyLoginOTP(context.Background(), "+15550100", "123456"); err != ErrPhoneDisabled {
+				t.Errorf("VerifyLoginOTP = %v, want %v", err, ErrPhoneDisabled)
+			}
+		})
+	}
+}
+
+// countingSMS counts the login codes texted
+type countingSMS struct {
+	SMSService
+	sent int
+}
+
+func (c *countingSMS) SendLoginCodeSMS(context.Context, string, string) error {
+	c.sent++
+	return nil
+}
+
+func TestSendLoginOTPSameAnswerForUnknownNumbers(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DisablePhone = false
+	cfg.SMSOTP.Enabled = true
+	cfg.SMSOTP.HashKey = "a-long-random-secret"
+	userID, registered := uuid.New(), "+15550100"
+	repo, _ := newFakeRepo(t, &model.User{ID: &userID, Phone: &registered, IsPhoneVerified: true})
+	sms := &countingSMS{}
+	s := &AuthServiceImpl{repo: repo, config: cfg, smsService: sms}
+	ctx := context.Background()
+
+	// The second request for the registered number falls within the cooldown
+	for _, phone := range []string{registered, registered, "+15550199", "+15550199"} {
+		if err := s.SendLoginOTP(ctx, phone); err != nil {
+			t.Errorf("SendLoginOTP(%s) = %v, want nil", phone, err)
+		}
+	}
+	if sms.sent != 1 {
+		t.Errorf("%d codes texted, want 1", sms.sent)
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// roleNamePattern keeps role names safe to put in tokens and URLs
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,50}$`)
+
+// GetUserAccess resolves the user's roles, User.Role plus the assigned ones, and the permissions they grant
+func (s *AuthServiceImpl) GetUserAccess(ctx context.Context, user *model.User) ([]string, []string, error) {
+	assigned, err := s.repo.GetUserRoleNames(ctx, *user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roles := []string{}
+	if user.Role != "" {
+		roles = append(roles, user.Role)
+	}
+	for _, role := range assigned {
+		if role != user.Role {
+			roles = append(roles, role)
+		}
+	}
+
+	permissions, err := s.repo.GetRolePermissionNames(ctx, roles)
+	if err != nil {
+		return nil, nil, err
+	}
+	return roles, permissions, nil
+}
+
+// isProtectedRole reports whether the role is one of the configured admin roles
+func (s *AuthServiceImpl) isProtectedRole(name string) bool {
+	for _, role := range s.config.AdminRoles {
+		if role == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AuthServiceImpl) ListRoles(ctx context.Context) ([]model.Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+// CreateRole creates a role granting existing permissions
+func (s *AuthServiceImpl) CreateRole(ctx context.Context, adminID, name, description string, permissions []string) (*model.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	if _, err := s.repo.GetRoleByName(ctx, name); err == nil {
+		return nil, ErrRoleExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	role := &model.Role{
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now(),
+	}
+	for _, name := range permissions {
+		permission, err := s.repo.GetPermissionByName(ctx, name)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrPermissionNotFound
+			}
+			return nil, err
+		}
+		role.Permissions = append(role.Permissions, *permission)
+	}
+
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventRoleCreated, nil, map[string]string{"role": name, "permissions": strings.Join(permissions, " ")})
+	return role, nil
+}
+
+// DeleteRole deletes a role and takes it away from every user holding it
+func (s *AuthServiceImpl) DeleteRole(ctx context.Context, adminID, name string) error {
+	if s.isProtectedRole(name) {
+		return ErrProtectedRole
+	}
+	if err := s.repo.DeleteRole(ctx, name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventRoleDeleted, nil, map[string]string{"role": name})
+	return nil
+}
+
+// GrantRolePermission adds a permission to a role
+func (s *AuthServiceImpl) GrantRolePermission(ctx context.Context, adminID, roleName, permissionName string) error {
+	role, permission, err := s.rolePermission(ctx, roleName, permissionName)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.AddRolePermission(ctx, role, permission); err != nil {
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventRolePermissionGranted, nil, map[string]string{"role": roleName, "permission": permissionName})
+	return nil
+}
+
+// RevokeRolePermission removes a permission from a role
+func (s *AuthServiceImpl) RevokeRolePermission(ctx context.Context, adminID, roleName, permissionName string) error {
+	if s.isProtectedRole(roleName) {
+		return ErrProtectedRole
+	}
+	role, permission, err := s.rolePermission(ctx, roleName, permissionName)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemoveRolePermission(ctx, role, permission); err != nil {
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventRolePermissionRevoked, nil, map[string]string{"role": roleName, "permission": permissionName})
+	return nil
+}
+
+func (s *AuthServiceImpl) rolePermission(ctx context.Context, roleName, permissionName string) (*model.Role, *model.Permission, error) {
+	role, err := s.repo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRoleNotFound
+		}
+		return nil, nil, err
+	}
+	permission, err := s.repo.GetPermissionByName(ctx, permissionName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrPermissionNotFound
+		}
+		return nil, nil, err
+	}
+	return role, permission, nil
+}
+
+func (s *AuthServiceImpl) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	return s.repo.ListPermissions(ctx)
+}
+
+// CreatePermission registers a permission, names follow the scope syntax so they can be granted to clients too
+func (s *AuthServiceImpl) CreatePermission(ctx context.Context, adminID, name, description string) (*model.Permission, error) {
+	if len(name) > 100 || !scopePattern.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	if _, err := s.repo.GetPermissionByName(ctx, name); err == nil {
+		return nil, ErrPermissionExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	permission := &model.Permission{
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repo.CreatePermission(ctx, permission); err != nil {
+		return nil, err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventPermissionCreated, nil, map[string]string{"permission": name})
+	return permission, nil
+}
+
+// DeletePermission deletes a permission, every role loses it
+func (s *AuthServiceImpl) DeletePermission(ctx context.Context, adminID, name string) error {
+	if err := s.repo.DeletePermission(ctx, name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPermissionNotFound
+		}
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventPermissionDeleted, nil, map[string]string{"permission": name})
+	return nil
+}
+
+// ListUserRoles lists every role of the user, User.Role included
+func (s *AuthServiceImpl) ListUserRoles(ctx context.Context, userID string) ([]string, error) {
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, _, err := s.GetUserAccess(ctx, user)
+	return roles, err
+}
+
+// AssignRole gives the user an extra role
+func (s *AuthServiceImpl) AssignRole(ctx context.Context, adminID, userID, roleName string) error {
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	role, err := s.repo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	if err := s.repo.AssignUserRole(ctx, *user.ID, role.ID); err != nil {
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventRoleAssigned, user.ID, map[string]string{"role": roleName})
+	return nil
+}
+
+// UnassignRole takes an assigned role away, User.Role itself isn't assigned and can't be removed here
+func (s *AuthServiceImpl) UnassignRole(ctx context.Context, adminID, userID, roleName string) error {
+	user, err := s.userByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	role, err := s.repo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	if err := s.repo.UnassignUserRole(ctx, *user.ID, role.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	s.auditAdminChange(ctx, adminID, model.LogEventRoleUnassigned, user.ID, map[string]string{"role": roleName})
+	return nil
+}
+
+func (s *AuthServiceImpl) userByID(ctx context.Context, userID string) (*model.User, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.repo.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestRBACNamesValidated(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	ctx := context.Background()
+
+	for _, name := range []string{"", "team lead", "admin/role", strings.Repeat("r", 51)} {
+		if _, err := s.CreateRole(ctx, "admin", name, "", nil); err != ErrInvalidName {
+			t.Errorf("CreateRole(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+	}
+	for _, name := range []string{"", "posts write", `posts"write`, `posts\write`, strings.Repeat("p", 101)} {
+		if _, err := s.CreatePermission(ctx, "admin", name, ""); err != ErrInvalidName {
+			t.Errorf("CreatePermission(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+	}
+}
+
+func TestProtectedRoles(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AdminRoles = []string{"admin", "owner"}
+	s := &AuthServiceImpl{config: cfg}
+	ctx := context.Background()
+
+	for _, role := range cfg.AdminRoles {
+		if err := s.DeleteRole(ctx, "admin", role); err != ErrProtectedRole {
+			t.Errorf("DeleteRole(%s) = %v, want %v", role, err, ErrProtectedRole)
+		}
+		if err := s.RevokeRolePermission(ctx, "admin", role, "roles:write"); err != ErrProtectedRole {
+			t.Errorf("RevokeRolePermission(%s) = %v, want %v", role, err, ErrProtectedRole)
+		}
+	}
+	if s.isProtectedRole("Admin") || s.isProtectedRole("editor") {
+		t.Error("isProtectedRole protects a role that isn't configured")
+	}
+}
+
+func TestRBACUserIDsValidated(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	ctx := context.Background()
+
+	if _, err := s.ListUserRoles(ctx, "not-a-uuid"); err != ErrUserNotFound {
+		t.Errorf("ListUserRoles = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := s.AssignRole(ctx, uuid.NewString(), "not-a-uuid", "editor"); err != ErrUserNotFound {
+		t.Errorf("AssignRole = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := s.UnassignRole(ctx, uuid.NewString(), "not-a-uuid", "editor"); err != ErrUserNotFound {
+		t.Errorf("UnassignRole = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestRoleAssignmentAudited(t *testing.T) {
+	adminID, userID := uuid.New(), uuid.New()
+	ctx := context.Background()
+
+	tests := []struct {
+		event string
+		call  func(s *AuthServiceImpl) error
+	}{
+		{model.LogEventRoleAssigned, func(s *AuthServiceImpl) error { return s.AssignRole(ctx, adminID.String(), userID.String(), "editor") }},
+		{model.LogEventRoleUnassigned, func(s *AuthServiceImpl) error {
+			return s.UnassignRole(ctx, adminID.String(), userID.String(), "editor")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			roleID := uuid.New()
+			repo, db := newFakeRepo(t, &model.User{ID: &userID}, &model.Role{ID: roleID, Name: "editor"}, &model.UserRole{UserID: userID, RoleID: roleID})
+			s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig()}
+			if err := tt.call(s); err != nil {
+				t.Fatal(err)
+			}
+
+			logs := db.logs()
+			if len(logs) != 1 {
+				t.Fatalf("%d audit entries, want 1", len(logs))
+			}
+			entry := logs[0]
+			if entry.Event != tt.event || entry.UserId != adminID.String() || entry.TargetUserId != userID.String() || entry.Metadata["role"] != "editor" {
+				t.Errorf("audit entry = %+v, want editor %s by %s on %s", entry, tt.event, adminID, userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// deviceFromContext reads the fingerprint attached by the AttachDeviceLog middleware, if any
+func deviceFromContext(ctx context.Context) model.LogData {
+	var data model.LogData
+	if raw, ok := ctx.Value("log_data").([]byte); ok {
+		_ = json.Unmarshal(raw, &data)
+	}
+	return data
+}
+
+// createSession records a new signed-in device for the user with a fresh refresh token family
+func (s *AuthServiceImpl) createSession(ctx context.Context, user *model.User) (*model.Session, error) {
+	device := deviceFromContext(ctx)
+
+	deviceName := device.DeviceName
+	if deviceName == "" {
+		deviceName = device.UserAgent
+	}
+
+	now := time.Now()
+	session := &model.Session{
+		ID:         uuid.New(),
+		UserID:     *user.ID,
+		FamilyID:   uuid.New(),
+		DeviceName: truncate(deviceName, 100),
+		IPAddress:  device.ClientIp,
+		UserAgent:  truncate(device.UserAgent, 255),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ListSessions returns the active sessions of a user
+func (s *AuthServiceImpl) ListSessions(ctx context.Context, userID string) ([]model.Session, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListActiveSessions(ctx, uid)
+}
+
+// RevokeSession signs a single device out
+func (s *AuthServiceImpl) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	if err := s.repo.RevokeSession(ctx, uid, sid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RevokeOtherSessions signs out every device except the current one
+func (s *AuthServiceImpl) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	// Tokens issued before sessions existed have no sid, there is nothing to keep then
+	sid, err := uuid.Parse(currentSessionID)
+	if err != nil {
+		return s.repo.RevokeAllSessions(ctx, uid)
+	}
+	return s.repo.RevokeOtherSessions(ctx, uid, sid)
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+func TestSessionIDsValidated(t *testing.T) {
+	// Malformed IDs never reach the store
+	s := &AuthServiceImpl{}
+	ctx := context.Background()
+
+	if _, err := s.ListSessions(ctx, "not-a-uuid"); err != ErrUserNotFound {
+		t.Errorf("ListSessions = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := s.RevokeSession(ctx, "not-a-uuid", uuid.NewString()); err != ErrUserNotFound {
+		t.Errorf("RevokeSession with a bad user = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := s.RevokeSession(ctx, uuid.NewString(), "not-a-uuid"); err != ErrSessionNotFound {
+		t.Errorf("RevokeSession with a bad session = %v, want %v", err, ErrSessionNotFound)
+	}
+	if err := s.RevokeOtherSessions(ctx, "not-a-uuid", uuid.NewString()); err != ErrUserNotFound {
+		t.Errorf("RevokeOtherSessions = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestRevokeSessionOnce(t *testing.T) {
+	userID, familyID := uuid.New(), uuid.New()
+	session := &model.Session{ID: uuid.New(), UserID: userID, FamilyID: familyID}
+	token := &model.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}
+	other := &model.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	repo, _ := newFakeRepo(t, session, token, other)
+	s := &AuthServiceImpl{repo: repo}
+	ctx := context.Background()
+
+	if err := s.RevokeSession(ctx, userID.String(), session.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeSession(ctx, userID.String(), session.ID.String()); err != ErrSessionNotFound {
+		t.Errorf("revoking twice = %v, want %v", err, ErrSessionNotFound)
+	}
+	if used, err := repo.MarkRefreshTokenUsed(ctx, token.ID); err != nil || used {
+		t.Errorf("MarkRefreshTokenUsed on the revoked session's token = %v, %v, want false", used, err)
+	}
+
+	// Two refreshes racing with the same token, only the first consumes it
+	if used, err := repo.MarkRefreshTokenUsed(ctx, other.ID); err != nil || !used {
+		t.Errorf("MarkRefreshTokenUsed = %v, %v, want true", used, err)
+	}
+	if used, err := repo.MarkRefreshTokenUsed(ctx, other.ID); err != nil || used {
+		t.Errorf("MarkRefreshTokenUsed again = %v, %v, want false", used, err)
+	}
+}
+
+// rotatingJWT accepts any refresh token as the user's and mints a new one each time
+type rotatingJWT struct {
+	JWTService
+	userID uuid.UUID
+}
+
+func (j *rotatingJWT) ValidateRefreshToken(string) (uuid.UUID, error) {
+	return j.userID, nil
+}
+
+func (j *rotatingJWT) GenerateToken(*model.User, uuid.UUID, *uuid.UUID, time.Duration, interface{}) (string, error) {
+	return "access", nil
+}
+
+func (j *rotatingJWT) GenerateRefreshToken(*model.User, uuid.UUID, time.Duration) (string, error) {
+	return uuid.NewString(), nil
+}
+
+func TestRefreshTokenReplayRevokesSession(t *testing.T) {
+	userID, familyID := uuid.New(), uuid.New()
+	session := &model.Session{ID: uuid.New(), UserID: userID, FamilyID: familyID}
+	repo, _ := newFakeRepo(t,
+		&model.User{ID: &userID, IsEmailVerified: true},
+		&model.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, TokenHash: utils.HashToken("stolen"), ExpiresAt: time.Now().Add(time.Hour)},
+		session,
+	)
+	s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig(), jwtService: &rotatingJWT{userID: userID}, tenants: newTenantCache()}
+	ctx := context.Background()
+
+	_, rotated, err := s.RefreshToken(ctx, "stolen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.RefreshToken(ctx, "stolen"); err != ErrTokenReused {
+		t.Fatalf("replayed refresh = %v, want %v", err, ErrTokenReused)
+	}
+
+	if stored, err := repo.GetSessionByFamily(ctx, familyID); err != nil || stored.RevokedAt == nil {
+		t.Errorf("session after the replay = %+v, %v, want it revoked", stored, err)
+	}
+	if _, _, err := s.RefreshToken(ctx, rotated); err != ErrTokenReused {
+		t.Errorf("refresh with the rotated token = %v, want %v", err, ErrTokenReused)
+	}
+}
+
+func TestDeviceFromContext(t *testing.T) {
+	device := model.LogData{ClientIp: "203.0.113.7", UserAgent: "curl/8.0", DeviceName: "laptop"}
+	raw, err := json.Marshal(device)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want model.LogData
+	}{
+		{"attached", context.WithValue(context.Background(), "log_data", raw), device},
+		{"missing", context.Background(), model.LogData{}},
+		{"not bytes", context.WithValue(context.Background(), "log_data", device), model.LogData{}},
+		{"malformed", context.WithValue(context.Background(), "log_data", []byte("{")), model.LogData{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deviceFromContext(tt.ctx); got != tt.want {
+				t.Errorf("deviceFromContext = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	if got := truncate(long, 255); len(got) != 255 {
+		t.Errorf("truncate kept %d bytes, want 255", len(got))
+	}
+	if got := truncate("short", 255); got != "short" {
+		t.Errorf("truncate(short) = %q", got)
+	}
+}
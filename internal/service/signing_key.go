@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// ListSigningKeys lists the rotated key set with each key's state
+func (s *AuthServiceImpl) ListSigningKeys(ctx context.Context) ([]model.SigningKey, error) {
+	return s.jwtService.SigningKeys()
+}
+
+// CreateSigningKey generates the next signing key, it is published before it starts signing
+func (s *AuthServiceImpl) CreateSigningKey(ctx context.Context, adminID string) (*model.SigningKey, error) {
+	key, err := s.jwtService.GenerateSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	s.auditAdmin(ctx, adminID, model.LogEventSigningKeyCreated)
+	return key, nil
+}
+
+// PromoteSigningKey makes the next key active, the previous one keeps verifying until its tokens expire
+func (s *AuthServiceImpl) PromoteSigningKey(ctx context.Context, adminID string) (*model.SigningKey, error) {
+	key, err := s.jwtService.PromoteSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	s.auditAdmin(ctx, adminID, model.LogEventSigningKeyPromoted)
+	return key, nil
+}
+
+// RetireSigningKey removes a key that isn't active, the tokens it signed are rejected from now on
+func (s *AuthServiceImpl) RetireSigningKey(ctx context.Context, adminID, kid string) error {
+	if err := s.jwtService.RetireSigningKey(kid); err != nil {
+		return err
+	}
+	s.auditAdmin(ctx, adminID, model.LogEventSigningKeyRetired)
+	return nil
+}
+
+// auditAdmin records an event under the admin who triggered it
+func (s *AuthServiceImpl) auditAdmin(ctx context.Context, adminID, event string) {
+	if uid, err := uuid.Parse(adminID); err == nil {
+		s.audit(ctx, uid, event)
+	}
+}
+
+// auditAdminChange records an event under the admin who triggered it, naming the user it was done to, when there
+// is one, and what changed in metadata
+func (s *AuthServiceImpl) auditAdminChange(ctx context.Context, adminID, event string, target *uuid.UUID, metadata map[string]string) {
+	uid, err := uuid.Parse(adminID)
+	if err != nil {
+		return
+	}
+	entry := model.NewLog(uid.String(), event, deviceFromContext(ctx).ClientIp)
+	if target != nil {
+		entry.TargetUserId = target.String()
+	}
+	entry.Metadata = metadata
+	s.writeAudit(ctx, entry)
+}
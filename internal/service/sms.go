@@ -4,16 +4,21 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/sirupsen/logrus"
+
 	config "github.com/minilikmila/standard-auth-go/configs"
 )
 
 type SMSServiceImpl struct {
 	config *config.Config
+	// devMode prints login codes to stdout in place of sending them, they must never reach production logs
+	devMode bool
 }
 
-func NewSMSService(config *config.Config) SMSService {
+func NewSMSService(config *config.Config, devMode bool) SMSService {
 	return &SMSServiceImpl{
-		config: config,
+		config:  config,
+		devMode: devMode,
 	}
 }
 
@@ -28,3 +33,13 @@ func (s *SMSServiceImpl) SendPasswordResetSMS(ctx context.Context, phone string,
 	fmt.Printf("Sending password reset SMS to %s with code %s\n", phone, code)
 	return nil
 }
+
+func (s *SMSServiceImpl) SendLoginCodeSMS(ctx context.Context, phone, code string) error {
+	// TODO: Implement actual SMS sending logic
+	if !s.devMode {
+		logrus.Warnf("No SMS gateway configured, login SMS to %s not sent", phone)
+		return nil
+	}
+	fmt.Printf("Sending login SMS to %s with code %s\n", phone, code)
+	return nil
+}
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// tenantCacheTTL bounds how long a tenant change takes to reach the other instances
+const tenantCacheTTL = time.Minute
+
+// tenantCacheSize caps the cache, slugs and hosts come from the client and misses are cached too
+const tenantCacheSize = 1000
+
+// tenantSlugPattern keeps slugs safe to put in headers and URLs
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+type tenantCacheEntry struct {
+	tenant  *model.Tenant // nil when nothing matched, misses are cached too
+	expires time.Time
+}
+
+// tenantCache keeps resolved tenants by "id:", "slug:" or "host:" key so every request doesn't hit the database
+type tenantCache struct {
+	mu      sync.Mutex
+	entries map[string]tenantCacheEntry
+}
+
+func newTenantCache() *tenantCache {
+	return &tenantCache{entries: map[string]tenantCacheEntry{}}
+}
+
+func (c *tenantCache) get(key string) (*model.Tenant, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.tenant, true
+}
+
+// set caches the tenant. A full cache first drops expired entries, then arbitrary ones.
+func (c *tenantCache) set(key string, tenant *model.Tenant) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= tenantCacheSize {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < tenantCacheSize {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = tenantCacheEntry{tenant: tenant, expires: now.Add(tenantCacheTTL)}
+}
+
+func (c *tenantCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]tenantCacheEntry{}
+}
+
+// tenantFromContext returns the tenant resolved for the request, nil when it runs with the global configuration
+func tenantFromContext(ctx context.Context) *model.Tenant {
+	tenant, _ := ctx.Value("tenant").(*model.Tenant)
+	return tenant
+}
+
+// tenantIDFromContext returns the ID of the request's tenant, the one new accounts are bound to
+func tenantIDFromContext(ctx context.Context) *uuid.UUID {
+	if tenant := tenantFromContext(ctx); tenant != nil {
+		id := tenant.ID
+		return &id
+	}
+	return nil
+}
+
+// tenantConfig returns the configuration in effect for the request, the global one with the tenant's overrides
+// applied on a copy
+func tenantConfig(ctx context.Context, global *config.Config) *config.Config {
+	return withTenant(global, tenantFromContext(ctx))
+}
+
+// userConfig returns the configuration in effect for the user's own tenant. Lockout and password policy go by it
+// rather than by the request, the caller picks the request's tenant. Accounts without a tenant, or whose tenant
+// was deleted, get the global configuration.
+func (s *AuthServiceImpl) userConfig(ctx context.Context, user *model.User) (*config.Config, error) {
+	if user == nil || user.TenantID == nil {
+		return s.config, nil
+	}
+	id := *user.TenantID
+	tenant, err := s.cachedTenant(ctx, "id:"+id.String(), func() (*model.Tenant, error) {
+		return s.repo.GetTenantByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withTenant(s.config, tenant), nil
+}
+
+// withTenant applies the tenant's overrides to a copy of the global configuration
+func withTenant(global *config.Config, tenant *model.Tenant) *config.Config {
+	if tenant == nil {
+		return global
+	}
+
+	cfg := *global
+	settings := tenant.Settings
+	if settings.DisableSignup != nil {
+		cfg.DisableSignup = *settings.DisableSignup
+	}
+	if settings.LockoutAttempts != nil {
+		cfg.LockoutPolicy.Attempts = *settings.LockoutAttempts
+	}
+	if settings.LockoutFor != nil {
+		cfg.LockoutPolicy.For = time.Duration(*settings.LockoutFor)
+	}
+	if settings.LockoutMaxFor != nil {
+		cfg.LockoutPolicy.MaxFor = time.Duration(*settings.LockoutMaxFor)
+	}
+	if settings.CompanyName != nil {
+		cfg.CompanyName = *settings.CompanyName
+	}
+	if settings.AppURL != nil {
+		cfg.AppURL = *settings.AppURL
+	}
+	if settings.AccessTokenExpiry != nil {
+		cfg.JWT.Exp = time.Duration(*settings.AccessTokenExpiry)
+	}
+	if settings.RefreshTokenExpiry != nil {
+		cfg.JWT.RefreshExp = time.Duration(*settings.RefreshTokenExpiry)
+	}
+	if settings.PasswordMinLength != nil {
+		cfg.PasswordPolicy.MinLength = *settings.PasswordMinLength
+	}
+	if settings.PasswordMaxLength != nil {
+		cfg.PasswordPolicy.MaxLength = *settings.PasswordMaxLength
+	}
+	if settings.PasswordHistory != nil {
+		cfg.PasswordPolicy.History = *settings.PasswordHistory
+	}
+	if settings.PasswordRequireUpper != nil {
+		cfg.PasswordPolicy.RequireUpper = *settings.PasswordRequireUpper
+	}
+	if settings.PasswordRequireLower != nil {
+		cfg.PasswordPolicy.RequireLower = *settings.PasswordRequireLower
+	}
+	if settings.PasswordRequireDigit != nil {
+		cfg.PasswordPolicy.RequireDigit = *settings.PasswordRequireDigit
+	}
+	if settings.PasswordRequireSymbol != nil {
+		cfg.PasswordPolicy.RequireSymbol = *settings.PasswordRequireSymbol
+	}
+	if settings.PasswordDisallowPersonalInfo != nil {
+		cfg.PasswordPolicy.DisallowPersonalInfo = *settings.PasswordDisallowPersonalInfo
+	}
+	return &cfg
+}
+
+// normalizeHost lower cases the host and drops the port
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// ResolveTenant finds the tenant named by slug or, without one, the tenant serving host. A host matching no
+// tenant resolves to nil, the global configuration.
+func (s *AuthServiceImpl) ResolveTenant(ctx context.Context, slug, host string) (*model.Tenant, error) {
+	if slug != "" {
+		tenant, err := s.cachedTenant(ctx, "slug:"+slug, func() (*model.Tenant, error) {
+			return s.repo.GetTenantBySlug(ctx, slug)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if tenant == nil {
+			return nil, ErrTenantNotFound
+		}
+		return tenant, nil
+	}
+
+	host = normalizeHost(host)
+	if host == "" {
+		return nil, nil
+	}
+	return s.cachedTenant(ctx, "host:"+host, func() (*model.Tenant, error) {
+		return s.repo.GetTenantByHost(ctx, host)
+	})
+}
+
+func (s *AuthServiceImpl) cachedTenant(ctx context.Context, key string, lookup func() (*model.Tenant, error)) (*model.Tenant, error) {
+	if tenant, ok := s.tenants.get(key); ok {
+		return tenant, nil
+	}
+	tenant, err := lookup()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		tenant = nil
+	}
+	s.tenants.set(key, tenant)
+	return tenant, nil
+}
+
+// providerEnabled reports whether the social provider is enabled globally and for the request's tenant
+func (s *AuthServiceImpl) providerEnabled(ctx context.Context, provider string) bool {
+	if _, ok := s.oauth[provider]; !ok {
+		return false
+	}
+	tenant := tenantFromContext(ctx)
+	if tenant == nil || tenant.Settings.EnabledProviders == nil {
+		return true
+	}
+	for _, name := range tenant.Settings.EnabledProviders {
+		if name == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTenant normalizes the tenant and checks its overrides only tighten the global configuration. Callers
+// pick their tenant, a more lenient one would let anyone sidestep the global policies.
+func (s *AuthServiceImpl) validateTenant(tenant *model.Tenant) error {
+	if !tenantSlugPattern.MatchString(tenant.Slug) || strings.TrimSpace(tenant.Name) == "" {
+		return ErrInvalidName
+	}
+	if tenant.Host != nil {
+		host := normalizeHost(*tenant.Host)
+		if host == "" {
+			tenant.Host = nil
+		} else {
+			tenant.Host = &host
+		}
+	}
+
+	settings := tenant.Settings
+	global := s.config
+	if settings.DisableSignup != nil && !*settings.DisableSignup && global.DisableSignup {
+		return ErrInvalidTenantSettings
+	}
+	// Fewer attempts, unless lockout is off globally, and a window at least as long
+	if settings.LockoutAttempts != nil && (*settings.LockoutAttempts < 1 ||
+		(global.LockoutPolicy.Attempts > 0 && *settings.LockoutAttempts > global.LockoutPolicy.Attempts)) {
+		return ErrInvalidTenantSettings
+	}
+	if settings.LockoutFor != nil && (*settings.LockoutFor < 1 || time.Duration(*settings.LockoutFor) < global.LockoutPolicy.For) {
+		return ErrInvalidTenantSettings
+	}
+	// A higher cap, a global one of zero doesn't cap the backoff at all
+	if settings.LockoutMaxFor != nil && (global.LockoutPolicy.MaxFor == 0 || time.Duration(*settings.LockoutMaxFor) < global.LockoutPolicy.MaxFor) {
+		return ErrInvalidTenantSettings
+	}
+	// Retired signing keys are only kept for the global lifetimes, longer tenant tokens would outlive them
+	if settings.AccessTokenExpiry != nil && (*settings.AccessTokenExpiry < 1 || time.Duration(*settings.AccessTokenExpiry) > s.config.JWT.Exp) {
+		return ErrInvalidTenantSettings
+	}
+	if settings.RefreshTokenExpiry != nil && (*settings.RefreshTokenExpiry < 1 || time.Duration(*settings.RefreshTokenExpiry) > s.config.JWT.RefreshExp) {
+		return ErrInvalidTenantSettings
+	}
+	if settings.PasswordMinLength != nil && (*settings.PasswordMinLength < global.PasswordPolicy.MinLength ||
+		(global.PasswordPolicy.MaxLength > 0 && *settings.PasswordMinLength > global.PasswordPolicy.MaxLength)) {
+		return ErrInvalidTenantSettings
+	}
+	// A lower maximum, still leaving room for the minimum in effect
+	if settings.PasswordMaxLength != nil {
+		minLength := global.PasswordPolicy.MinLength
+		if settings.PasswordMinLength != nil {
+			minLength = *settings.PasswordMinLength
+		}
+		if *settings.PasswordMaxLength < max(minLength, 1) ||
+			(global.PasswordPolicy.MaxLength > 0 && *settings.PasswordMaxLength > global.PasswordPolicy.MaxLength) {
+			return ErrInvalidTenantSettings
+		}
+	}
+	if settings.PasswordHistory != nil && *settings.PasswordHistory < global.PasswordPolicy.History {
+		return ErrInvalidTenantSettings
+	}
+	for _, rule := range []struct {
+		tenant *bool
+		global bool
+	}{
+		{settings.PasswordRequireUpper, global.PasswordPolicy.RequireUpper},
+		{settings.PasswordRequireLower, global.PasswordPolicy.RequireLower},
+		{settings.PasswordRequireDigit, global.PasswordPolicy.RequireDigit},
+		{settings.PasswordRequireSymbol, global.PasswordPolicy.RequireSymbol},
+		{settings.PasswordDisallowPersonalInfo, global.PasswordPolicy.DisallowPersonalInfo},
+	} {
+		if rule.tenant != nil && !*rule.tenant && rule.global {
+			return ErrInvalidTenantSettings
+		}
+	}
+	for _, provider := range settings.EnabledProviders {
+		if _, ok := s.oauth[provider]; !ok {
+			return ErrInvalidTenantSettings
+		}
+	}
+	return nil
+}
+
+// checkTenantUnique makes sure no other tenant has the slug or host
+func (s *AuthServiceImpl) checkTenantUnique(ctx context.Context, tenant *model.Tenant) error {
+	lookups := []func() (*model.Tenant, error){
+		func() (*model.Tenant, error) { return s.repo.GetTenantBySlug(ctx, tenant.Slug) },
+	}
+	if tenant.Host != nil {
+		lookups = append(lookups, func() (*model.Tenant, error) { return s.repo.GetTenantByHost(ctx, *tenant.Host) })
+	}
+	for _, lookup := range lookups {
+		existing, err := lookup()
+		if err == nil && existing.ID != tenant.ID {
+			return ErrTenantExists
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *AuthServiceImpl) tenantByID(ctx context.Context, tenantID string) (*model.Tenant, error) {
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+	tenant, err := s.repo.GetTenantByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+func (s *AuthServiceImpl) ListTenants(ctx context.Context) ([]model.Tenant, error) {
+	return s.repo.ListTenants(ctx)
+}
+
+func (s *AuthServiceImpl) GetTenant(ctx context.Context, tenantID string) (*model.Tenant, error) {
+	return s.tenantByID(ctx, tenantID)
+}
+
+// CreateTenant registers a tenant, its slug and host must not be taken
+func (s *AuthServiceImpl) CreateTenant(ctx context.Context, adminID string, tenant *model.Tenant) error {
+	if err := s.validateTenant(tenant); err != nil {
+		return err
+	}
+	if err := s.checkTenantUnique(ctx, tenant); err != nil {
+		return err
+	}
+
+	tenant.CreatedAt = time.Now()
+	tenant.UpdatedAt = time.Now()
+	if err := s.repo.CreateTenant(ctx, tenant); err != nil {
+		return err
+	}
+	s.tenants.clear()
+	s.auditAdmin(ctx, adminID, model.LogEventTenantCreated)
+	return nil
+}
+
+// UpdateTenant replaces the tenant's name, host and settings, the slug clients send stays the same
+func (s *AuthServiceImpl) UpdateTenant(ctx context.Context, adminID, tenantID string, update *model.Tenant) (*model.Tenant, error) {
+	tenant, err := s.tenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Name = update.Name
+	tenant.Host = update.Host
+	tenant.Settings = update.Settings
+	if err := s.validateTenant(tenant); err != nil {
+		return nil, err
+	}
+	if err := s.checkTenantUnique(ctx, tenant); err != nil {
+		return nil, err
+	}
+
+	tenant.UpdatedAt = time.Now()
+	if err := s.repo.SaveTenant(ctx, tenant); err != nil {
+		return nil, err
+	}
+	s.tenants.clear()
+	s.auditAdmin(ctx, adminID, model.LogEventTenantUpdated)
+	return tenant, nil
+}
+
+// DeleteTenant removes the tenant, its requests fall back to the global configuration
+func (s *AuthServiceImpl) DeleteTenant(ctx context.Context, adminID, tenantID string) error {
+	tenant, err := s.tenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteTenant(ctx, tenant.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTenantNotFound
+		}
+		return err
+	}
+	s.tenants.clear()
+	s.auditAdmin(ctx, adminID, model.LogEventTenantDeleted)
+	return nil
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+	"github.com/minilikmila/standard-auth-go/pkg/utils"
+)
+
+func intPtr(i int) *int    { return &i }
+func boolPtr(b bool) *bool { return &b }
+
+func TestValidateTenant(t *testing.T) {
+	global := config.DefaultConfig()
+	global.DisableSignup = true
+	global.LockoutPolicy = config.LockoutPolicy{Attempts: 5, For: 30, MaxFor: 1440}
+	global.PasswordPolicy.MinLength, global.PasswordPolicy.MaxLength, global.PasswordPolicy.History = 8, 72, 3
+	s := &AuthServiceImpl{config: global}
+
+	tests := []struct {
+		name     string
+		settings model.TenantSettings
+		want     error
+	}{
+		{"no overrides", model.TenantSettings{}, nil},
+		{"keeps signup disabled", model.TenantSettings{DisableSignup: boolPtr(true)}, nil},
+		{"enables signup", model.TenantSettings{DisableSignup: boolPtr(false)}, ErrInvalidTenantSettings},
+		{"fewer lockout attempts", model.TenantSettings{LockoutAttempts: intPtr(3)}, nil},
+		{"more lockout attempts", model.TenantSettings{LockoutAttempts: intPtr(50)}, ErrInvalidTenantSettings},
+		{"lockout off", model.TenantSettings{LockoutAttempts: intPtr(0)}, ErrInvalidTenantSettings},
+		{"longer lockout", model.TenantSettings{LockoutFor: intPtr(60)}, nil},
+		{"shorter lockout", model.TenantSettings{LockoutFor: intPtr(1)}, ErrInvalidTenantSettings},
+		{"higher lockout cap", model.TenantSettings{LockoutMaxFor: intPtr(2880)}, nil},
+		{"lower lockout cap", model.TenantSettings{LockoutMaxFor: intPtr(60)}, ErrInvalidTenantSettings},
+		{"shorter access tokens", model.TenantSettings{AccessTokenExpiry: intPtr(15)}, nil},
+		{"longer access tokens", model.TenantSettings{AccessTokenExpiry: intPtr(int(global.JWT.Exp) + 1)}, ErrInvalidTenantSettings},
+		{"longer refresh tokens", model.TenantSettings{RefreshTokenExpiry: intPtr(int(global.JWT.RefreshExp) + 1)}, ErrInvalidTenantSettings},
+		{"longer passwords", model.TenantSettings{PasswordMinLength: intPtr(12)}, nil},
+		{"shorter passwords", model.TenantSettings{PasswordMinLength: intPtr(4)}, ErrInvalidTenantSettings},
+		{"unsettable password length", model.TenantSettings{PasswordMinLength: intPtr(100)}, ErrInvalidTenantSettings},
+		{"lower max length", model.TenantSettings{PasswordMaxLength: intPtr(64)}, nil},
+		{"higher max length", model.TenantSettings{PasswordMaxLength: intPtr(100)}, ErrInvalidTenantSettings},
+		{"max length under the min", model.TenantSettings{PasswordMaxLength: intPtr(6)}, ErrInvalidTenantSettings},
+		{"max length under the tenant's min", model.TenantSettings{PasswordMinLength: intPtr(20), PasswordMaxLength: intPtr(16)}, ErrInvalidTenantSettings},
+		{"longer history", model.TenantSettings{PasswordHistory: intPtr(5)}, nil},
+		{"shorter history", model.TenantSettings{PasswordHistory: intPtr(0)}, ErrInvalidTenantSettings},
+		{"requires symbols", model.TenantSettings{PasswordRequireSymbol: boolPtr(true)}, nil},
+		{"keeps symbols optional", model.TenantSettings{PasswordRequireSymbol: boolPtr(false)}, nil},
+		{"keeps the personal info check", model.TenantSettings{PasswordDisallowPersonalInfo: boolPtr(true)}, nil},
+		{"drops the personal info check", model.TenantSettings{PasswordDisallowPersonalInfo: boolPtr(false)}, ErrInvalidTenantSettings},
+		{"unknown provider", model.TenantSettings{EnabledProviders: []string{"myspace"}}, ErrInvalidTenantSettings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant := &model.Tenant{Slug: "acme", Name: "Acme", Settings: tt.settings}
+			if err := s.validateTenant(tenant); err != tt.want {
+				t.Errorf("validateTenant = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTenantLockoutOffGlobally(t *testing.T) {
+	global := config.DefaultConfig()
+	global.LockoutPolicy.Attempts = 0
+	s := &AuthServiceImpl{config: global}
+
+	tenant := &model.Tenant{Slug: "acme", Name: "Acme", Settings: model.TenantSettings{LockoutAttempts: intPtr(20)}}
+	if err := s.validateTenant(tenant); err != nil {
+		t.Errorf("turning lockout on for a tenant = %v, want it accepted", err)
+	}
+}
+
+func TestValidateTenantUncappedLockout(t *testing.T) {
+	global := config.DefaultConfig()
+	global.LockoutPolicy.MaxFor = 0
+	s := &AuthServiceImpl{config: global}
+
+	tenant := &model.Tenant{Slug: "acme", Name: "Acme", Settings: model.TenantSettings{LockoutMaxFor: intPtr(100000)}}
+	if err := s.validateTenant(tenant); err != ErrInvalidTenantSettings {
+		t.Errorf("capping an uncapped lockout = %v, want %v", err, ErrInvalidTenantSettings)
+	}
+}
+
+func TestValidateTenantNormalizesHost(t *testing.T) {
+	s := &AuthServiceImpl{config: config.DefaultConfig()}
+	host := " Auth.Acme.COM.:8443 "
+	tenant := &model.Tenant{Slug: "acme", Name: "Acme", Host: &host}
+	if err := s.validateTenant(tenant); err != nil {
+		t.Fatal(err)
+	}
+	if tenant.Host == nil || *tenant.Host != "auth.acme.com" {
+		t.Errorf("host = %v, want auth.acme.com", tenant.Host)
+	}
+
+	for _, slug := range []string{"", "Acme", "-acme", "acme corp"} {
+		if err := s.validateTenant(&model.Tenant{Slug: slug, Name: "Acme"}); err != ErrInvalidName {
+			t.Errorf("validateTenant with slug %q = %v, want %v", slug, err, ErrInvalidName)
+		}
+	}
+}
+
+func TestWithTenant(t *testing.T) {
+	global := config.DefaultConfig()
+	tenant := &model.Tenant{Settings: model.TenantSettings{
+		LockoutAttempts:       intPtr(3),
+		LockoutFor:            intPtr(120),
+		LockoutMaxFor:         intPtr(2880),
+		PasswordMinLength:     intPtr(12),
+		PasswordMaxLength:     intPtr(64),
+		PasswordHistory:       intPtr(5),
+		PasswordRequireUpper:  boolPtr(true),
+		PasswordRequireLower:  boolPtr(true),
+		PasswordRequireDigit:  boolPtr(true),
+		PasswordRequireSymbol: boolPtr(true),
+	}}
+
+	cfg := withTenant(global, tenant)
+	want := config.LockoutPolicy{Attempts: 3, For: 120, MaxFor: 2880}
+	if cfg.LockoutPolicy != want {
+		t.Errorf("lockout = %+v, want %+v", cfg.LockoutPolicy, want)
+	}
+	policy := cfg.PasswordPolicy
+	if policy.MinLength != 12 || policy.MaxLength != 64 || policy.History != 5 ||
+		!policy.RequireUpper || !policy.RequireLower || !policy.RequireDigit || !policy.RequireSymbol {
+		t.Errorf("withTenant didn't apply the password overrides: %+v", policy)
+	}
+	if policy.DisallowPersonalInfo != global.PasswordPolicy.DisallowPersonalInfo || policy.BreachedDir != global.PasswordPolicy.BreachedDir {
+		t.Error("withTenant changed settings the tenant doesn't override")
+	}
+	if global.LockoutPolicy.Attempts == 3 || global.PasswordPolicy.MinLength == 12 {
+		t.Error("withTenant modified the global configuration")
+	}
+	if withTenant(global, nil) != global {
+		t.Error("withTenant without a tenant isn't the global configuration")
+	}
+}
+
+func TestUserConfig(t *testing.T) {
+	global := config.DefaultConfig()
+	s := &AuthServiceImpl{config: global, tenants: newTenantCache()}
+	tenantID := uuid.New()
+	s.tenants.set("id:"+tenantID.String(), &model.Tenant{ID: tenantID, Settings: model.TenantSettings{PasswordMinLength: intPtr(14)}})
+	deletedID := uuid.New()
+	s.tenants.set("id:"+deletedID.String(), nil)
+
+	// The request names a lenient tenant, only the one the user belongs to counts
+	lenient := &model.Tenant{ID: uuid.New(), Settings: model.TenantSettings{PasswordMinLength: intPtr(1)}}
+	ctx := context.WithValue(context.Background(), "tenant", lenient)
+
+	tests := []struct {
+		name      string
+		user      *model.User
+		minLength int
+	}{
+		{"no user", nil, global.PasswordPolicy.MinLength},
+		{"user without tenant", &model.User{}, global.PasswordPolicy.MinLength},
+		{"user of a tenant", &model.User{TenantID: &tenantID}, 14},
+		{"user of a deleted tenant", &model.User{TenantID: &deletedID}, global.PasswordPolicy.MinLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := s.userConfig(ctx, tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.PasswordPolicy.MinLength != tt.minLength {
+				t.Errorf("min length = %d, want %d", cfg.PasswordPolicy.MinLength, tt.minLength)
+			}
+		})
+	}
+}
+
+func TestTenantCacheBounded(t *testing.T) {
+	c := newTenantCache()
+	acme := &model.Tenant{ID: uuid.New(), Slug: "acme"}
+	c.set("slug:acme", acme)
+	c.entries["host:stale.example.com"] = tenantCacheEntry{expires: time.Now().Add(-time.Second)}
+
+	if _, ok := c.get("host:stale.example.com"); ok {
+		t.Error("expired entry served")
+	}
+	if _, ok := c.entries["host:stale.example.com"]; ok {
+		t.Error("expired entry kept after the read")
+	}
+
+	// A client making up hosts must not grow the cache past its size
+	for i := 0; i < 2*tenantCacheSize; i++ {
+		c.set(fmt.Sprintf("host:%d.example.com", i), nil)
+	}
+	if len(c.entries) > tenantCacheSize {
+		t.Errorf("cache holds %d entries, want at most %d", len(c.entries), tenantCacheSize)
+	}
+	if tenant, ok := c.get(fmt.Sprintf("host:%d.example.com", 2*tenantCacheSize-1)); !ok || tenant != nil {
+		t.Error("latest miss not cached")
+	}
+
+	// Expired entries go before live ones
+	c.clear()
+	for i := 0; i < tenantCacheSize-1; i++ {
+		c.entries[fmt.Sprintf("host:%d.example.com", i)] = tenantCacheEntry{expires: time.Now().Add(-time.Second)}
+	}
+	c.set("slug:acme", acme)
+	c.set("slug:other", nil)
+	if len(c.entries) != 2 {
+		t.Errorf("cache holds %d entries after the sweep, want 2", len(c.entries))
+	}
+	if tenant, ok := c.get("slug:acme"); !ok || tenant != acme {
+		t.Error("live entry evicted while expired ones were left")
+	}
+}
+
+// lifetimeJWT records the lifetimes tokens are minted with
+type lifetimeJWT struct {
+	JWTService
+	userID                uuid.UUID
+	accessExp, refreshExp time.Duration
+}
+
+func (j *lifetimeJWT) ValidateRefreshToken(string) (uuid.UUID, error) {
+	return j.userID, nil
+}
+
+func (j *lifetimeJWT) GenerateToken(_ *model.User, _ uuid.UUID, _ *uuid.UUID, expiry time.Duration, _ interface{}) (string, error) {
+	j.accessExp = expiry
+	return "access", nil
+}
+
+func (j *lifetimeJWT) GenerateRefreshToken(_ *model.User, _ uuid.UUID, expiry time.Duration) (string, error) {
+	j.refreshExp = expiry
+	return "refresh", nil
+}
+
+func TestRefreshTokenLifetimesFollowUserTenant(t *testing.T) {
+	userID, tenantID, familyID := uuid.New(), uuid.New(), uuid.New()
+	user := &model.User{ID: &userID, TenantID: &tenantID, IsEmailVerified: true}
+	repo, db := newFakeRepo(t,
+		user,
+		&model.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: familyID, TokenHash: utils.HashToken("refresh"), ExpiresAt: time.Now().Add(time.Hour)},
+		&model.Session{ID: uuid.New(), UserID: userID, FamilyID: familyID},
+	)
+	jwtService := &lifetimeJWT{userID: userID}
+	s := &AuthServiceImpl{repo: repo, config: config.DefaultConfig(), jwtService: jwtService, tenants: newTenantCache()}
+	s.tenants.set("id:"+tenantID.String(), &model.Tenant{ID: tenantID, Settings: model.TenantSettings{
+		AccessTokenExpiry:  intPtr(5),
+		RefreshTokenExpiry: intPtr(60),
+	}})
+
+	// No tenant header, the request runs with the global configuration
+	if _, _, err := s.RefreshToken(context.Background(), "refresh"); err != nil {
+		t.Fatal(err)
+	}
+	if jwtService.accessExp != 5 || jwtService.refreshExp != 60 {
+		t.Errorf("lifetimes = %d/%d minutes, want the tenant's 5/60", jwtService.accessExp, jwtService.refreshExp)
+	}
+	for _, created := range db.created {
+		if token, ok := created.(*model.RefreshToken); ok && token.ExpiresAt.After(time.Now().Add(time.Hour)) {
+			t.Errorf("stored refresh token expires at %v, past the tenant's hour", token.ExpiresAt)
+		}
+	}
+}
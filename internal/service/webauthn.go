@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+// webAuthnCeremonyTimeout bounds how long a registration or login challenge stays valid
+const webAuthnCeremonyTimeout = 5 * time.Minute
+
+// newWebAuthn builds the relying party from configuration, nil when WebAuthn is disabled
+func newWebAuthn(cfg *config.Config) *webauthn.WebAuthn {
+	if !cfg.WebAuthn.Enabled {
+		return nil
+	}
+
+	displayName := cfg.WebAuthn.RPDisplayName
+	if displayName == "" {
+		displayName = cfg.CompanyName
+	}
+
+	relyingParty, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: displayName,
+		RPOrigins:     cfg.WebAuthn.RPOrigins,
+	})
+	if err != nil {
+		logrus.Fatalf("Failed to initialize webauthn: %v", err)
+	}
+	return relyingParty
+}
+
+// webAuthnUser adapts a user and its stored credentials to the webauthn.User interface
+type webAuthnUser struct {
+	user        *model.User
+	credentials []model.WebAuthnCredential
+}
+
+// WebAuthnID is the user handle, the account UUID bytes so it maps straight back to the user
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	if email := derefString(u.user.Email); email != "" {
+		return email
+	}
+	return derefString(u.user.Phone)
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if name := derefString(u.user.Name); name != "" {
+		return name
+	}
+	return u.WebAuthnName()
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		var transports []protocol.AuthenticatorTransport
+		for _, t := range strings.Split(c.Transports, ",") {
+			if t != "" {
+				transports = append(transports, protocol.AuthenticatorTransport(t))
+			}
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       c.AAGUID,
+				SignCount:    c.SignCount,
+				CloneWarning: c.CloneWarning,
+			},
+		})
+	}
+	return credentials
+}
+
+func (s *AuthServiceImpl) loadWebAuthnUser(ctx context.Context, userID uuid.UUID) (*webAuthnUser, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	credentials, err := s.repo.ListWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// saveWebAuthnCeremony persists the ceremony session keyed by its challenge, the client echoes it back in clientDataJSON
+func (s *AuthServiceImpl) saveWebAuthnCeremony(ctx context.Context, userID uuid.UUID, vType model.VerificationType, session *webauthn.SessionData) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	serialized := string(data)
+	challenge := session.Challenge
+
+	verification := &model.Verification{
+		UserID:    userID,
+		Type:      vType,
+		Token:     &challenge,
+		Data:      &serialized,
+		Status:    model.VerificationStatusPending,
+		SentAt:    time.Now(),
+		ExpiresAt: time.Now().Add(webAuthnCeremonyTimeout),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	return s.repo.CreateVerification(ctx, verification)
+}
+
+// consumeWebAuthnCeremony looks a ceremony up by challenge and marks it used so it can't be replayed
+func (s *AuthServiceImpl) consumeWebAuthnCeremony(ctx context.Context, challenge string, vType model.VerificationType) (*model.Verification, *webauthn.SessionData, error) {
+	verification, err := s.repo.GetVerificationByToken(ctx, challenge, vType)
+	if err != nil || verification.Data == nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(*verification.Data), &session); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.UpdateVerificationStatus(ctx, verification.ID, model.VerificationStatusVerified); err != nil {
+		return nil, nil, err
+	}
+	return verification, &session, nil
+}
+
+// BeginWebAuthnRegistration starts registering a new authenticator for the user
+func (s *AuthServiceImpl) BeginWebAuthnRegistration(ctx context.Context, userID string) (*protocol.CredentialCreation, error) {
+	if s.webAuthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	waUser, err := s.loadWebAuthnUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't let the same authenticator register twice
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(waUser.credentials))
+	for _, c := range waUser.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(waUser,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.saveWebAuthnCeremony(ctx, uid, model.VerificationTypeWebAuthnRegister, session); err != nil {
+		return nil, err
+	}
+	return creation, nil
+}
+
+// FinishWebAuthnRegistration verifies the attestation response and stores the new credential
+func (s *AuthServiceImpl) FinishWebAuthnRegistration(ctx context.Context, userID, name string, body io.Reader) (*model.WebAuthnCredential, error) {
+	if s.webAuthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	verification, session, err := s.consumeWebAuthnCeremony(ctx, parsed.Response.CollectedClientData.Challenge, model.VerificationTypeWebAuthnRegister)
+	if err != nil {
+		return nil, err
+	}
+	if verification.UserID != uid {
+		return nil, ErrInvalidToken
+	}
+
+	waUser, err := s.loadWebAuthnUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.webAuthn.CreateCredential(waUser, *session, parsed)
+	if err != nil {
+		logrus.Errorln("WebAuthn registration rejected : ", err)
+		return nil, ErrInvalidCredentials
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+	if name == "" {
+		name = "Security key"
+	}
+
+	record := &model.WebAuthnCredential{
+		UserID:          uid,
+		Name:            truncate(name, 100),
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+	if err := s.repo.CreateWebAuthnCredential(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// BeginWebAuthnLogin starts a passwordless login. With an email the user's credentials are listed in the
+// challenge, without one the browser offers any discoverable passkey it holds for this relying party.
+func (s *AuthServiceImpl) BeginWebAuthnLogin(ctx context.Context, email string) (*protocol.CredentialAssertion, error) {
+	if s.webAuthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		err       error
+		userID    uuid.UUID
+	)
+
+	if email == "" {
+		assertion, session, err = s.webAuthn.BeginDiscoverableLogin()
+	} else {
+		user, lookupErr := s.repo.GetUserByEmail(ctx, email)
+		if lookupErr != nil {
+			return nil, ErrInvalidCredentials
+		}
+		waUser, lookupErr := s.loadWebAuthnUser(ctx, *user.ID)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if len(waUser.credentials) == 0 {
+			return nil, ErrInvalidCredentials
+		}
+		userID = *user.ID
+		assertion, session, err = s.webAuthn.BeginLogin(waUser)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.saveWebAuthnCeremony(ctx, userID, model.VerificationTypeWebAuthnLogin, session); err != nil {
+		return nil, err
+	}
+	return assertion, nil
+}
+
+// FinishWebAuthnLogin verifies the assertion and returns the authenticated user. A sign counter that didn't
+// move forward means the authenticator may have been cloned, the credential is flagged and the login refused.
+// An assertion the authenticator verified the user for, by PIN or biometrics, is two factors on its own. One
+// proving presence only takes the second step like a password does, an MFA pending token is returned instead.
+func (s *AuthServiceImpl) FinishWebAuthnLogin(ctx context.Context, body io.Reader) (*model.User, string, error) {
+	if s.webAuthn == nil {
+		return nil, "", ErrWebAuthnDisabled
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, "", ErrInvalidCredentials
+	}
+
+	_, session, err := s.consumeWebAuthnCeremony(ctx, parsed.Response.CollectedClientData.Challenge, model.VerificationTypeWebAuthnLogin)
+	if err != nil {
+		return nil, "", err
+	}
+
+	stored, err := s.repo.GetWebAuthnCredentialByCredentialID(ctx, parsed.RawID)
+	if err != nil {
+		return nil, "", ErrInvalidCredentials
+	}
+	waUser, err := s.loadWebAuthnUser(ctx, stored.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var credential *webauthn.Credential
+	if len(session.UserID) == 0 {
+		credential, err = s.webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			return waUser, nil
+		}, *session, parsed)
+	} else {
+		credential, err = s.webAuthn.ValidateLogin(waUser, *session, parsed)
+	}
+	if err != nil {
+		logrus.Errorln("WebAuthn assertion rejected : ", err)
+		return nil, "", ErrInvalidCredentials
+	}
+
+	if credential.Authenticator.CloneWarning {
+		logrus.Warnln("WebAuthn sign counter regression, possible cloned authenticator : ", stored.ID)
+		if err := s.repo.FlagWebAuthnCredentialCloned(ctx, stored.ID); err != nil {
+			logrus.Errorln("Failed to flag credential : ", err)
+		}
+		s.audit(ctx, stored.UserID, model.LogEventAuthenticatorCloned)
+		return nil, "", ErrAuthenticatorCloned
+	}
+
+	if err := s.repo.UpdateWebAuthnCredentialUsage(ctx, stored.ID, credential.Authenticator.SignCount, credential.Flags.BackupState); err != nil {
+		return nil, "", err
+	}
+	user := waUser.user
+	if !credential.Flags.UserVerified && (user.MFAEnabled || s.mfaRequired(ctx, user)) {
+		mfaToken, err := s.createMFAChallenge(ctx, user)
+		if err != nil {
+			return nil, "", err
+		}
+		return user, mfaToken, nil
+	}
+
+	if err := s.repo.UpdateLastLogin(ctx, stored.UserID); err != nil {
+		logrus.Errorln("Failed to update last login : ", err)
+	}
+
+	return user, "", nil
+}
+
+// ListWebAuthnCredentials returns the authenticators registered by the user
+func (s *AuthServiceImpl) ListWebAuthnCredentials(ctx context.Context, userID string) ([]model.WebAuthnCredential, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListWebAuthnCredentials(ctx, uid)
+}
+
+// DeleteWebAuthnCredential removes one of the user's authenticators unless it is their last way to sign in
+func (s *AuthServiceImpl) DeleteWebAuthnCredential(ctx context.Context, userID, credentialID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	id, err := uuid.Parse(credentialID)
+	if err != nil {
+		return ErrCredentialNotFound
+	}
+	methods, err := s.loginMethods(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if methods <= 1 {
+		return ErrLastLoginMethod
+	}
+	if err := s.repo.DeleteWebAuthnCredential(ctx, uid, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCredentialNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+
+	config "github.com/minilikmila/standard-auth-go/configs"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
+)
+
+func TestWebAuthnUser(t *testing.T) {
+	id := uuid.New()
+	phone := "+15555550100"
+
+	tests := []struct {
+		name        string
+		user        *model.User
+		wantName    string
+		wantDisplay string
+	}{
+		{"email and name", &model.User{ID: &id, Email: strPtr("jane@example.com"), Name: strPtr("Jane"), Phone: &phone}, "jane@example.com", "Jane"},
+		{"phone only", &model.User{ID: &id, Phone: &phone}, phone, phone},
+		{"empty name", &model.User{ID: &id, Email: strPtr("jane@example.com"), Name: strPtr("")}, "jane@example.com", "jane@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &webAuthnUser{user: tt.user}
+			if !bytes.Equal(u.WebAuthnID(), id[:]) {
+				t.Errorf("WebAuthnID = %x, want the account UUID bytes", u.WebAuthnID())
+			}
+			if u.WebAuthnName() != tt.wantName || u.WebAuthnDisplayName() != tt.wantDisplay {
+				t.Errorf("names = (%q, %q), want (%q, %q)", u.WebAuthnName(), u.WebAuthnDisplayName(), tt.wantName, tt.wantDisplay)
+			}
+		})
+	}
+}
+
+func TestWebAuthnUserCredentials(t *testing.T) {
+	id := uuid.New()
+	u := &webAuthnUser{user: &model.User{ID: &id}, credentials: []model.WebAuthnCredential{
+		{CredentialID: []byte("usb"), Transports: "usb,nfc", SignCount: 7, BackupEligible: true},
+		{CredentialID: []byte("cloned"), SignCount: 3, CloneWarning: true},
+	}}
+
+	credentials := u.WebAuthnCredentials()
+	if len(credentials) != 2 {
+		t.Fatalf("got %d credentials, want 2", len(credentials))
+	}
+	usb := credentials[0]
+	if len(usb.Transport) != 2 || usb.Transport[0] != protocol.USB || usb.Transport[1] != protocol.NFC {
+		t.Errorf("transports = %v, want [usb nfc]", usb.Transport)
+	}
+	if usb.Authenticator.SignCount != 7 || !usb.Flags.BackupEligible {
+		t.Errorf("sign count %d and backup eligibility %v not carried over", usb.Authenticator.SignCount, usb.Flags.BackupEligible)
+	}
+	if cloned := credentials[1]; cloned.Transport != nil || !cloned.Authenticator.CloneWarning {
+		t.Errorf("credential without transports = %+v, want no transports and the clone warning kept", cloned)
+	}
+}
+
+func TestWebAuthnDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.WebAuthn.Enabled = false
+	if newWebAuthn(cfg) != nil {
+		t.Fatal("relying party built with WebAuthn disabled")
+	}
+
+	s := &AuthServiceImpl{config: cfg}
+	ctx := context.Background()
+	if _, err := s.BeginWebAuthnRegistration(ctx, uuid.NewString()); err != ErrWebAuthnDisabled {
+		t.Errorf("BeginWebAuthnRegistration = %v, want %v", err, ErrWebAuthnDisabled)
+	}
+	if _, err := s.FinishWebAuthnRegistration(ctx, uuid.NewString(), "", strings.NewReader("{}")); err != ErrWebAuthnDisabled {
+		t.Errorf("FinishWebAuthnRegistration = %v, want %v", err, ErrWebAuthnDisabled)
+	}
+	if _, err := s.BeginWebAuthnLogin(ctx, ""); err != ErrWebAuthnDisabled {
+		t.Errorf("BeginWebAuthnLogin = %v, want %v", err, ErrWebAuthnDisabled)
+	}
+	if _, _, err := s.FinishWebAuthnLogin(ctx, strings.NewReader("{}")); err != ErrWebAuthnDisabled {
+		t.Errorf("FinishWebAuthnLogin = %v, want %v", err, ErrWebAuthnDisabled)
+	}
+}
+
+func TestWebAuthnMalformedResponses(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.WebAuthn = config.WebAuthnConfig{Enabled: true, RPID: "example.com", RPDisplayName: "Example", RPOrigins: []string{"https://example.com"}}
+	s := &AuthServiceImpl{config: cfg, webAuthn: newWebAuthn(cfg)}
+	ctx := context.Background()
+
+	// Rejected before any challenge is looked up or consumed
+	for _, body := range []string{"", "not json", `{"id":"abc","type":"public-key"}`} {
+		if _, _, err := s.FinishWebAuthnLogin(ctx, strings.NewReader(body)); err != ErrInvalidCredentials {
+			t.Errorf("FinishWebAuthnLogin(%q) = %v, want %v", body, err, ErrInvalidCredentials)
+		}
+		if _, err := s.FinishWebAuthnRegistration(ctx, uuid.NewString(), "", strings.NewReader(body)); err != ErrInvalidCredentials {
+			t.Errorf("FinishWebAuthnRegistration(%q) = %v, want %v", body, err, ErrInvalidCredentials)
+		}
+	}
+	if _, err := s.FinishWebAuthnRegistration(ctx, "not-a-uuid", "", strings.NewReader("{}")); err != ErrUserNotFound {
+		t.Errorf("FinishWebAuthnRegistration for a bad user = %v, want %v", err, ErrUserNotFound)
+	}
+	if _, err := s.BeginWebAuthnRegistration(ctx, "not-a-uuid"); err != ErrUserNotFound {
+		t.Errorf("BeginWebAuthnRegistration for a bad user = %v, want %v", err, ErrUserNotFound)
+	}
+}
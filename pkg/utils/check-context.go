@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"encoding/json"
 	"errors"
 
 	"github.com/gin-gonic/gin"
+	"github.com/minilikmila/standard-auth-go/internal/domain/model"
 )
 
 func CheckUserContext(ctx *gin.Context, name string) (any, error) {
@@ -14,3 +16,27 @@ func CheckUserContext(ctx *gin.Context, name string) (any, error) {
 	}
 	return ctx_user, nil
 }
+
+// GetUserContext decodes the authenticated user attached by the Authenticate middleware, client tokens have none.
+// API key requests are refused too, a key must not manage the account that owns it.
+func GetUserContext(ctx *gin.Context) (*model.UserCtxData, error) {
+	ctxUser, err := CheckUserContext(ctx, "user_data")
+	if err != nil {
+		return nil, err
+	}
+
+	userBytes, ok := ctxUser.([]byte)
+	if !ok {
+		return nil, errors.New("error: malformed user context")
+	}
+
+	var userData model.UserCtxData
+	if err := json.Unmarshal(userBytes, &userData); err != nil {
+		return nil, err
+	}
+	// Client credentials tokens and API keys only reach routes guarded by permissions
+	if userData.ID == "" || userData.APIKeyID != "" {
+		return nil, errors.New("error: no user in context")
+	}
+	return &userData, nil
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 )
@@ -10,3 +11,11 @@ func HashToken(token string) string {
 	h.Write([]byte(token))
 	return hex.EncodeToString(h.Sum(nil))
 }
+
+// HashCode keys the hash of a short code with a server secret, a plain hash of a 6-digit code is reversed by
+// trying all million of them
+func HashCode(code, key string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
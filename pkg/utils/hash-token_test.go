@@ -0,0 +1,31 @@
+package utils
+
+import "testing"
+
+func TestHashCode(t *testing.T) {
+	// RFC 4231 test case 2
+	if got := HashCode("what do ya want for nothing?", "Jefe"); got != "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843" {
+		t.Errorf("HashCode = %s, want the RFC 4231 HMAC-SHA256", got)
+	}
+
+	tests := []struct {
+		name      string
+		code, key string
+		same      bool
+	}{
+		{"same code and key", "123456", "secret", true},
+		{"other code", "123457", "secret", false},
+		{"other key", "123456", "other-secret", false},
+	}
+	reference := HashCode("123456", "secret")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashCode(tt.code, tt.key) == reference; got != tt.same {
+				t.Errorf("HashCode(%q, %q) matching the reference = %v, want %v", tt.code, tt.key, got, tt.same)
+			}
+		})
+	}
+	if HashCode("123456", "secret") == HashToken("123456") {
+		t.Error("HashCode is the unkeyed hash")
+	}
+}
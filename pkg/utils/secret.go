@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// sealedPrefix marks values sealed by EncryptSecret, secrets stored before they were encrypted don't have it
+const sealedPrefix = "v1:"
+
+var (
+	ErrNoSecretKey     = errors.New("no key to encrypt secrets with")
+	ErrMalformedSecret = errors.New("malformed encrypted secret")
+)
+
+// secretCipher is AES-256-GCM under the SHA-256 of the server secret, so any long random string works as key
+func secretCipher(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, ErrNoSecretKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret seals a secret that has to be read back, like a TOTP shared secret, for storage. The value is
+// bound to owner: sealed for one account it doesn't open for another.
+func EncryptSecret(secret, key, owner string) (string, error) {
+	gcm, err := secretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), []byte(owner))
+	return sealedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret opens a value sealed by EncryptSecret for the same owner. A value without the prefix was stored
+// before secrets were encrypted, it is returned as is and reported as plaintext so the caller can seal it.
+func DecryptSecret(stored, key, owner string) (string, bool, error) {
+	if !strings.HasPrefix(stored, sealedPrefix) {
+		return stored, true, nil
+	}
+	gcm, err := secretCipher(key)
+	if err != nil {
+		return "", false, err
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", false, ErrMalformedSecret
+	}
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(owner))
+	if err != nil {
+		return "", false, ErrMalformedSecret
+	}
+	return string(secret), false, nil
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	const secret, key, owner = "JBSWY3DPEHPK3PXP", "a-long-random-secret", "user-1"
+
+	sealed, err := EncryptSecret(secret, key, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, secret) {
+		t.Fatalf("sealed value %s contains the secret", sealed)
+	}
+	if again, _ := EncryptSecret(secret, key, owner); again == sealed {
+		t.Error("sealing twice gave the same value, the nonce isn't random")
+	}
+
+	got, plaintext, err := DecryptSecret(sealed, key, owner)
+	if err != nil || got != secret || plaintext {
+		t.Errorf("DecryptSecret = %q, %v, %v, want %q sealed", got, plaintext, err, secret)
+	}
+
+	tampered := []byte(sealed)
+	if tampered[len(tampered)/2] == 'A' {
+		tampered[len(tampered)/2] = 'B'
+	} else {
+		tampered[len(tampered)/2] = 'A'
+	}
+
+	tests := []struct {
+		name          string
+		stored        string
+		key, owner    string
+		wantErr       error
+		wantSecret    string
+		wantPlaintext bool
+	}{
+		{"other key", sealed, "other-secret", owner, ErrMalformedSecret, "", false},
+		{"other owner", sealed, key, "user-2", ErrMalformedSecret, "", false},
+		{"tampered", string(tampered), key, owner, ErrMalformedSecret, "", false},
+		{"truncated", sealedPrefix + "AAAA", key, owner, ErrMalformedSecret, "", false},
+		{"no key", sealed, "", owner, ErrNoSecretKey, "", false},
+		{"stored before encryption", secret, key, owner, nil, secret, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, plaintext, err := DecryptSecret(tt.stored, tt.key, tt.owner)
+			if err != tt.wantErr || got != tt.wantSecret || plaintext != tt.wantPlaintext {
+				t.Errorf("DecryptSecret = %q, %v, %v, want %q, %v, %v", got, plaintext, err, tt.wantSecret, tt.wantPlaintext, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := EncryptSecret(secret, "", owner); err != ErrNoSecretKey {
+		t.Errorf("EncryptSecret without a key = %v, want %v", err, ErrNoSecretKey)
+	}
+}
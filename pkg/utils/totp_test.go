@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the RFC 6238 SHA-1 test key "12345678901234567890" in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B vectors, truncated to 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := GenerateTOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode at %d: %v", tt.unix, err)
+		}
+		if code != tt.code {
+			t.Errorf("GenerateTOTPCode at %d = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+
+	if _, err := GenerateTOTPCode("not base32!", 1); err == nil {
+		t.Error("GenerateTOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+	codeAt := func(s int64) string {
+		code, err := GenerateTOTPCode(rfc6238Secret, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		valid    bool
+	}{
+		{"current step", rfc6238Secret, codeAt(step), step, true},
+		{"previous step", rfc6238Secret, codeAt(step - 1), step - 1, true},
+		{"next step", rfc6238Secret, codeAt(step + 1), step + 1, true},
+		{"outside skew", rfc6238Secret, codeAt(step - 2), 0, false},
+		{"wrong code", rfc6238Secret, "000000", 0, false},
+		{"empty code", rfc6238Secret, "", 0, false},
+		{"invalid secret", "not base32!", codeAt(step), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, valid := ValidateTOTPCode(tt.secret, tt.code, now)
+			if valid != tt.valid || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTPCode = (%d, %v), want (%d, %v)", gotStep, valid, tt.wantStep, tt.valid)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q isn't base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret is %d bytes, want 20", len(key))
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Acme Inc", "jane@example.com", rfc6238Secret)
+	for _, want := range []string{
+		"otpauth://totp/Acme%20Inc:jane@example.com?",
+		"secret=" + rfc6238Secret,
+		"issuer=Acme+Inc",
+		"digits=6",
+		"period=30",
+	} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("TOTPURI = %s, missing %s", uri, want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	format := regexp.MustCompile(`^[a-hjkmnp-z2-9]{5}-[a-hjkmnp-z2-9]{5}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		code, err := GenerateRecoveryCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !format.MatchString(code) {
+			t.Errorf("GenerateRecoveryCode = %q, want five readable characters, a dash and five more", code)
+		}
+		if seen[code] {
+			t.Errorf("GenerateRecoveryCode repeated %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"k7q2m-x9d4a", "k7q2mx9d4a"},
+		{"k7q2mx9d4a", "k7q2mx9d4a"},
+		{"K7Q2M-X9D4A", "k7q2mx9d4a"},
+		{"k7q2m x9d4a", "k7q2mx9d4a"},
+		{"  k7q2m-x9d4a\n", "k7q2mx9d4a"},
+		{"k7q-2m x9-d4a", "k7q2mx9d4a"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.code); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	stored := HashRecoveryCode("k7q2m-x9d4a")
+	for _, typed := range []string{"k7q2m-x9d4a", "k7q2mx9d4a", "K7Q2M X9D4A", " k7q2m-x9d4a "} {
+		if got := HashRecoveryCode(typed); got != stored {
+			t.Errorf("HashRecoveryCode(%q) doesn't match the generated code's hash", typed)
+		}
+	}
+	if HashRecoveryCode("k7q2m-x9d4b") == stored {
+		t.Error("different codes hash alike")
+	}
+}